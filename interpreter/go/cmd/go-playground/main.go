@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/boynton/repl"
+	log "github.com/sirupsen/logrus"
+
+	"go-playground/pascal"
+)
+
+func main() {
+	// NOTE: a subcommand comes before the flags of the interpreter.
+	if len(os.Args) > 1 && os.Args[1] == "fmt" {
+		os.Exit(pascal.FormatFiles(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "lsp" {
+		// NOTE: stdout carries the protocol, logs go to stderr.
+		if err := pascal.NewLanguageServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			log.Errorln(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	sourceFile := flag.String("f", "", "A Pascal source file")
+	logLevel := flag.String("v", "INFO", "log level, debug, info, warn")
+	backend := flag.String("backend", "ast", "ast walks the AST, vm compiles it to bytecode run by a VM")
+	debug := flag.Bool("debug", false, "run the program in a debugger reading commands from stdin, type help for them")
+	optimize := flag.Bool("optimize", false, "fold constants and remove dead code before running, the changes are written to stderr")
+	dump := flag.String("dump", "", "instead of running the program, dump its ast, symbols or tokens")
+	dumpFormat := flag.String("dump-format", "json", "the format of -dump, json or dot for Graphviz")
+	timeout := flag.Duration("timeout", 0, "stop the program after this long, 0 for no timeout")
+	maxSteps := flag.Int("max-steps", 0, "stop the program after this many statements, or vm instructions, 0 for no limit")
+	maxDepth := flag.Int("max-depth", 0, "the most nested calls, 0 for the default of 4096")
+	maxMemory := flag.Int("max-memory", 0, "the most variables on the call stack, 0 for no limit")
+	profile := flag.String("profile", "", "write a coverage and profiling report of the run to this file")
+	profileFormat := flag.String("profile-format", "listing", "the format of -profile, listing annotates the source, json or pprof")
+	transpile := flag.String("transpile", "", "instead of running the program, translate it to Go source written to this file, - for stdout")
+	searchPath := flag.String("path", "", "the directories searched for the units the program uses, separated as in $PATH, the source file's by default")
+
+	flag.Parse()
+
+	level, err := log.ParseLevel(*logLevel)
+	if err != nil {
+		log.WithField("level", logLevel).Info("invalid log level")
+		return
+	}
+	log.SetLevel(level)
+	log.SetFormatter(&log.TextFormatter{
+		PadLevelText: true,
+	})
+
+	if *sourceFile == "" {
+		// without a source file, read from stdin interactively.
+		r := pascal.NewREPL(os.Stdin, os.Stdout)
+		if stat, statErr := os.Stdin.Stat(); statErr == nil && stat.Mode()&os.ModeCharDevice != 0 {
+			err = repl.REPL(pascal.TerminalREPL{REPL: r})
+		} else {
+			err = r.Run()
+		}
+		if err != nil {
+			log.Errorln(err)
+		}
+		return
+	}
+
+	source, err := ioutil.ReadFile(*sourceFile)
+	if err != nil {
+		log.WithField("err", err.Error()).Info("read source file")
+		return
+	}
+
+	compileOpts := pascal.CompileOptions{SearchPath: filepath.SplitList(*searchPath)}
+	if len(compileOpts.SearchPath) == 0 {
+		compileOpts.SearchPath = []string{filepath.Dir(*sourceFile)}
+	}
+
+	if *dump != "" {
+		if err = pascal.DumpSource(os.Stdout, string(source), *dump, *dumpFormat, compileOpts); err != nil {
+			pascal.RenderError(os.Stderr, err, *sourceFile, string(source))
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *transpile != "" {
+		if err = writeGo(string(source), *transpile, compileOpts); err != nil {
+			pascal.RenderError(os.Stderr, err, *sourceFile, string(source))
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts := pascal.RunOptions{
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		Backend: pascal.Backend(*backend),
+		Debug:   *debug,
+		Limits: pascal.Limits{
+			Steps:     *maxSteps,
+			CallDepth: *maxDepth,
+			Memory:    *maxMemory,
+		},
+	}
+	if *optimize {
+		opts.Optimizer = pascal.NewOptimizer(os.Stderr)
+	}
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	program, err := pascal.CompileWith(string(source), compileOpts)
+	if err == nil {
+		if *profile != "" {
+			opts.Profiler = pascal.NewProfiler(program)
+		}
+		err = program.Run(ctx, opts)
+	}
+	// NOTE: the report of a run that failed is still written, it shows how
+	// far the program got.
+	if opts.Profiler != nil {
+		if profileErr := writeProfile(opts.Profiler, *profile, *profileFormat, *sourceFile); profileErr != nil {
+			log.Errorln(profileErr)
+			os.Exit(1)
+		}
+	}
+	if err != nil {
+		pascal.RenderError(os.Stderr, err, *sourceFile, string(source))
+		os.Exit(1)
+	}
+}
+
+// writeProfile writes the report of profiler to the file named name.
+func writeProfile(profiler *pascal.Profiler, name, format, sourceFile string) (err error) {
+	file, err := os.Create(name)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	switch format {
+	case "listing":
+		return profiler.WriteListing(file)
+	case "json":
+		return profiler.WriteJSON(file)
+	case "pprof":
+		return profiler.WritePprof(file, sourceFile)
+	}
+	return fmt.Errorf("unknown profile format %q", format)
+}
+
+// writeGo translates source to Go source written to the file named name,
+// or to stdout if it's -.
+func writeGo(source, name string, opts pascal.CompileOptions) (err error) {
+	program, err := pascal.CompileWith(source, opts)
+	if err != nil {
+		return
+	}
+	if name == "-" {
+		return pascal.NewTranspiler(os.Stdout).Transpile(program)
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return pascal.NewTranspiler(file).Transpile(program)
+}
@@ -0,0 +1,151 @@
+package pascal
+
+// The accessors below expose the tree to the tools walking it from outside
+// the package, they read the nodes and never change them. Type returns the
+// type annotated by the SemanticAnalyzer, nil before the tree is checked.
+
+func (n *ProgramNode) Name() string       { return n.name }
+func (n *ProgramNode) Uses() []*Token     { return n.uses }
+func (n *ProgramNode) Block() *BlockNode  { return n.block }
+func (n *ProgramNode) Units() []*UnitNode { return n.units }
+
+func (n *UnitNode) Token() *Token       { return n.token }
+func (n *UnitNode) Name() string        { return n.name }
+func (n *UnitNode) File() string        { return n.file }
+func (n *UnitNode) Uses() []*Token      { return n.uses }
+func (n *UnitNode) Headings() []ASTNode { return n.headings }
+func (n *UnitNode) Block() *BlockNode   { return n.block }
+
+func (n *BlockNode) Declarations() []ASTNode     { return n.declarations }
+func (n *BlockNode) CompoundStmt() *CompoundNode { return n.compoundStmt }
+
+func (n *ProcedureDeclNode) Token() *Token        { return n.token }
+func (n *ProcedureDeclNode) Name() string         { return n.name }
+func (n *ProcedureDeclNode) Params() []*ParamNode { return n.params }
+func (n *ProcedureDeclNode) Block() *BlockNode    { return n.block }
+
+func (n *FunctionDeclNode) Token() *Token        { return n.token }
+func (n *FunctionDeclNode) Name() string         { return n.name }
+func (n *FunctionDeclNode) Params() []*ParamNode { return n.params }
+func (n *FunctionDeclNode) ReturnType() *TypNode { return n.returnType }
+func (n *FunctionDeclNode) Block() *BlockNode    { return n.block }
+
+func (n *ParamNode) VarNode() *VarNode { return n.varNode }
+func (n *ParamNode) TypNode() *TypNode { return n.typNode }
+func (n *ParamNode) ByReference() bool { return n.byReference }
+
+func (n *VarDeclNode) VarNode() *VarNode { return n.varNode }
+func (n *VarDeclNode) TypNode() ASTNode  { return n.typNode }
+
+func (n *ConstDeclNode) Token() *Token  { return n.token }
+func (n *ConstDeclNode) Name() string   { return n.name }
+func (n *ConstDeclNode) Value() ASTNode { return n.value }
+
+func (n *TypeDeclNode) Token() *Token    { return n.token }
+func (n *TypeDeclNode) Name() string     { return n.name }
+func (n *TypeDeclNode) TypNode() ASTNode { return n.typNode }
+func (n *TypeDeclNode) Type() Symbol     { return n.typ }
+
+func (n *ArrayTypeNode) Token() *Token     { return n.token }
+func (n *ArrayTypeNode) Low() ASTNode      { return n.low }
+func (n *ArrayTypeNode) High() ASTNode     { return n.high }
+func (n *ArrayTypeNode) ElemType() ASTNode { return n.elemType }
+
+func (n *RecordTypeNode) Token() *Token          { return n.token }
+func (n *RecordTypeNode) Fields() []*VarDeclNode { return n.fields }
+
+func (n *EnumTypeNode) Token() *Token    { return n.token }
+func (n *EnumTypeNode) Values() []*Token { return n.values }
+
+func (n *TypNode) Token() *Token { return n.token }
+func (n *TypNode) Name() string  { return n.value }
+
+func (n *CompoundNode) Children() []ASTNode { return n.children }
+
+func (n *AssignNode) Token() *Token  { return n.token }
+func (n *AssignNode) Left() ASTNode  { return n.left }
+func (n *AssignNode) Right() ASTNode { return n.right }
+
+func (n *ProcedureCallNode) Token() *Token           { return n.token }
+func (n *ProcedureCallNode) Name() string            { return n.name }
+func (n *ProcedureCallNode) ActualParams() []ASTNode { return n.actualParams }
+
+// Builtin reports whether the procedure called is WRITE, WRITELN or READLN
+// rather than declared by the program.
+func (n *ProcedureCallNode) Builtin() bool { return n.builtin != nil }
+
+func (n *FunctionCallNode) Token() *Token           { return n.token }
+func (n *FunctionCallNode) Name() string            { return n.name }
+func (n *FunctionCallNode) ActualParams() []ASTNode { return n.actualParams }
+func (n *FunctionCallNode) Type() Symbol            { return n.typ }
+
+// Builtin reports whether the function called is implemented by the
+// interpreter rather than declared by the program.
+func (n *FunctionCallNode) Builtin() bool { return n.builtin != nil }
+
+func (n *VarNode) Token() *Token { return n.token }
+func (n *VarNode) Name() string  { return n.value }
+func (n *VarNode) Type() Symbol  { return n.typ }
+
+// Constant reports whether the node names a constant rather than a variable.
+func (n *VarNode) Constant() bool { return n.constant != nil }
+
+func (n *IndexNode) Token() *Token  { return n.token }
+func (n *IndexNode) Base() ASTNode  { return n.base }
+func (n *IndexNode) Index() ASTNode { return n.index }
+func (n *IndexNode) Type() Symbol   { return n.typ }
+
+func (n *FieldNode) Token() *Token { return n.token }
+func (n *FieldNode) Base() ASTNode { return n.base }
+func (n *FieldNode) Field() string { return n.field }
+func (n *FieldNode) Type() Symbol  { return n.typ }
+
+func (n *NumNode) Token() *Token { return n.token }
+func (n *NumNode) Type() Symbol  { return n.typ }
+
+// Value returns the literal, an int64 or a float64 for a REAL one.
+func (n *NumNode) Value() interface{} {
+	value, _ := literal(n)
+	return value
+}
+
+func (n *BoolNode) Token() *Token { return n.token }
+func (n *BoolNode) Value() bool   { return n.value }
+func (n *BoolNode) Type() Symbol  { return n.typ }
+
+func (n *StrNode) Token() *Token { return n.token }
+func (n *StrNode) Value() string { return n.value }
+func (n *StrNode) Type() Symbol  { return n.typ }
+
+func (n *UnaryOpNode) Token() *Token    { return n.token }
+func (n *UnaryOpNode) Op() TokenKind    { return n.op }
+func (n *UnaryOpNode) Operand() ASTNode { return n.operand }
+func (n *UnaryOpNode) Type() Symbol     { return n.typ }
+
+func (n *BinOpNode) Token() *Token  { return n.token }
+func (n *BinOpNode) Op() TokenKind  { return n.op }
+func (n *BinOpNode) Left() ASTNode  { return n.left }
+func (n *BinOpNode) Right() ASTNode { return n.right }
+func (n *BinOpNode) Type() Symbol   { return n.typ }
+
+func (n *IfNode) Token() *Token      { return n.token }
+func (n *IfNode) Condition() ASTNode { return n.condition }
+func (n *IfNode) ThenStmt() ASTNode  { return n.thenStmt }
+
+// ElseStmt returns nil if there's no ELSE.
+func (n *IfNode) ElseStmt() ASTNode { return n.elseStmt }
+
+func (n *WhileNode) Token() *Token      { return n.token }
+func (n *WhileNode) Condition() ASTNode { return n.condition }
+func (n *WhileNode) Body() ASTNode      { return n.body }
+
+func (n *RepeatNode) Token() *Token       { return n.token }
+func (n *RepeatNode) Children() []ASTNode { return n.children }
+func (n *RepeatNode) Condition() ASTNode  { return n.condition }
+
+func (n *ForNode) Token() *Token     { return n.token }
+func (n *ForNode) VarNode() *VarNode { return n.varNode }
+func (n *ForNode) Start() ASTNode    { return n.start }
+func (n *ForNode) End() ASTNode      { return n.end }
+func (n *ForNode) Downto() bool      { return n.downto }
+func (n *ForNode) Body() ASTNode     { return n.body }
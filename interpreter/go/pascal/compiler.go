@@ -0,0 +1,635 @@
+package pascal
+
+import (
+	"fmt"
+	"strings"
+)
+
+type OpCode int
+
+const (
+	// OpConst pushes Consts[A].
+	OpConst OpCode = iota + 1
+	// OpLoad pushes slot B of the frame A static links away.
+	OpLoad
+	// OpStore pops into slot B of the frame A static links away, an ARRAY
+	// or a RECORD is copied.
+	OpStore
+	// OpToReal widens the INTEGER on top of the stack into a REAL.
+	OpToReal
+	// OpNeg negates the number on top of the stack.
+	OpNeg
+	// OpNot negates the BOOLEAN on top of the stack.
+	OpNot
+	// OpBinary pops the right and left operands and pushes the result of
+	// the operator TokenKind(A).
+	OpBinary
+	// OpJump jumps to A.
+	OpJump
+	// OpJumpIfFalse pops a BOOLEAN and jumps to A if it's FALSE.
+	OpJumpIfFalse
+	// OpCall calls Routines[A], its static link is the frame B static links
+	// away from the caller, its arguments are on the stack.
+	OpCall
+	// OpReturn leaves the current routine, a function pushes its result.
+	OpReturn
+	// OpWrite pops and writes A values, then a new line if B is 1.
+	OpWrite
+	// OpReadLine reads a line and pushes its A fields, first field on top, the
+	// field B being the rest of the line.
+	OpReadLine
+	// OpParse replaces the field on top of the stack by its value of type TokenKind(A).
+	OpParse
+	// OpRef pushes a reference to slot B of the frame A static links away,
+	// the argument of a VAR parameter, LOAD and STORE follow it.
+	OpRef
+	// OpRefIndex pops an index and an ARRAY and pushes a reference to the
+	// element, the argument of a VAR parameter.
+	OpRefIndex
+	// OpRefField pops a RECORD and pushes a reference to its field A, the
+	// argument of a VAR parameter.
+	OpRefField
+	// OpIndex pops an index and an ARRAY and pushes the element.
+	OpIndex
+	// OpStoreIndex pops an index, an ARRAY and a value stored into the element.
+	OpStoreIndex
+	// OpField pops a RECORD and pushes its field A.
+	OpField
+	// OpStoreField pops a RECORD and a value stored into its field A.
+	OpStoreField
+	// OpBuiltin pops B arguments and pushes the result of builtinFunctions[A].
+	OpBuiltin
+	// OpNew pushes a new ARRAY or RECORD of the type Consts[A], if it fits
+	// the memory limit.
+	OpNew
+)
+
+func (op OpCode) String() string {
+	switch op {
+	case OpConst:
+		return "CONST"
+	case OpLoad:
+		return "LOAD"
+	case OpStore:
+		return "STORE"
+	case OpToReal:
+		return "TO_REAL"
+	case OpNeg:
+		return "NEG"
+	case OpNot:
+		return "NOT"
+	case OpBinary:
+		return "BINARY"
+	case OpJump:
+		return "JUMP"
+	case OpJumpIfFalse:
+		return "JUMP_IF_FALSE"
+	case OpCall:
+		return "CALL"
+	case OpReturn:
+		return "RETURN"
+	case OpWrite:
+		return "WRITE"
+	case OpReadLine:
+		return "READ_LINE"
+	case OpParse:
+		return "PARSE"
+	case OpRef:
+		return "REF"
+	case OpRefIndex:
+		return "REF_INDEX"
+	case OpRefField:
+		return "REF_FIELD"
+	case OpIndex:
+		return "INDEX"
+	case OpStoreIndex:
+		return "STORE_INDEX"
+	case OpField:
+		return "FIELD"
+	case OpStoreField:
+		return "STORE_FIELD"
+	case OpBuiltin:
+		return "BUILTIN"
+	case OpNew:
+		return "NEW"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+type Instruction struct {
+	Op OpCode
+	A  int
+	B  int
+	// Token is where runtime errors of the instruction are reported.
+	Token *Token
+}
+
+func (ins Instruction) String() string {
+	switch ins.Op {
+	case OpBinary, OpParse:
+		return fmt.Sprintf("%s %s", ins.Op, TokenKind(ins.A))
+	case OpBuiltin:
+		return fmt.Sprintf("%s %s %d", ins.Op, builtinFunctions[ins.A], ins.B)
+	case OpConst, OpJump, OpJumpIfFalse, OpRefField, OpField, OpStoreField, OpNew:
+		return fmt.Sprintf("%s %d", ins.Op, ins.A)
+	case OpLoad, OpStore, OpCall, OpWrite, OpRef, OpReadLine:
+		return fmt.Sprintf("%s %d %d", ins.Op, ins.A, ins.B)
+	}
+	return ins.Op.String()
+}
+
+// Routine is the compiled code of the program, a procedure or a function.
+type Routine struct {
+	Name string
+	Kind ARKind
+	// Level is the nesting level of the routine's frames, 1 for the program
+	// or, if it uses units, the first one.
+	Level     int
+	NumParams int
+	NumSlots  int
+	// ResultSlot holds the result of a function.
+	ResultSlot int
+	Code       []Instruction
+}
+
+// Bytecode is a compiled program, Routines[0] runs first: the program
+// itself or the first unit it uses.
+type Bytecode struct {
+	Routines []*Routine
+	Consts   []interface{}
+}
+
+// String disassembles the bytecode.
+func (b *Bytecode) String() string {
+	sb := strings.Builder{}
+	for i, routine := range b.Routines {
+		sb.WriteString(fmt.Sprintf("%d: %s %s level=%d params=%d slots=%d\n",
+			i, routine.Kind, routine.Name, routine.Level, routine.NumParams, routine.NumSlots))
+		for pc, ins := range routine.Code {
+			sb.WriteString(fmt.Sprintf("\t%04d %s", pc, ins))
+			switch ins.Op {
+			case OpConst:
+				sb.WriteString(fmt.Sprintf(" (%s)", formatValue(b.Consts[ins.A])))
+			case OpNew:
+				sb.WriteString(fmt.Sprintf(" (%s)", b.Consts[ins.A].(Symbol).GetName()))
+			}
+			sb.WriteRune('\n')
+		}
+	}
+	return sb.String()
+}
+
+// compileScope maps the names of a routine to the slots of its frames and
+// the routines declared in it.
+type compileScope struct {
+	level     int
+	routine   *Routine
+	slots     map[string]int
+	routines  map[string]int
+	enclosing *compileScope
+}
+
+func NewCompiler() *Compiler {
+	return &Compiler{bytecode: &Bytecode{}}
+}
+
+var _ Visitor = (*Compiler)(nil)
+
+// Compiler compiles an AST checked by the SemanticAnalyzer into Bytecode.
+// Variables are addressed lexically by the number of static links to
+// follow and their slot in the frame found.
+type Compiler struct {
+	bytecode *Bytecode
+	scope    *compileScope
+	// err is the first bug of the compiler met, Compile returns it.
+	err error
+}
+
+func (c *Compiler) Compile(root ASTNode) (bytecode *Bytecode, err error) {
+	if err = Walk(c, root); err != nil {
+		return
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.bytecode, nil
+}
+
+func (c *Compiler) Before(node ASTNode) (shouldStepIn bool, err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		// NOTE: a unit is a routine initializing it, which then calls the
+		// next unit nested in it, the last one calls the program.
+		units := make([]int, len(n.units))
+		for i, unit := range n.units {
+			units[i] = len(c.bytecode.Routines)
+			c.enter(unit.name, ARKindUnit, nil)
+			if err = Walk(c, unit.block); err != nil {
+				return
+			}
+		}
+		next := len(c.bytecode.Routines)
+		c.enter(n.name, ARKindProgram, nil)
+		if err = Walk(c, n.block); err != nil {
+			return
+		}
+		c.leave()
+		for i := len(units) - 1; i >= 0; i-- {
+			c.emit(OpCall, next, 0, nil)
+			c.leave()
+			next = units[i]
+		}
+		return false, nil
+	case *BlockNode:
+	case *CompoundNode:
+	case *VarDeclNode:
+		slot := c.slot(n.varNode.value)
+		// an ARRAY or a RECORD is allocated as it's declared.
+		switch n.varNode.typ.(type) {
+		case *ArrayTypeSymbol, *RecordTypeSymbol:
+			c.emit(OpNew, c.constant(n.varNode.typ), 0, n.varNode.token)
+			c.emit(OpStore, 0, slot, nil)
+		}
+		return false, nil
+	case *ConstDeclNode, *TypeDeclNode:
+		return false, nil
+	case *ProcedureDeclNode:
+		c.enter(n.name, ARKindProcedure, n.params)
+		if err = Walk(c, n.block); err != nil {
+			return
+		}
+		c.leave()
+		return false, nil
+	case *FunctionDeclNode:
+		routine := c.enter(n.name, ARKindFunction, n.params)
+		routine.ResultSlot = c.slot(n.name)
+		if err = Walk(c, n.block); err != nil {
+			return
+		}
+		c.leave()
+		return false, nil
+	case *ProcedureCallNode:
+		if n.builtin != nil {
+			c.callBuiltin(n)
+			return false, nil
+		}
+		c.call(n.name, n.procSymbol.scopeLevel, n.procSymbol.formalParams, n.actualParams, n.token)
+		return false, nil
+	case *AssignNode:
+		c.expr(n.right)
+		c.widen(typeOf(n.left), n.right)
+		c.assign(n.left)
+		return false, nil
+	case *IfNode:
+		c.expr(n.condition)
+		jumpToElse := c.emit(OpJumpIfFalse, 0, 0, nil)
+		if err = Walk(c, n.thenStmt); err != nil {
+			return
+		}
+		if n.elseStmt == nil {
+			c.patch(jumpToElse)
+			return false, nil
+		}
+		jumpToEnd := c.emit(OpJump, 0, 0, nil)
+		c.patch(jumpToElse)
+		if err = Walk(c, n.elseStmt); err != nil {
+			return
+		}
+		c.patch(jumpToEnd)
+		return false, nil
+	case *WhileNode:
+		start := len(c.scope.routine.Code)
+		c.expr(n.condition)
+		jumpToEnd := c.emit(OpJumpIfFalse, 0, 0, nil)
+		if err = Walk(c, n.body); err != nil {
+			return
+		}
+		// NOTE: the jump back has the loop's token, the body may have none.
+		c.emit(OpJump, start, 0, n.token)
+		c.patch(jumpToEnd)
+		return false, nil
+	case *RepeatNode:
+		start := len(c.scope.routine.Code)
+		for _, child := range n.children {
+			if err = Walk(c, child); err != nil {
+				return
+			}
+		}
+		c.expr(n.condition)
+		c.emit(OpJumpIfFalse, start, 0, n.token)
+		return false, nil
+	case *ForNode:
+		// NOTE: the bounds are evaluated once and the loop counts in hidden
+		// slots, assignments to the control variable in the body don't
+		// change the number of iterations, as in the Interpreter.
+		counter, end := c.slot(""), c.slot("")
+		c.expr(n.start)
+		c.emit(OpStore, 0, counter, nil)
+		c.expr(n.end)
+		c.emit(OpStore, 0, end, nil)
+		compare, step := LessEqual, Plus
+		if n.downto {
+			compare, step = GreaterEqual, Minus
+		}
+		start := c.emit(OpLoad, 0, counter, nil)
+		c.emit(OpLoad, 0, end, nil)
+		c.emit(OpBinary, int(compare), 0, n.token)
+		jumpToEnd := c.emit(OpJumpIfFalse, 0, 0, nil)
+		c.emit(OpLoad, 0, counter, nil)
+		c.store(n.varNode)
+		if err = Walk(c, n.body); err != nil {
+			return
+		}
+		c.emit(OpLoad, 0, counter, nil)
+		c.emit(OpConst, c.constant(int64(1)), 0, nil)
+		c.emit(OpBinary, int(step), 0, n.token)
+		c.emit(OpStore, 0, counter, nil)
+		c.emit(OpJump, start, 0, nil)
+		c.patch(jumpToEnd)
+		return false, nil
+	case *NoopNode:
+	default:
+		return false, c.internal("unexpected node %T", n)
+	}
+	return true, nil
+}
+
+func (c *Compiler) After(node ASTNode) (err error) {
+	return
+}
+
+func (c *Compiler) expr(node ASTNode) {
+	switch n := node.(type) {
+	case *NumNode:
+		if n.token.Kind == IntegerConst {
+			c.emit(OpConst, c.constant(int64(n.intValue)), 0, nil)
+		} else {
+			c.emit(OpConst, c.constant(n.floatValue), 0, nil)
+		}
+	case *BoolNode:
+		c.emit(OpConst, c.constant(n.value), 0, nil)
+	case *StrNode:
+		c.emit(OpConst, c.constant(n.value), 0, nil)
+	case *VarNode:
+		if n.constant != nil {
+			c.emit(OpConst, c.constant(n.constant.value), 0, nil)
+			return
+		}
+		hops, slot := c.resolve(n)
+		c.emit(OpLoad, hops, slot, n.token)
+	case *FunctionCallNode:
+		if n.builtin != nil {
+			c.callBuiltinFunction(n)
+			return
+		}
+		c.call(n.name, n.funcSymbol.scopeLevel, n.funcSymbol.formalParams, n.actualParams, n.token)
+	case *IndexNode:
+		c.expr(n.base)
+		c.expr(n.index)
+		c.emit(OpIndex, 0, 0, n.token)
+	case *FieldNode:
+		c.expr(n.base)
+		c.emit(OpField, n.index, 0, n.token)
+	case *UnaryOpNode:
+		c.expr(n.operand)
+		switch n.op {
+		case Minus:
+			c.emit(OpNeg, 0, 0, n.token)
+		case Not:
+			c.emit(OpNot, 0, 0, n.token)
+		}
+	case *BinOpNode:
+		// NOTE: AND and OR short-circuit, the right operand is only evaluated when needed.
+		switch n.op {
+		case And:
+			c.expr(n.left)
+			jumpToFalse := c.emit(OpJumpIfFalse, 0, 0, nil)
+			c.expr(n.right)
+			jumpToEnd := c.emit(OpJump, 0, 0, nil)
+			c.patch(jumpToFalse)
+			c.emit(OpConst, c.constant(false), 0, nil)
+			c.patch(jumpToEnd)
+		case Or:
+			c.expr(n.left)
+			jumpToRight := c.emit(OpJumpIfFalse, 0, 0, nil)
+			c.emit(OpConst, c.constant(true), 0, nil)
+			jumpToEnd := c.emit(OpJump, 0, 0, nil)
+			c.patch(jumpToRight)
+			c.expr(n.right)
+			c.patch(jumpToEnd)
+		default:
+			c.expr(n.left)
+			c.expr(n.right)
+			c.emit(OpBinary, int(n.op), 0, n.token)
+		}
+	default:
+		c.internal("unexpected expression %T", n)
+	}
+}
+
+func (c *Compiler) callBuiltin(n *ProcedureCallNode) {
+	switch n.name {
+	case "WRITE", "WRITELN":
+		for _, actualParam := range n.actualParams {
+			c.expr(actualParam)
+		}
+		newLine := 0
+		if n.name == "WRITELN" {
+			newLine = 1
+		}
+		c.emit(OpWrite, len(n.actualParams), newLine, n.token)
+	case "READLN":
+		c.emit(OpReadLine, len(n.actualParams), restField(n.actualParams), n.token)
+		for _, actualParam := range n.actualParams {
+			c.emit(OpParse, int(TokenValues[typeOf(actualParam).GetName()]), 0, n.token)
+			c.assign(actualParam)
+		}
+	default:
+		c.internal("unknown builtin procedure %s", n.name)
+	}
+}
+
+func (c *Compiler) callBuiltinFunction(n *FunctionCallNode) {
+	for _, actualParam := range n.actualParams {
+		c.expr(actualParam)
+	}
+	for i, name := range builtinFunctions {
+		if name == n.name {
+			c.emit(OpBuiltin, i, len(n.actualParams), n.token)
+			return
+		}
+	}
+	c.internal("unknown builtin function %s", n.name)
+}
+
+// call pushes the actual parameters and calls the routine name, declared at
+// level.
+func (c *Compiler) call(name string, level int, formalParams []*VarSymbol, actualParams []ASTNode, token *Token) {
+	for i, actualParam := range actualParams {
+		if formalParams[i].byReference {
+			c.reference(actualParam)
+			continue
+		}
+		c.expr(actualParam)
+		c.widen(formalParams[i].typ, actualParam)
+	}
+	scope := c.scopeAt(level)
+	if index, ok := scope.routines[name]; ok {
+		// the callee is nested in scope, its static link is scope's frame.
+		c.emit(OpCall, index, c.scope.level-scope.level, token)
+		return
+	}
+	c.internal("routine %s not found", name)
+}
+
+// reference pushes a reference to a variable, an element or a field, the
+// argument of a VAR parameter.
+func (c *Compiler) reference(node ASTNode) {
+	switch n := node.(type) {
+	case *VarNode:
+		hops, slot := c.resolve(n)
+		c.emit(OpRef, hops, slot, nil)
+	case *IndexNode:
+		c.expr(n.base)
+		c.expr(n.index)
+		c.emit(OpRefIndex, 0, 0, n.token)
+	case *FieldNode:
+		c.expr(n.base)
+		c.emit(OpRefField, n.index, 0, nil)
+	default:
+		c.internal("unexpected VAR argument %T", n)
+	}
+}
+
+// widen converts an INTEGER expression stored into a REAL.
+func (c *Compiler) widen(to Symbol, from ASTNode) {
+	if fromType := typeOf(from); to != nil && fromType != nil &&
+		to.GetName() == TokenNames[Real] && fromType.GetName() == TokenNames[Integer] {
+		c.emit(OpToReal, 0, 0, nil)
+	}
+}
+
+// assign pops the value on top of the stack into a variable, an element or a field.
+func (c *Compiler) assign(node ASTNode) {
+	switch n := node.(type) {
+	case *VarNode:
+		c.store(n)
+	case *IndexNode:
+		c.expr(n.base)
+		c.expr(n.index)
+		c.emit(OpStoreIndex, 0, 0, n.token)
+	case *FieldNode:
+		c.expr(n.base)
+		c.emit(OpStoreField, n.index, 0, n.token)
+	default:
+		c.internal("unexpected assignment target %T", n)
+	}
+}
+
+func (c *Compiler) store(n *VarNode) {
+	hops, slot := c.resolve(n)
+	c.emit(OpStore, hops, slot, nil)
+}
+
+// resolve returns the lexical address of a variable, in the scope of the
+// level the SemanticAnalyzer annotated it with.
+func (c *Compiler) resolve(n *VarNode) (hops int, slot int) {
+	scope := c.scopeAt(n.level)
+	slot, ok := scope.slots[n.value]
+	if !ok {
+		c.internal("variable %s not found", n.value)
+	}
+	return c.scope.level - scope.level, slot
+}
+
+// scopeAt returns the scope of level, the current one or one enclosing it.
+func (c *Compiler) scopeAt(level int) *compileScope {
+	scope := c.scope
+	for scope.level > level && scope.enclosing != nil {
+		scope = scope.enclosing
+	}
+	return scope
+}
+
+// enter starts compiling a new routine, whose formal parameters take the first slots.
+func (c *Compiler) enter(name string, kind ARKind, params []*ParamNode) *Routine {
+	level := 1
+	if c.scope != nil {
+		level = c.scope.level + 1
+	}
+	routine := &Routine{Name: name, Kind: kind, Level: level, NumParams: len(params)}
+	// NOTE: the program and the units are called by index so that their names
+	// never clash with those of routines.
+	if c.scope != nil && kind != ARKindProgram && kind != ARKindUnit {
+		// NOTE: registered before its block is compiled, so that it can recurse.
+		c.scope.routines[name] = len(c.bytecode.Routines)
+	}
+	c.bytecode.Routines = append(c.bytecode.Routines, routine)
+	c.scope = &compileScope{
+		level:     level,
+		routine:   routine,
+		slots:     make(map[string]int),
+		routines:  make(map[string]int),
+		enclosing: c.scope,
+	}
+	for _, param := range params {
+		c.slot(param.varNode.value)
+	}
+	return routine
+}
+
+func (c *Compiler) leave() {
+	c.emit(OpReturn, 0, 0, nil)
+	c.scope = c.scope.enclosing
+}
+
+// slot allocates a slot in the current routine's frames, an empty name
+// allocates a hidden one.
+func (c *Compiler) slot(name string) int {
+	if slot, ok := c.scope.slots[name]; ok && name != "" {
+		return slot
+	}
+	slot := c.scope.routine.NumSlots
+	c.scope.routine.NumSlots++
+	if name != "" {
+		c.scope.slots[name] = slot
+	}
+	return slot
+}
+
+func (c *Compiler) constant(value interface{}) int {
+	for i, v := range c.bytecode.Consts {
+		if v == value {
+			return i
+		}
+	}
+	c.bytecode.Consts = append(c.bytecode.Consts, value)
+	return len(c.bytecode.Consts) - 1
+}
+
+// emit appends an instruction to the current routine and returns its address.
+func (c *Compiler) emit(op OpCode, a, b int, token *Token) int {
+	c.scope.routine.Code = append(c.scope.routine.Code, Instruction{Op: op, A: a, B: b, Token: token})
+	return len(c.scope.routine.Code) - 1
+}
+
+// internal records a bug of the compiler, the checked program can't cause
+// it, and returns it.
+func (c *Compiler) internal(format string, args ...interface{}) error {
+	err := Error{
+		Code:       ErrorCodeInternal,
+		Module:     ModuleInterpreter,
+		Message:    fmt.Sprintf(format, args...),
+		Suggestion: runtimeSuggestion(ErrorCodeInternal),
+	}
+	if c.err == nil {
+		c.err = err
+	}
+	return err
+}
+
+// patch makes the jump at address land on the next instruction emitted.
+func (c *Compiler) patch(address int) {
+	c.scope.routine.Code[address].A = len(c.scope.routine.Code)
+}
@@ -0,0 +1,236 @@
+package pascal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompiler_Compile(t *testing.T) {
+	tests := map[string]struct {
+		givenSource  string
+		wantBytecode string
+	}{
+		"assignments and widening": {
+			givenSource: `
+				program Main;
+					var a : integer; r : real;
+				begin
+					a := 1 + 2;
+					r := a
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=2\n" +
+				"\t0000 CONST 0 (1)\n" +
+				"\t0001 CONST 1 (2)\n" +
+				"\t0002 BINARY +\n" +
+				"\t0003 STORE 0 0\n" +
+				"\t0004 LOAD 0 0\n" +
+				"\t0005 TO_REAL\n" +
+				"\t0006 STORE 0 1\n" +
+				"\t0007 RETURN\n",
+		},
+		"while and short circuit": {
+			givenSource: `
+				program Main;
+					var ok : boolean;
+				begin
+					ok := true;
+					while ok and false do ok := not ok
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=1\n" +
+				"\t0000 CONST 0 (TRUE)\n" +
+				"\t0001 STORE 0 0\n" +
+				"\t0002 LOAD 0 0\n" +
+				"\t0003 JUMP_IF_FALSE 6\n" +
+				"\t0004 CONST 1 (FALSE)\n" +
+				"\t0005 JUMP 7\n" +
+				"\t0006 CONST 1 (FALSE)\n" +
+				"\t0007 JUMP_IF_FALSE 12\n" +
+				"\t0008 LOAD 0 0\n" +
+				"\t0009 NOT\n" +
+				"\t0010 STORE 0 0\n" +
+				"\t0011 JUMP 2\n" +
+				"\t0012 RETURN\n",
+		},
+		"nested routines": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Twice(n : integer): integer;
+						procedure Reset;
+						begin
+							x := 0
+						end;
+					begin
+						Reset;
+						Twice := n * 2
+					end;
+				begin
+					x := Twice(3)
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=1\n" +
+				"\t0000 CONST 2 (3)\n" +
+				"\t0001 CALL 1 0\n" +
+				"\t0002 STORE 0 0\n" +
+				"\t0003 RETURN\n" +
+				"1: FUNCTION TWICE level=2 params=1 slots=2\n" +
+				"\t0000 CALL 2 0\n" +
+				"\t0001 LOAD 0 0\n" +
+				"\t0002 CONST 1 (2)\n" +
+				"\t0003 BINARY *\n" +
+				"\t0004 STORE 0 1\n" +
+				"\t0005 RETURN\n" +
+				"2: PROCEDURE RESET level=3 params=0 slots=0\n" +
+				"\t0000 CONST 0 (0)\n" +
+				"\t0001 STORE 2 0\n" +
+				"\t0002 RETURN\n",
+		},
+		"var parameters": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					procedure Inc(var n : integer);
+						procedure Again;
+						begin
+							Inc(n)
+						end;
+					begin
+						n := n + 1
+					end;
+				begin
+					x := 0;
+					Inc(x)
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=1\n" +
+				"\t0000 CONST 1 (0)\n" +
+				"\t0001 STORE 0 0\n" +
+				"\t0002 REF 0 0\n" +
+				"\t0003 CALL 1 0\n" +
+				"\t0004 RETURN\n" +
+				"1: PROCEDURE INC level=2 params=1 slots=1\n" +
+				"\t0000 LOAD 0 0\n" +
+				"\t0001 CONST 0 (1)\n" +
+				"\t0002 BINARY +\n" +
+				"\t0003 STORE 0 0\n" +
+				"\t0004 RETURN\n" +
+				"2: PROCEDURE AGAIN level=3 params=0 slots=0\n" +
+				"\t0000 REF 1 0\n" +
+				"\t0001 CALL 1 2\n" +
+				"\t0002 RETURN\n",
+		},
+		"var parameters of elements and fields": {
+			givenSource: `
+				program Main;
+					var a : array[1..2] of integer;
+					    r : record x : integer end;
+					procedure Clear(var n : integer);
+					begin
+						n := 0
+					end;
+				begin
+					Clear(a[2]);
+					Clear(r.x)
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=2\n" +
+				"\t0000 NEW 0 (ARRAY[1..2] OF INTEGER)\n" +
+				"\t0001 STORE 0 0\n" +
+				"\t0002 NEW 1 (RECORD X:INTEGER END)\n" +
+				"\t0003 STORE 0 1\n" +
+				"\t0004 LOAD 0 0\n" +
+				"\t0005 CONST 3 (2)\n" +
+				"\t0006 REF_INDEX\n" +
+				"\t0007 CALL 1 0\n" +
+				"\t0008 LOAD 0 1\n" +
+				"\t0009 REF_FIELD 0\n" +
+				"\t0010 CALL 1 0\n" +
+				"\t0011 RETURN\n" +
+				"1: PROCEDURE CLEAR level=2 params=1 slots=1\n" +
+				"\t0000 CONST 2 (0)\n" +
+				"\t0001 STORE 0 0\n" +
+				"\t0002 RETURN\n",
+		},
+		"strings and builtin functions": {
+			givenSource: `
+				program Main;
+					var s : string;
+				begin
+					s := 'a';
+					s := copy(s + 'bc', 2, length(s))
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=1\n" +
+				"\t0000 CONST 0 (a)\n" +
+				"\t0001 STORE 0 0\n" +
+				"\t0002 LOAD 0 0\n" +
+				"\t0003 CONST 1 (bc)\n" +
+				"\t0004 BINARY +\n" +
+				"\t0005 CONST 2 (2)\n" +
+				"\t0006 LOAD 0 0\n" +
+				"\t0007 BUILTIN LENGTH 1\n" +
+				"\t0008 BUILTIN COPY 3\n" +
+				"\t0009 STORE 0 0\n" +
+				"\t0010 RETURN\n",
+		},
+		"arrays and records": {
+			givenSource: `
+				program Main;
+					var a : array[1..2] of integer;
+					    p : record x : integer end;
+				begin
+					a[2] := 1;
+					p.x := a[2]
+				end.
+			`,
+			wantBytecode: "" +
+				"0: PROGRAM MAIN level=1 params=0 slots=2\n" +
+				"\t0000 NEW 0 (ARRAY[1..2] OF INTEGER)\n" +
+				"\t0001 STORE 0 0\n" +
+				"\t0002 NEW 1 (RECORD X:INTEGER END)\n" +
+				"\t0003 STORE 0 1\n" +
+				"\t0004 CONST 2 (1)\n" +
+				"\t0005 LOAD 0 0\n" +
+				"\t0006 CONST 3 (2)\n" +
+				"\t0007 STORE_INDEX\n" +
+				"\t0008 LOAD 0 0\n" +
+				"\t0009 CONST 3 (2)\n" +
+				"\t0010 INDEX\n" +
+				"\t0011 LOAD 0 1\n" +
+				"\t0012 STORE_FIELD 0\n" +
+				"\t0013 RETURN\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			parser, err := NewParser(NewLexer(tc.givenSource))
+			assert.NoError(t, err)
+			root, err := parser.Parse()
+			assert.NoError(t, err)
+			assert.NoError(t, Walk(NewSemanticAnalyzer(), root))
+			bytecode, err := NewCompiler().Compile(root)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantBytecode, bytecode.String())
+		})
+	}
+
+	t.Run("unchecked tree", func(t *testing.T) {
+		parser, err := NewParser(NewLexer("program Main; begin x := 1 end."))
+		assert.NoError(t, err)
+		root, err := parser.Parse()
+		assert.NoError(t, err)
+		bytecode, err := NewCompiler().Compile(root)
+		assert.Nil(t, bytecode)
+		assert.Equal(t, `<Error: module=Interpreter,code=Internal,message="variable X not found">`, err.Error())
+	})
+}
@@ -0,0 +1,252 @@
+package pascal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const debuggerPrompt = "(debug) "
+
+// errDebuggerQuit stops the program, it's not reported as an error.
+var errDebuggerQuit = errors.New("quit")
+
+const debuggerHelp = `commands:
+  break LINE, b LINE    stop at the statements of LINE
+  delete LINE, d LINE   remove the breakpoint at LINE
+  continue, c           run until a breakpoint
+  step, s               run the next statement, stepping into calls
+  next, n               run the next statement, stepping over calls
+  out, o                run until the current routine returns
+  print [NAME], p       print a variable, or all of the current record
+  stack, bt             show the call stack
+  quit, q               stop the program
+`
+
+// NewDebugger creates a Debugger, commands are read from reader, as READLN
+// does, and both the program and the Debugger write to writer.
+func NewDebugger(reader io.Reader, writer io.Writer) *Debugger {
+	d := &Debugger{
+		reader:      bufio.NewReader(reader),
+		writer:      writer,
+		breakpoints: make(map[int]bool),
+	}
+	// NOTE: both read from the same bufio.Reader, so that neither buffers
+	// input meant for the other.
+	d.interpreter = NewInterpreter(d.reader, writer)
+	d.interpreter.hook = d.stop
+	return d
+}
+
+type stepMode int
+
+const (
+	// stepModeInto stops at the next statement.
+	stepModeInto stepMode = iota
+	// stepModeOver stops at the next statement of the current routine or
+	// of a caller.
+	stepModeOver
+	// stepModeOut stops at the next statement of a caller.
+	stepModeOut
+	// stepModeContinue stops at breakpoints only.
+	stepModeContinue
+)
+
+// Debugger runs a program on the Interpreter, stopping at its first
+// statement and then as its commands tell. Once there are no more commands
+// the program runs to the end.
+type Debugger struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	interpreter *Interpreter
+	lines       []string
+	breakpoints map[int]bool
+	mode        stepMode
+	// depth is the call stack's when the program last stopped.
+	depth int
+	// row, col and rowDepth are where the last statement was, a breakpoint
+	// stops once each time its line is entered: a statement after the last
+	// one on its line, at the same depth, is on the same pass over the line.
+	row      int
+	col      int
+	rowDepth int
+	// level is the nesting level of the program, the units it uses are
+	// nested in it.
+	level int
+}
+
+func (d *Debugger) Interpret(source string) (err error) {
+	program, err := Compile(source)
+	if err != nil {
+		return
+	}
+	if program, err = program.optimize(d.interpreter.optimizer); err != nil {
+		return
+	}
+	return d.run(context.Background(), program)
+}
+
+// run runs a checked program, stopping before its first statement.
+func (d *Debugger) run(ctx context.Context, program *CompiledProgram) (err error) {
+	d.lines = strings.Split(program.source, "\n")
+	d.level = len(program.root.units) + 1
+	d.mode = stepModeInto
+	d.row, d.col, d.rowDepth = 0, 0, 0
+	if err = d.interpreter.run(ctx, program.root); err == errDebuggerQuit {
+		return nil
+	}
+	return
+}
+
+// stop is the Interpreter's hook, it prompts for commands if the program
+// should stop before node.
+func (d *Debugger) stop(node ASTNode) (err error) {
+	token := statementToken(node)
+	if token == nil {
+		return
+	}
+	// NOTE: the statements of the units are in sources of their own, they
+	// run without stopping.
+	if d.interpreter.callStack.Peek().Enclosing(d.level).Kind != ARKindProgram {
+		return
+	}
+	depth := d.interpreter.callStack.Len()
+	stop := false
+	switch d.mode {
+	case stepModeInto:
+		stop = true
+	case stepModeOver:
+		stop = depth <= d.depth
+	case stepModeOut:
+		stop = depth < d.depth
+	}
+	if d.breakpoints[token.Row] && (token.Row != d.row || token.Col <= d.col || depth != d.rowDepth) {
+		stop = true
+	}
+	d.row, d.col, d.rowDepth = token.Row, token.Col, depth
+	if !stop {
+		return
+	}
+
+	d.depth = depth
+	fmt.Fprintf(d.writer, "line %d: %s\n", token.Row, strings.TrimSpace(d.lines[token.Row-1]))
+	return d.prompt()
+}
+
+// prompt runs commands until one resumes the program.
+func (d *Debugger) prompt() (err error) {
+	for {
+		if _, err = io.WriteString(d.writer, debuggerPrompt); err != nil {
+			return
+		}
+		line, readErr := d.reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if line == "" && readErr == io.EOF {
+			// no more commands, run to the end.
+			fmt.Fprintln(d.writer)
+			d.mode = stepModeContinue
+			d.breakpoints = make(map[int]bool)
+			return nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "break", "b", "delete", "d":
+			var row int
+			if len(fields) != 2 {
+				fmt.Fprintf(d.writer, "%s needs a line number\n", fields[0])
+				continue
+			}
+			if row, err = strconv.Atoi(fields[1]); err != nil || row < 1 || row > len(d.lines) {
+				err = nil
+				fmt.Fprintf(d.writer, "invalid line %s\n", fields[1])
+				continue
+			}
+			if fields[0] == "break" || fields[0] == "b" {
+				d.breakpoints[row] = true
+				fmt.Fprintf(d.writer, "breakpoint at line %d\n", row)
+			} else {
+				delete(d.breakpoints, row)
+				fmt.Fprintf(d.writer, "deleted breakpoint at line %d\n", row)
+			}
+		case "continue", "c":
+			d.mode = stepModeContinue
+			return
+		case "step", "s":
+			d.mode = stepModeInto
+			return
+		case "next", "n":
+			d.mode = stepModeOver
+			return
+		case "out", "o":
+			d.mode = stepModeOut
+			return
+		case "print", "p":
+			d.print(fields[1:])
+		case "stack", "bt":
+			for _, frame := range d.interpreter.trace() {
+				fmt.Fprintln(d.writer, frame)
+			}
+		case "quit", "q":
+			return errDebuggerQuit
+		case "help", "h":
+			io.WriteString(d.writer, debuggerHelp)
+		default:
+			fmt.Fprintf(d.writer, "unknown command %s, type help for the commands\n", fields[0])
+		}
+	}
+}
+
+// print writes variables of the current activation record, those of the
+// enclosing routines are found through static links.
+func (d *Debugger) print(names []string) {
+	ar := d.interpreter.callStack.Peek()
+	if len(names) == 0 {
+		for name := range ar.Members {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	for _, name := range names {
+		name = normalizeKeyword(name)
+		value, ok := interface{}(nil), false
+		for record := ar; record != nil && !ok; record = record.StaticLink {
+			value, ok = record.Get(name)
+		}
+		if !ok {
+			fmt.Fprintf(d.writer, "%s is not set\n", name)
+			continue
+		}
+		fmt.Fprintf(d.writer, "%s = %s\n", name, formatMember(value))
+	}
+}
+
+// statementToken returns the token of a statement the Debugger may stop
+// at, nil for other nodes.
+func statementToken(node ASTNode) *Token {
+	switch n := node.(type) {
+	case *AssignNode:
+		return n.token
+	case *ProcedureCallNode:
+		return n.token
+	case *IfNode:
+		return n.token
+	case *WhileNode:
+		return n.token
+	case *RepeatNode:
+		return n.token
+	case *ForNode:
+		return n.token
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package pascal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const debuggerTestSource = `program Main;
+var x, y : integer;
+
+procedure Alpha(a : integer);
+var b : integer;
+begin
+   b := a * 2;
+   x := x + b
+end;
+
+begin
+   x := 1;
+   Alpha(3);
+   Alpha(4);
+   writeln(x)
+end.`
+
+func TestDebugger_Interpret(t *testing.T) {
+	tests := map[string]struct {
+		givenCommands string
+		wantOutput    string
+	}{
+		"run without commands": {
+			givenCommands: "",
+			wantOutput:    "line 12: x := 1;\n(debug) \n15\n",
+		},
+		"step into, over and out": {
+			givenCommands: "s\ns\ns\nstack\no\nn\nn\n",
+			wantOutput: `line 12: x := 1;
+(debug) line 13: Alpha(3);
+(debug) line 7: b := a * 2;
+(debug) line 8: x := x + b
+(debug) 2: PROCEDURE ALPHA
+1: PROGRAM MAIN
+(debug) line 14: Alpha(4);
+(debug) line 15: writeln(x)
+(debug) 15
+`,
+		},
+		"breakpoints": {
+			givenCommands: "b 8\nc\np\nc\np x\nd 8\nc\n",
+			wantOutput: `line 12: x := 1;
+(debug) breakpoint at line 8
+(debug) line 8: x := x + b
+(debug) A = 3
+B = 6
+(debug) line 8: x := x + b
+(debug) X = 7
+(debug) deleted breakpoint at line 8
+(debug) 15
+`,
+		},
+		"print": {
+			givenCommands: "p y z\nn\np X\nq\n",
+			wantOutput: `line 12: x := 1;
+(debug) Y is not set
+Z is not set
+(debug) line 13: Alpha(3);
+(debug) X = 1
+(debug) `,
+		},
+		"invalid commands": {
+			givenCommands: "b\nb 99\njump\nq\n",
+			wantOutput: `line 12: x := 1;
+(debug) b needs a line number
+(debug) invalid line 99
+(debug) unknown command jump, type help for the commands
+(debug) `,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := bytes.NewBuffer(nil)
+			debugger := NewDebugger(strings.NewReader(tc.givenCommands), w)
+			err := debugger.Interpret(debuggerTestSource)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantOutput, w.String())
+		})
+	}
+}
+
+func TestDebugger_InterpretReadln(t *testing.T) {
+	source := `program Main;
+var x : integer;
+begin
+   readln(x);
+   writeln(x * 2)
+end.`
+	w := bytes.NewBuffer(nil)
+	debugger := NewDebugger(strings.NewReader("n\n21\nc\n"), w)
+	err := debugger.Interpret(source)
+	assert.NoError(t, err)
+	assert.Equal(t, "line 4: readln(x);\n(debug) line 5: writeln(x * 2)\n(debug) 42\n", w.String())
+}
+
+func TestDebugger_InterpretLoopBreakpoint(t *testing.T) {
+	source := `program Main;
+var i, sum : integer;
+begin
+   sum := 0;
+   for i := 1 to 3 do
+      sum := sum + i; writeln(i);
+   writeln(sum)
+end.`
+	w := bytes.NewBuffer(nil)
+	debugger := NewDebugger(strings.NewReader("b 6\nc\np i\nc\np i\nc\np i\nc\n"), w)
+	err := debugger.Interpret(source)
+	assert.NoError(t, err)
+	assert.Equal(t, `line 4: sum := 0;
+(debug) breakpoint at line 6
+(debug) line 6: sum := sum + i; writeln(i);
+(debug) I = 1
+(debug) line 6: sum := sum + i; writeln(i);
+(debug) I = 2
+(debug) line 6: sum := sum + i; writeln(i);
+(debug) I = 3
+(debug) 3
+6
+`, w.String())
+}
@@ -0,0 +1,362 @@
+package pascal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DumpNode is a serializable node of the AST, of the scopes or of the
+// token stream, a dump is written as JSON or as a Graphviz DOT digraph.
+type DumpNode struct {
+	Node     string            `json:"node"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Row      int               `json:"row,omitempty"`
+	Col      int               `json:"col,omitempty"`
+	Children []*DumpNode       `json:"children,omitempty"`
+}
+
+func newDumpNode(node string, token *Token, attrs ...string) *DumpNode {
+	d := &DumpNode{Node: node}
+	if token != nil {
+		d.Row, d.Col = token.Row, token.Col
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if d.Attrs == nil {
+			d.Attrs = make(map[string]string)
+		}
+		d.Attrs[attrs[i]] = attrs[i+1]
+	}
+	return d
+}
+
+// WriteJSON writes the dump as indented JSON.
+func (d *DumpNode) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(d)
+}
+
+// WriteDOT writes the dump as a digraph named name, a node is labelled
+// with its kind and attributes and has an edge to each of its children.
+func (d *DumpNode) WriteDOT(writer io.Writer, name string) (err error) {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("digraph %s {\n", name))
+	builder.WriteString("  node [shape=box];\n")
+	id := 0
+	var write func(node *DumpNode) int
+	write = func(node *DumpNode) int {
+		nodeID := id
+		id += 1
+		builder.WriteString(fmt.Sprintf("  n%d [label=%s];\n", nodeID, strconv.Quote(node.label())))
+		for _, child := range node.Children {
+			childID := write(child)
+			builder.WriteString(fmt.Sprintf("  n%d -> n%d;\n", nodeID, childID))
+		}
+		return nodeID
+	}
+	write(d)
+	builder.WriteString("}\n")
+	_, err = io.WriteString(writer, builder.String())
+	return
+}
+
+func (d *DumpNode) label() string {
+	lines := []string{d.Node}
+	var keys []string
+	for key := range d.Attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s=%s", key, d.Attrs[key]))
+	}
+	if d.Row > 0 {
+		lines = append(lines, fmt.Sprintf("(%d,%d)", d.Row, d.Col))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DumpAST serializes the tree, the Parser's output needs no analysis.
+func DumpAST(root ASTNode) (*DumpNode, error) {
+	dumper := &astDumper{}
+	if err := Walk(dumper, root); err != nil {
+		return nil, err
+	}
+	return dumper.root, nil
+}
+
+var _ Visitor = (*astDumper)(nil)
+
+// astDumper builds the dump of a tree, stack holds the dumps of the nodes
+// being walked.
+type astDumper struct {
+	stack []*DumpNode
+	root  *DumpNode
+}
+
+func (a *astDumper) Before(node ASTNode) (shouldStepIn bool, err error) {
+	d := dumpASTNode(node)
+	if len(a.stack) == 0 {
+		a.root = d
+	} else {
+		parent := a.stack[len(a.stack)-1]
+		parent.Children = append(parent.Children, d)
+	}
+	a.stack = append(a.stack, d)
+
+	// NOTE: Walk steps into the block of the procedure called instead of
+	// the actual parameters.
+	if n, ok := node.(*ProcedureCallNode); ok {
+		for _, actualParam := range n.actualParams {
+			if err = Walk(a, actualParam); err != nil {
+				return
+			}
+		}
+		a.stack = a.stack[:len(a.stack)-1]
+		return false, nil
+	}
+	return true, nil
+}
+
+func (a *astDumper) After(node ASTNode) error {
+	a.stack = a.stack[:len(a.stack)-1]
+	return nil
+}
+
+func dumpASTNode(node ASTNode) *DumpNode {
+	switch n := node.(type) {
+	case *ProgramNode:
+		return newDumpNode("ProgramNode", nil, "name", n.name)
+	case *BlockNode:
+		return newDumpNode("BlockNode", nil)
+	case *ProcedureDeclNode:
+		return newDumpNode("ProcedureDeclNode", nil, "name", n.name)
+	case *FunctionDeclNode:
+		return newDumpNode("FunctionDeclNode", nil, "name", n.name)
+	case *ParamNode:
+		if n.byReference {
+			return newDumpNode("ParamNode", nil, "byReference", "true")
+		}
+		return newDumpNode("ParamNode", nil)
+	case *VarDeclNode:
+		return newDumpNode("VarDeclNode", nil)
+	case *ConstDeclNode:
+		return newDumpNode("ConstDeclNode", n.token, "name", n.name)
+	case *TypeDeclNode:
+		return newDumpNode("TypeDeclNode", n.token, "name", n.name)
+	case *ArrayTypeNode:
+		return newDumpNode("ArrayTypeNode", n.token)
+	case *RecordTypeNode:
+		return newDumpNode("RecordTypeNode", n.token)
+	case *EnumTypeNode:
+		values := make([]string, len(n.values))
+		for i, value := range n.values {
+			values[i] = value.Value
+		}
+		return newDumpNode("EnumTypeNode", n.token, "values", strings.Join(values, ","))
+	case *TypNode:
+		return newDumpNode("TypNode", n.token, "value", n.value)
+	case *CompoundNode:
+		return newDumpNode("CompoundNode", nil)
+	case *AssignNode:
+		return newDumpNode("AssignNode", n.token)
+	case *ProcedureCallNode:
+		return newDumpNode("ProcedureCallNode", n.token, "name", n.name)
+	case *FunctionCallNode:
+		return newDumpNode("FunctionCallNode", n.token, "name", n.name)
+	case *VarNode:
+		return newDumpNode("VarNode", n.token, "value", n.value)
+	case *IndexNode:
+		return newDumpNode("IndexNode", n.token)
+	case *FieldNode:
+		return newDumpNode("FieldNode", n.token, "field", n.field)
+	case *NumNode:
+		return newDumpNode("NumNode", n.token, "value", n.token.Value)
+	case *BoolNode:
+		return newDumpNode("BoolNode", n.token, "value", n.token.Value)
+	case *StrNode:
+		return newDumpNode("StrNode", n.token, "value", n.value)
+	case *UnaryOpNode:
+		return newDumpNode("UnaryOpNode", n.token, "op", TokenNames[n.op])
+	case *BinOpNode:
+		return newDumpNode("BinOpNode", n.token, "op", TokenNames[n.op])
+	case *IfNode:
+		return newDumpNode("IfNode", n.token)
+	case *WhileNode:
+		return newDumpNode("WhileNode", n.token)
+	case *RepeatNode:
+		return newDumpNode("RepeatNode", n.token)
+	case *ForNode:
+		if n.downto {
+			return newDumpNode("ForNode", n.token, "downto", "true")
+		}
+		return newDumpNode("ForNode", n.token)
+	case *NoopNode:
+		return newDumpNode("NoopNode", nil)
+	}
+	panic(fmt.Sprintf("unknown node %T", node))
+}
+
+// DumpSymbols analyzes the tree, loading the units it uses as opts say, and
+// serializes the scopes, each holds its symbols, sorted by name, followed by
+// the scopes nested in it.
+func DumpSymbols(root ASTNode, opts CompileOptions) (*DumpNode, error) {
+	dumper := &symbolDumper{semanticAnalyzer: NewSemanticAnalyzer()}
+	dumper.semanticAnalyzer.loader = searchPath(opts.SearchPath)
+	dumper.scopes = []*ScopedSymbolTable{dumper.semanticAnalyzer.currentScope}
+	if err := Walk(dumper, root); err != nil {
+		return nil, err
+	}
+	return dumper.dump(dumper.scopes[0]), nil
+}
+
+var _ Visitor = (*symbolDumper)(nil)
+
+// symbolDumper records the scopes the SemanticAnalyzer enters, they're
+// serialized once all symbols are defined.
+type symbolDumper struct {
+	semanticAnalyzer *SemanticAnalyzer
+	scopes           []*ScopedSymbolTable
+}
+
+func (s *symbolDumper) Before(node ASTNode) (shouldStepIn bool, err error) {
+	scope := s.semanticAnalyzer.currentScope
+	shouldStepIn, err = s.semanticAnalyzer.Before(node)
+	// NOTE: the scope of a program that uses units is nested in the one of
+	// the symbols it imports.
+	var entered []*ScopedSymbolTable
+	for current := s.semanticAnalyzer.currentScope; current != scope && current != nil; current = current.enclosingScope {
+		entered = append([]*ScopedSymbolTable{current}, entered...)
+	}
+	s.scopes = append(s.scopes, entered...)
+	return
+}
+
+func (s *symbolDumper) After(node ASTNode) error {
+	return s.semanticAnalyzer.After(node)
+}
+
+func (s *symbolDumper) dump(scope *ScopedSymbolTable) *DumpNode {
+	d := newDumpNode("Scope", nil, "name", scope.name, "level", strconv.Itoa(scope.level))
+	var names []string
+	for name := range scope.symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		symbol := scope.symbols[name]
+		d.Children = append(d.Children, newDumpNode(symbolKind(symbol), nil, "name", name, "symbol", symbol.String()))
+	}
+	for _, nested := range s.scopes {
+		if nested.enclosingScope == scope {
+			d.Children = append(d.Children, s.dump(nested))
+		}
+	}
+	return d
+}
+
+func symbolKind(symbol Symbol) string {
+	switch symbol.(type) {
+	case *BuiltinTypeSymbol:
+		return "BuiltinTypeSymbol"
+	case *ArrayTypeSymbol:
+		return "ArrayTypeSymbol"
+	case *RecordTypeSymbol:
+		return "RecordTypeSymbol"
+	case *TypeAliasSymbol:
+		return "TypeAliasSymbol"
+	case *EnumTypeSymbol:
+		return "EnumTypeSymbol"
+	case *ConstSymbol:
+		return "ConstSymbol"
+	case *VarSymbol:
+		return "VarSymbol"
+	case *ProcedureSymbol:
+		return "ProcedureSymbol"
+	case *FunctionSymbol:
+		return "FunctionSymbol"
+	case *BuiltinProcedureSymbol:
+		return "BuiltinProcedureSymbol"
+	case *BuiltinFunctionSymbol:
+		return "BuiltinFunctionSymbol"
+	}
+	panic(fmt.Sprintf("unknown symbol %T", symbol))
+}
+
+// DumpTokens serializes the token stream of source, EOF included.
+func DumpTokens(source string) (d *DumpNode, err error) {
+	d = newDumpNode("Tokens", nil)
+	lexer := NewLexer(source)
+	for {
+		var token *Token
+		if token, err = lexer.GetNextToken(); err != nil {
+			return nil, err
+		}
+		child := newDumpNode("Token", token, "kind", token.Kind.String(), "value", token.Value)
+		for _, comment := range token.Comments {
+			child.Children = append(child.Children, newDumpNode("Comment", &Token{Row: comment.Row, Col: comment.Col}, "text", comment.Text))
+		}
+		d.Children = append(d.Children, child)
+		if token.Kind == EOF {
+			return
+		}
+	}
+}
+
+// DumpSource writes the dump of what, ast, symbols or tokens, of source in
+// format, json or dot. The units of the symbols are loaded as opts say.
+func DumpSource(writer io.Writer, source, what, format string, opts CompileOptions) (err error) {
+	var d *DumpNode
+	switch what {
+	case "tokens":
+		d, err = DumpTokens(source)
+	case "ast", "symbols":
+		var root ASTNode
+		if root, err = parse(source); err != nil {
+			return
+		}
+		if what == "ast" {
+			d, err = DumpAST(root)
+		} else {
+			d, err = DumpSymbols(root, opts)
+		}
+	default:
+		return fmt.Errorf("invalid dump %q, use ast, symbols or tokens", what)
+	}
+	if err != nil {
+		return
+	}
+
+	switch format {
+	case "json":
+		return d.WriteJSON(writer)
+	case "dot":
+		return d.WriteDOT(writer, strings.ToUpper(what))
+	}
+	return fmt.Errorf("invalid dump format %q, use json or dot", format)
+}
+
+// parseSource parses source as a unit if it starts with UNIT, else as a
+// program.
+func parseSource(source string) (root ASTNode, err error) {
+	parser, err := NewParser(NewLexer(source))
+	if err != nil {
+		return
+	}
+	if parser.currToken.Kind == Unit {
+		return parser.ParseUnit()
+	}
+	return parser.Parse()
+}
+
+func parse(source string) (root ASTNode, err error) {
+	parser, err := NewParser(NewLexer(source))
+	if err != nil {
+		return
+	}
+	return parser.Parse()
+}
@@ -0,0 +1,170 @@
+package pascal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpSource(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		givenWhat   string
+		givenFormat string
+		wantDump    string
+	}{
+		"ast json": {
+			givenSource: `program Main; begin x := -1 end.`,
+			givenWhat:   "ast",
+			givenFormat: "json",
+			wantDump: `{
+				"node": "ProgramNode", "attrs": {"name": "MAIN"},
+				"children": [{"node": "BlockNode", "children": [{"node": "CompoundNode", "children": [
+					{"node": "AssignNode", "row": 1, "col": 23, "children": [
+						{"node": "VarNode", "attrs": {"value": "X"}, "row": 1, "col": 21},
+						{"node": "UnaryOpNode", "attrs": {"op": "-"}, "row": 1, "col": 26, "children": [
+							{"node": "NumNode", "attrs": {"value": "1"}, "row": 1, "col": 27}
+						]}
+					]}
+				]}]}]
+			}`,
+		},
+		"ast json with procedure call": {
+			givenSource: `program Main; begin writeln('a', 1) end.`,
+			givenWhat:   "ast",
+			givenFormat: "json",
+			wantDump: `{
+				"node": "ProgramNode", "attrs": {"name": "MAIN"},
+				"children": [{"node": "BlockNode", "children": [{"node": "CompoundNode", "children": [
+					{"node": "ProcedureCallNode", "attrs": {"name": "WRITELN"}, "row": 1, "col": 21, "children": [
+						{"node": "StrNode", "attrs": {"value": "a"}, "row": 1, "col": 29},
+						{"node": "NumNode", "attrs": {"value": "1"}, "row": 1, "col": 34}
+					]}
+				]}]}]
+			}`,
+		},
+		"ast dot": {
+			givenSource: `program Main; begin end.`,
+			givenWhat:   "ast",
+			givenFormat: "dot",
+			wantDump: `digraph AST {
+  node [shape=box];
+  n0 [label="ProgramNode\nname=MAIN"];
+  n1 [label="BlockNode"];
+  n2 [label="CompoundNode"];
+  n3 [label="NoopNode"];
+  n2 -> n3;
+  n1 -> n2;
+  n0 -> n1;
+}
+`,
+		},
+		"tokens json": {
+			givenSource: "program Main; {x}\nbegin end.",
+			givenWhat:   "tokens",
+			givenFormat: "json",
+			wantDump: `{"node": "Tokens", "children": [
+				{"node": "Token", "attrs": {"kind": "PROGRAM", "value": "PROGRAM"}, "row": 1, "col": 1},
+				{"node": "Token", "attrs": {"kind": "ID", "value": "MAIN"}, "row": 1, "col": 9},
+				{"node": "Token", "attrs": {"kind": ";", "value": ";"}, "row": 1, "col": 13},
+				{"node": "Token", "attrs": {"kind": "BEGIN", "value": "BEGIN"}, "row": 2, "col": 1, "children": [
+					{"node": "Comment", "attrs": {"text": "{x}"}, "row": 1, "col": 15}
+				]},
+				{"node": "Token", "attrs": {"kind": "END", "value": "END"}, "row": 2, "col": 7},
+				{"node": "Token", "attrs": {"kind": ".", "value": "."}, "row": 2, "col": 10},
+				{"node": "Token", "attrs": {"kind": "EOF", "value": "EOF"}, "row": 2, "col": 11}
+			]}`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := bytes.NewBuffer(nil)
+			err := DumpSource(w, tc.givenSource, tc.givenWhat, tc.givenFormat, CompileOptions{})
+			assert.NoError(t, err)
+			if tc.givenFormat == "json" {
+				assert.JSONEq(t, tc.wantDump, w.String())
+			} else {
+				assert.Equal(t, tc.wantDump, w.String())
+			}
+		})
+	}
+}
+
+func TestDumpSymbols(t *testing.T) {
+	root, err := parse(`
+		program Main;
+		var x : integer;
+		procedure P(a : real);
+		begin
+		end;
+		begin
+		end.
+	`)
+	assert.NoError(t, err)
+	d, err := DumpSymbols(root, CompileOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Scope", d.Node)
+	assert.Equal(t, map[string]string{"name": "global", "level": "0"}, d.Attrs)
+	program := d.Children[len(d.Children)-1]
+	assert.Equal(t, &DumpNode{
+		Node:  "Scope",
+		Attrs: map[string]string{"name": "MAIN", "level": "1"},
+		Children: []*DumpNode{
+			{Node: "ProcedureSymbol", Attrs: map[string]string{"name": "P", "symbol": "<P:<A:REAL>>"}},
+			{Node: "VarSymbol", Attrs: map[string]string{"name": "X", "symbol": "<X:INTEGER>"}},
+			{
+				Node:  "Scope",
+				Attrs: map[string]string{"name": "P", "level": "2"},
+				Children: []*DumpNode{
+					{Node: "VarSymbol", Attrs: map[string]string{"name": "A", "symbol": "<A:REAL>"}},
+				},
+			},
+		},
+	}, program)
+}
+
+func TestDumpSource_units(t *testing.T) {
+	dir := writeTestUnits(t, map[string]string{"counter.pas": counterTestUnit})
+	source := `program Main; uses Counter; var n : integer; begin Tick(1) end.`
+	w := bytes.NewBuffer(nil)
+	assert.NoError(t, DumpSource(w, source, "symbols", "json", CompileOptions{SearchPath: []string{dir}}))
+	assert.Contains(t, w.String(), `"name": "TICK"`)
+	assert.Contains(t, w.String(), `"name": "N"`)
+
+	assert.Error(t, DumpSource(bytes.NewBuffer(nil), source, "symbols", "json", CompileOptions{SearchPath: []string{t.TempDir()}}))
+}
+
+func TestDumpSource_Errors(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		givenWhat   string
+		givenFormat string
+		wantErr     string
+	}{
+		"invalid dump": {
+			givenSource: `program Main; begin end.`,
+			givenWhat:   "scopes",
+			givenFormat: "json",
+			wantErr:     `invalid dump "scopes", use ast, symbols or tokens`,
+		},
+		"invalid format": {
+			givenSource: `program Main; begin end.`,
+			givenWhat:   "ast",
+			givenFormat: "xml",
+			wantErr:     `invalid dump format "xml", use json or dot`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := DumpSource(bytes.NewBuffer(nil), tc.givenSource, tc.givenWhat, tc.givenFormat, CompileOptions{})
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+
+	err := DumpSource(bytes.NewBuffer(nil), `program Main; begin x := 1 end.`, "symbols", "json", CompileOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,273 @@
+package pascal
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ErrorCode int32
+
+const (
+	ErrorCodeUnspecified ErrorCode = iota
+	// Parser.
+	ErrorCodeUnexpectedToken
+	// SemanticAnalyzer.
+	ErrorCodeIdNotFound
+	ErrorCodeDuplicateId
+	ErrorCodeUnknownDataType
+	ErrorCodeArgumentsMismatch
+	ErrorCodeNotCallable
+	ErrorCodeInvalidAssignment
+	ErrorCodeVariableExpected
+	ErrorCodeTypeMismatch
+	ErrorCodeUnknownField
+	ErrorCodeUnusedVariable
+	ErrorCodeUnusedRoutine
+	ErrorCodeConstantAssignment
+	ErrorCodeConstantExpected
+	ErrorCodeCircularDependency
+	// Lexer.
+	ErrorCodeUnknownRune
+	ErrorCodeUnclosedComment
+	ErrorCodeUnclosedString
+	// Interpreter.
+	ErrorCodeInvalidInput
+	ErrorCodeDivisionByZero
+	ErrorCodeUninitializedVariable
+	ErrorCodeStackOverflow
+	ErrorCodeIndexOutOfRange
+	ErrorCodeInvalidArgument
+	ErrorCodeCanceled
+	ErrorCodeStepLimitExceeded
+	ErrorCodeMemoryLimitExceeded
+	// ErrorCodeInternal is a bug of a backend rather than of the program.
+	ErrorCodeInternal
+)
+
+func (ec ErrorCode) String() string {
+	switch ec {
+	case ErrorCodeUnspecified:
+		return "Unspecified"
+	case ErrorCodeUnexpectedToken:
+		return "UnexpectedToken"
+	case ErrorCodeIdNotFound:
+		return "IdNotFound"
+	case ErrorCodeDuplicateId:
+		return "DuplicateId"
+	case ErrorCodeUnknownDataType:
+		return "UnknownDataType"
+	case ErrorCodeArgumentsMismatch:
+		return "ArgumentsMismatch"
+	case ErrorCodeNotCallable:
+		return "NotCallable"
+	case ErrorCodeInvalidAssignment:
+		return "InvalidAssignment"
+	case ErrorCodeVariableExpected:
+		return "VariableExpected"
+	case ErrorCodeTypeMismatch:
+		return "TypeMismatch"
+	case ErrorCodeUnknownField:
+		return "UnknownField"
+	case ErrorCodeUnusedVariable:
+		return "UnusedVariable"
+	case ErrorCodeUnusedRoutine:
+		return "UnusedRoutine"
+	case ErrorCodeConstantAssignment:
+		return "ConstantAssignment"
+	case ErrorCodeConstantExpected:
+		return "ConstantExpected"
+	case ErrorCodeCircularDependency:
+		return "CircularDependency"
+	case ErrorCodeUnknownRune:
+		return "UnknownRune"
+	case ErrorCodeUnclosedComment:
+		return "UnclosedComment"
+	case ErrorCodeUnclosedString:
+		return "UnclosedString"
+	case ErrorCodeInvalidInput:
+		return "InvalidInput"
+	case ErrorCodeDivisionByZero:
+		return "DivisionByZero"
+	case ErrorCodeUninitializedVariable:
+		return "UninitializedVariable"
+	case ErrorCodeStackOverflow:
+		return "StackOverflow"
+	case ErrorCodeIndexOutOfRange:
+		return "IndexOutOfRange"
+	case ErrorCodeInvalidArgument:
+		return "InvalidArgument"
+	case ErrorCodeCanceled:
+		return "Canceled"
+	case ErrorCodeStepLimitExceeded:
+		return "StepLimitExceeded"
+	case ErrorCodeMemoryLimitExceeded:
+		return "MemoryLimitExceeded"
+	case ErrorCodeInternal:
+		return "Internal"
+	default:
+		return "Unknown"
+	}
+}
+
+type Module int32
+
+const (
+	ModuleUnspecified Module = iota
+	ModuleLexer
+	ModuleParser
+	ModuleSemanticAnalyzer
+	ModuleInterpreter
+)
+
+func (m Module) String() string {
+	switch m {
+	case ModuleUnspecified:
+		return "Unspecified"
+	case ModuleLexer:
+		return "Lexer"
+	case ModuleParser:
+		return "Parser"
+	case ModuleSemanticAnalyzer:
+		return "SemanticAnalyzer"
+	case ModuleInterpreter:
+		return "Interpreter"
+	default:
+		return "Unknown"
+	}
+}
+
+var _ error = Error{}
+
+// Span locates an error in the source, Row and Col are 1-based and Len is
+// the number of columns to underline.
+type Span struct {
+	Row int
+	Col int
+	Len int
+}
+
+type Error struct {
+	Code    ErrorCode
+	Module  Module
+	Message string
+	Span    Span
+	// Suggestion hints at how to fix the error, e.g. the expected tokens.
+	Suggestion string
+	// Trace is the call stack of a runtime error, innermost record first.
+	Trace []string
+	// Warning marks a problem that doesn't stop the program, e.g. an
+	// unused declaration.
+	Warning bool
+}
+
+func (e Error) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("<Error: ")
+	var fields []string
+	if e.Module != ModuleUnspecified {
+		fields = append(fields, fmt.Sprintf("module=%s", e.Module))
+	}
+	if e.Code != ErrorCodeUnspecified {
+		fields = append(fields, fmt.Sprintf("code=%s", e.Code))
+	}
+	if e.Message != "" {
+		fields = append(fields, fmt.Sprintf("message=\"%s\"", e.Message))
+	}
+	sb.WriteString(strings.Join(fields, ","))
+	sb.WriteRune('>')
+	return sb.String()
+}
+
+// Render formats the error for humans: its location in filename, the
+// offending source line with a caret under the span and the suggestion.
+func (e Error) Render(filename, source string) string {
+	sb := strings.Builder{}
+	severity := "error"
+	if e.Warning {
+		severity = "warning"
+	}
+	sb.WriteString(fmt.Sprintf("%s:%d:%d: %s %s %s", filename, e.Span.Row, e.Span.Col, e.Module, severity, e.Code))
+	if e.Message != "" {
+		sb.WriteString(": " + e.Message)
+	}
+	sb.WriteRune('\n')
+
+	lines := strings.Split(source, "\n")
+	if e.Span.Row >= 1 && e.Span.Row <= len(lines) {
+		line := strings.TrimRight(lines[e.Span.Row-1], "\r")
+		gutter := fmt.Sprintf("%4d | ", e.Span.Row)
+		sb.WriteString(gutter + line + "\n")
+		sb.WriteString(strings.Repeat(" ", len(gutter)-2) + "| ")
+		// keep tabs so that the caret lines up with the source.
+		for i, r := range line {
+			if i+1 >= e.Span.Col {
+				break
+			}
+			if r == '\t' {
+				sb.WriteRune('\t')
+			} else {
+				sb.WriteRune(' ')
+			}
+		}
+		width := e.Span.Len
+		if width < 1 {
+			width = 1
+		}
+		sb.WriteString(strings.Repeat("^", width) + "\n")
+	}
+
+	if e.Suggestion != "" {
+		sb.WriteString(fmt.Sprintf("hint: %s\n", e.Suggestion))
+	}
+	for _, record := range e.Trace {
+		sb.WriteString(fmt.Sprintf("\tat %s\n", record))
+	}
+	return sb.String()
+}
+
+var _ error = ErrorList{}
+
+// ErrorList aggregates the errors reported by a module that recovers from
+// them instead of stopping at the first one.
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	strs := make([]string, len(l))
+	for i, err := range l {
+		strs[i] = err.Error()
+	}
+	return strings.Join(strs, "\n")
+}
+
+// Err returns nil if the list is empty, the list otherwise.
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Render renders every error of the list, see Error.Render.
+func (l ErrorList) Render(filename, source string) string {
+	sb := strings.Builder{}
+	for _, err := range l {
+		if e, ok := err.(Error); ok {
+			sb.WriteString(e.Render(filename, source))
+		} else {
+			sb.WriteString(err.Error() + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// expected builds the suggestion listing the token kinds expected instead.
+func expected(kinds ...TokenKind) string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = kind.String()
+	}
+	if len(names) == 1 {
+		return "expected " + names[0]
+	}
+	return "expected one of " + strings.Join(names, ", ")
+}
@@ -0,0 +1,130 @@
+package pascal
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError_Error(t *testing.T) {
+	tests := map[string]struct {
+		givenCode    ErrorCode
+		givenModule  Module
+		givenMessage string
+		wantString   string
+	}{
+		"without module": {
+			givenCode:    ErrorCodeIdNotFound,
+			givenModule:  0,
+			givenMessage: "hello world",
+			wantString:   `<Error: code=IdNotFound,message="hello world">`,
+		},
+		"without code": {
+			givenCode:    0,
+			givenModule:  ModuleLexer,
+			givenMessage: "hello world",
+			wantString:   `<Error: module=Lexer,message="hello world">`,
+		},
+		"without message": {
+			givenCode:    ErrorCodeDuplicateId,
+			givenModule:  ModuleParser,
+			givenMessage: "",
+			wantString:   "<Error: module=Parser,code=DuplicateId>",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := Error{
+				Code:    tc.givenCode,
+				Module:  tc.givenModule,
+				Message: tc.givenMessage,
+			}
+			assert.Equal(t, tc.wantString, e.Error())
+		})
+	}
+}
+
+func TestError_Render(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		wantRender  string
+	}{
+		"lexer": {
+			givenSource: "program Main;\nbegin\n\tx := 1 ? 2\nend.",
+			wantRender: "main.pas:3:9: Lexer error UnknownRune: lexeme=?,pos=(3,9)\n" +
+				"   3 | \tx := 1 ? 2\n" +
+				"     | \t       ^\n" +
+				"main.pas:3:11: Parser error UnexpectedToken: code=UnexpectedToken,token=(kind=INTEGER_CONST,value=2,pos=(3,11))\n" +
+				"   3 | \tx := 1 ? 2\n" +
+				"     | \t         ^\n" +
+				"hint: expected ;\n",
+		},
+		"lexer unclosed comment": {
+			givenSource: "program Main; { oops\nbegin end.",
+			wantRender: "main.pas:1:15: Lexer error UnclosedComment: lexeme=,pos=(2,11)\n" +
+				"   1 | program Main; { oops\n" +
+				"     |               ^\n" +
+				"hint: close the comment with }\n" +
+				"main.pas:2:11: Parser error UnexpectedToken: code=UnexpectedToken,token=(kind=EOF,value=EOF,pos=(2,11))\n" +
+				"   2 | begin end.\n" +
+				"     |           ^\n" +
+				"hint: expected BEGIN\n",
+		},
+		"parser": {
+			givenSource: "program Main;\nbegin\n  x := 1\n  y := 2\nend.",
+			wantRender: "main.pas:4:3: Parser error UnexpectedToken: code=UnexpectedToken,token=(kind=ID,value=Y,pos=(4,3))\n" +
+				"   4 |   y := 2\n" +
+				"     |   ^\n" +
+				"hint: expected ;\n",
+		},
+		"semantic analyzer": {
+			givenSource: "program Main;\n  var number : integer;\nbegin\n  number := numbr + 1\nend.",
+			wantRender: "main.pas:4:13: SemanticAnalyzer error IdNotFound: token:(kind=ID,value=NUMBR,pos=(4,13))\n" +
+				"   4 |   number := numbr + 1\n" +
+				"     |             ^^^^^\n" +
+				"hint: declare NUMBR before using it\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := NewInterpreter(nil, nil).Interpret(tc.givenSource)
+			errs, ok := err.(ErrorList)
+			assert.True(t, ok)
+			assert.Equal(t, tc.wantRender, errs.Render("main.pas", tc.givenSource))
+		})
+	}
+
+	t.Run("interpreter", func(t *testing.T) {
+		source := "program Main;\n  var a : integer;\nbegin\n  readln(a)\nend."
+		err := NewInterpreter(strings.NewReader("x"), ioutil.Discard).Interpret(source)
+		assert.Equal(t, "main.pas:4:3: Interpreter error InvalidInput: invalid INTEGER \"x\",token:(kind=ID,value=READLN,pos=(4,3))\n"+
+			"   4 |   readln(a)\n"+
+			"     |   ^^^^^^\n"+
+			"hint: type one value per variable on a single line, separated by spaces\n"+
+			"\tat 1: PROGRAM MAIN\n", err.(Error).Render("main.pas", source))
+	})
+
+	t.Run("warning", func(t *testing.T) {
+		source := "program Main;\n  var unused : integer;\nbegin\nend."
+		e := Error{
+			Code:       ErrorCodeUnusedVariable,
+			Module:     ModuleSemanticAnalyzer,
+			Span:       Span{Row: 2, Col: 7, Len: 6},
+			Suggestion: "UNUSED is declared but never used, remove it",
+			Warning:    true,
+		}
+		assert.Equal(t, "main.pas:2:7: SemanticAnalyzer warning UnusedVariable\n"+
+			"   2 |   var unused : integer;\n"+
+			"     |       ^^^^^^\n"+
+			"hint: UNUSED is declared but never used, remove it\n", e.Render("main.pas", source))
+	})
+
+	t.Run("without span", func(t *testing.T) {
+		e := Error{Code: ErrorCodeIdNotFound, Module: ModuleSemanticAnalyzer, Suggestion: "declare it"}
+		assert.Equal(t, "main.pas:0:0: SemanticAnalyzer error IdNotFound\nhint: declare it\n", e.Render("main.pas", "program Main;"))
+	})
+}
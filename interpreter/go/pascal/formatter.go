@@ -0,0 +1,723 @@
+package pascal
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+const formatIndent = "  "
+
+func NewFormatter(writer io.Writer) *Formatter {
+	return &Formatter{writer: writer}
+}
+
+var _ Visitor = (*Formatter)(nil)
+
+// Formatter writes a program in a canonical layout: lowercase keywords,
+// names spelled as their declarations are written, one statement per line
+// and two spaces per nesting level. The comments are kept, a comment on
+// the line of the token before it stays at the end of that line.
+type Formatter struct {
+	writer io.Writer
+	lines  []string
+	indent int
+	// tokens are all tokens of the source, EOF included, they're looked up
+	// by position to place the comments attached to them.
+	tokens    []*Token
+	positions map[[2]int]int
+	// next is the index of the token starting the last line written.
+	next int
+	// spellings maps the position of an identifier to how it's spelled: as
+	// the declaration it stands for is written, else as it's written itself.
+	spellings map[[2]int]string
+}
+
+func (f *Formatter) Format(source string) (err error) {
+	root, err := parseSource(source)
+	if err != nil {
+		return
+	}
+	if err = f.scan(source); err != nil {
+		return
+	}
+	f.respell(root)
+	if err = Walk(f, root); err != nil {
+		return
+	}
+	f.comments(len(f.tokens) - 1)
+	_, err = io.WriteString(f.writer, strings.Join(f.lines, "\n")+"\n")
+	return
+}
+
+// scan reads the tokens of source and how its identifiers are written.
+func (f *Formatter) scan(source string) (err error) {
+	f.lines, f.indent, f.next = nil, 0, -1
+	f.tokens = nil
+	f.positions = make(map[[2]int]int)
+	f.spellings = make(map[[2]int]string)
+	rows := strings.Split(source, "\n")
+	lexer := NewLexer(source)
+	for {
+		var token *Token
+		if token, err = lexer.GetNextToken(); err != nil {
+			return
+		}
+		f.positions[[2]int{token.Row, token.Col}] = len(f.tokens)
+		f.tokens = append(f.tokens, token)
+		if token.Kind == EOF {
+			return
+		}
+		if token.Kind == ID {
+			row := rows[token.Row-1]
+			f.spellings[[2]int{token.Row, token.Col}] = row[token.Col-1 : token.Col-1+len(token.Value)]
+		}
+	}
+}
+
+// errFormatUnit is the error of the units used by a program formatted, its
+// names are spelled without them.
+var errFormatUnit = errors.New("units aren't read by the formatter")
+
+// respell spells each name as the declaration it stands for is written, a
+// builtin as it's first written. The names the SemanticAnalyzer can't
+// resolve keep their own spelling.
+func (f *Formatter) respell(root ASTNode) {
+	declared := make(map[Symbol]string)
+	analyzer := NewSemanticAnalyzer()
+	analyzer.loader = func(name string) (file, source string, err error) {
+		return "", "", errFormatUnit
+	}
+	analyzer.resolved = func(token *Token, symbol Symbol) {
+		position := [2]int{token.Row, token.Col}
+		spelling, ok := declared[symbol]
+		if !ok {
+			spelling = f.spellings[position]
+			declared[symbol] = spelling
+		}
+		f.spellings[position] = spelling
+	}
+	// NOTE: the routines of a unit are declared by their headings.
+	if unit, ok := root.(*UnitNode); ok {
+		headings := make(map[string]string)
+		for _, heading := range unit.headings {
+			headings[routineName(heading)] = f.spellings[f.declared(heading)]
+		}
+		for _, decl := range unit.block.declarations[unit.public:] {
+			if spelling, ok := headings[routineName(decl)]; ok {
+				f.spellings[f.declared(decl)] = spelling
+			}
+		}
+	}
+	// NOTE: a program with errors is formatted all the same.
+	_ = Walk(analyzer, root)
+}
+
+// spell returns how the identifier token is spelled.
+func (f *Formatter) spell(token *Token) string {
+	return f.spellings[[2]int{token.Row, token.Col}]
+}
+
+func (f *Formatter) Before(node ASTNode) (shouldStepIn bool, err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		program := f.find(Program)
+		f.line(program, fmt.Sprintf("%s %s;", f.keyword(Program), f.spell(f.tokenAfter(program))))
+		if len(n.uses) > 0 {
+			names := make([]string, len(n.uses))
+			for i, token := range n.uses {
+				names[i] = f.spell(token)
+			}
+			f.line(f.find(Uses), fmt.Sprintf("%s %s;", f.keyword(Uses), strings.Join(names, ", ")))
+		}
+		f.blank()
+		if err = f.block(n.block, false); err != nil {
+			return
+		}
+		f.appendLast(TokenNames[Dot])
+	case *UnitNode:
+		err = f.unit(n)
+	case *ProcedureDeclNode:
+		f.blank()
+		f.line(f.find(Procedure), f.heading(n))
+		if err = f.block(n.block, true); err != nil {
+			return
+		}
+		f.appendLast(TokenNames[Semi])
+		f.blank()
+	case *FunctionDeclNode:
+		f.blank()
+		f.line(f.find(Function), f.heading(n))
+		if err = f.block(n.block, true); err != nil {
+			return
+		}
+		f.appendLast(TokenNames[Semi])
+		f.blank()
+	case *CompoundNode:
+		f.line(f.find(Begin), f.keyword(Begin))
+		if err = f.stmts(n.children); err != nil {
+			return
+		}
+		f.line(f.find(End), f.keyword(End))
+	case *AssignNode:
+		f.line(f.firstToken(n.left), fmt.Sprintf("%s %s %s", f.expr(n.left), TokenNames[Assign], f.expr(n.right)))
+	case *ProcedureCallNode:
+		text := f.spell(n.token)
+		if len(n.actualParams) > 0 {
+			text += f.args(n.actualParams)
+		}
+		f.line(n.token, text)
+	case *IfNode:
+		if err = f.ifStmt(n, ""); err != nil {
+			return
+		}
+	case *WhileNode:
+		f.line(n.token, fmt.Sprintf("%s %s %s", f.keyword(While), f.expr(n.condition), f.keyword(Do)))
+		err = f.body(n.body)
+	case *RepeatNode:
+		f.line(n.token, f.keyword(Repeat))
+		if err = f.stmts(n.children); err != nil {
+			return
+		}
+		f.line(f.find(Until), fmt.Sprintf("%s %s", f.keyword(Until), f.expr(n.condition)))
+	case *ForNode:
+		direction := To
+		if n.downto {
+			direction = Downto
+		}
+		f.line(n.token, fmt.Sprintf("%s %s %s %s %s %s %s",
+			f.keyword(For), f.spell(n.varNode.token), TokenNames[Assign],
+			f.expr(n.start), f.keyword(direction), f.expr(n.end), f.keyword(Do)))
+		err = f.body(n.body)
+	}
+	return false, err
+}
+
+func (f *Formatter) After(node ASTNode) error {
+	return nil
+}
+
+// unit writes a unit, the declarations and the headings of its INTERFACE
+// in the order they're written.
+func (f *Formatter) unit(node *UnitNode) (err error) {
+	f.line(f.find(Unit), fmt.Sprintf("%s %s;", f.keyword(Unit), f.spell(node.token)))
+	f.blank()
+	f.line(f.find(Interface), f.keyword(Interface))
+	if len(node.uses) > 0 {
+		names := make([]string, len(node.uses))
+		for i, token := range node.uses {
+			names[i] = f.spell(token)
+		}
+		f.line(f.find(Uses), fmt.Sprintf("%s %s;", f.keyword(Uses), strings.Join(names, ", ")))
+	}
+	f.blank()
+	public := append(append([]ASTNode{}, node.block.declarations[:node.public]...), node.headings...)
+	sort.SliceStable(public, func(i, j int) bool {
+		return f.positions[f.declared(public[i])] < f.positions[f.declared(public[j])]
+	})
+	if err = f.declarations(public, false); err != nil {
+		return
+	}
+	f.blank()
+	f.line(f.find(Implementation), f.keyword(Implementation))
+	f.blank()
+	implementation := &BlockNode{declarations: node.block.declarations[node.public:], compoundStmt: node.block.compoundStmt}
+	if err = f.block(implementation, false); err != nil {
+		return
+	}
+	f.appendLast(TokenNames[Dot])
+	return
+}
+
+// declared returns the position of the name a declaration declares.
+func (f *Formatter) declared(node ASTNode) [2]int {
+	var token *Token
+	switch n := node.(type) {
+	case *ConstDeclNode:
+		token = n.token
+	case *TypeDeclNode:
+		token = n.token
+	case *VarDeclNode:
+		token = n.varNode.token
+	case *ProcedureDeclNode:
+		token = n.token
+	case *FunctionDeclNode:
+		token = n.token
+	}
+	return [2]int{token.Row, token.Col}
+}
+
+// heading returns the heading of a procedure or a function.
+func (f *Formatter) heading(node ASTNode) string {
+	if n, ok := node.(*FunctionDeclNode); ok {
+		return fmt.Sprintf("%s %s%s : %s;", f.keyword(Function), f.spell(n.token), f.params(n.params), f.typ(n.returnType))
+	}
+	n := node.(*ProcedureDeclNode)
+	return fmt.Sprintf("%s %s%s;", f.keyword(Procedure), f.spell(n.token), f.params(n.params))
+}
+
+// routineName returns the name of a procedure or a function, "" if node
+// is neither.
+func routineName(node ASTNode) string {
+	switch n := node.(type) {
+	case *ProcedureDeclNode:
+		return n.name
+	case *FunctionDeclNode:
+		return n.name
+	}
+	return ""
+}
+
+// isHeading reports whether node is the heading of a routine in the
+// INTERFACE of a unit, one without a block.
+func isHeading(node ASTNode) bool {
+	switch n := node.(type) {
+	case *ProcedureDeclNode:
+		return n.block == nil
+	case *FunctionDeclNode:
+		return n.block == nil
+	}
+	return false
+}
+
+// block writes the declarations and the statements of a block, the
+// routines nested in a routine are indented.
+func (f *Formatter) block(node *BlockNode, nested bool) (err error) {
+	if err = f.declarations(node.declarations, nested); err != nil {
+		return
+	}
+	if len(node.declarations) > 0 && !nested {
+		f.blank()
+	}
+	// NOTE: a unit without statements to initialize it ends with END alone.
+	if len(node.compoundStmt.children) == 0 {
+		f.line(f.find(End), f.keyword(End))
+		return
+	}
+	return Walk(f, node.compoundStmt)
+}
+
+// declarations writes the sections of constants, types and variables
+// under their keyword, the routines and the headings of routines between
+// blank lines.
+func (f *Formatter) declarations(declarations []ASTNode, nested bool) (err error) {
+	for len(declarations) > 0 {
+		switch n := declarations[0].(type) {
+		case *ConstDeclNode:
+			f.line(f.tokenBefore(n.token), f.keyword(Const))
+			f.indent += 1
+			for len(declarations) > 0 {
+				constDecl, ok := declarations[0].(*ConstDeclNode)
+				if !ok {
+					break
+				}
+				f.line(constDecl.token, fmt.Sprintf("%s %s %s%s",
+					f.spell(constDecl.token), TokenNames[Equal], f.expr(constDecl.value), TokenNames[Semi]))
+				declarations = declarations[1:]
+			}
+			f.indent -= 1
+		case *TypeDeclNode:
+			f.line(f.tokenBefore(n.token), f.keyword(Type))
+			f.indent += 1
+			for len(declarations) > 0 {
+				typeDecl, ok := declarations[0].(*TypeDeclNode)
+				if !ok {
+					break
+				}
+				f.typeSpec(typeDecl.token, fmt.Sprintf("%s %s ", f.spell(typeDecl.token), TokenNames[Equal]), typeDecl.typNode)
+				declarations = declarations[1:]
+			}
+			f.indent -= 1
+		case *VarDeclNode:
+			f.line(f.tokenBefore(n.varNode.token), f.keyword(Var))
+			f.indent += 1
+			declarations = f.varDecls(declarations)
+			f.indent -= 1
+		default:
+			if isHeading(n) {
+				f.blank()
+				for len(declarations) > 0 && isHeading(declarations[0]) {
+					f.line(f.tokenBefore(f.tokens[f.positions[f.declared(declarations[0])]]), f.heading(declarations[0]))
+					declarations = declarations[1:]
+				}
+				f.blank()
+				break
+			}
+			if nested {
+				f.indent += 1
+			}
+			err = Walk(f, n)
+			if nested {
+				f.indent -= 1
+			}
+			if err != nil {
+				return
+			}
+			declarations = declarations[1:]
+		}
+	}
+	return
+}
+
+// varDecls writes the variable declarations declarations start with, those
+// declared together share a line, it returns the remaining ones.
+func (f *Formatter) varDecls(declarations []ASTNode) []ASTNode {
+	for len(declarations) > 0 {
+		first, ok := declarations[0].(*VarDeclNode)
+		if !ok {
+			break
+		}
+		var names []string
+		for len(declarations) > 0 {
+			varDecl, ok := declarations[0].(*VarDeclNode)
+			if !ok || varDecl.typNode != first.typNode {
+				break
+			}
+			names = append(names, f.spell(varDecl.varNode.token))
+			declarations = declarations[1:]
+		}
+		f.typeSpec(first.varNode.token, fmt.Sprintf("%s %s ", strings.Join(names, TokenNames[Comma]+" "), TokenNames[Colon]), first.typNode)
+	}
+	return declarations
+}
+
+// typeSpec writes head followed by a type, a record takes several lines.
+func (f *Formatter) typeSpec(token *Token, head string, node ASTNode) {
+	for {
+		array, ok := node.(*ArrayTypeNode)
+		if !ok {
+			break
+		}
+		head += fmt.Sprintf("%s%s%s%s%s%s %s ",
+			f.keyword(Array), TokenNames[LBracket], f.expr(array.low), TokenNames[DotDot], f.expr(array.high),
+			TokenNames[RBracket], f.keyword(Of))
+		node = array.elemType
+	}
+	if enum, ok := node.(*EnumTypeNode); ok {
+		values := make([]string, len(enum.values))
+		for i, value := range enum.values {
+			values[i] = f.spell(value)
+		}
+		f.line(token, head+TokenNames[LParen]+strings.Join(values, TokenNames[Comma]+" ")+TokenNames[RParen]+TokenNames[Semi])
+		return
+	}
+	record, ok := node.(*RecordTypeNode)
+	if !ok {
+		f.line(token, head+f.typ(node.(*TypNode))+TokenNames[Semi])
+		return
+	}
+	f.line(token, head+f.keyword(Record))
+	f.indent += 1
+	fields := make([]ASTNode, len(record.fields))
+	for i, field := range record.fields {
+		fields[i] = field
+	}
+	f.varDecls(fields)
+	f.indent -= 1
+	f.line(f.find(End), f.keyword(End)+TokenNames[Semi])
+}
+
+// ifStmt writes an IF statement, prefix is ELSE for an ELSE IF.
+func (f *Formatter) ifStmt(node *IfNode, prefix string) (err error) {
+	f.line(node.token, fmt.Sprintf("%s%s %s %s", prefix, f.keyword(If), f.expr(node.condition), f.keyword(Then)))
+	if err = f.body(node.thenStmt); err != nil {
+		return
+	}
+	if node.elseStmt == nil {
+		return
+	}
+	if elseIf, ok := node.elseStmt.(*IfNode); ok {
+		return f.ifStmt(elseIf, f.keyword(Else)+" ")
+	}
+	f.line(f.find(Else), f.keyword(Else))
+	return f.body(node.elseStmt)
+}
+
+// body writes the statement of a structured statement, it's indented
+// unless it's a compound statement.
+func (f *Formatter) body(node ASTNode) error {
+	if _, ok := node.(*CompoundNode); ok {
+		return Walk(f, node)
+	}
+	f.indent += 1
+	defer func() { f.indent -= 1 }()
+	return Walk(f, node)
+}
+
+// stmts writes a statement list indented, empty statements are dropped.
+func (f *Formatter) stmts(nodes []ASTNode) (err error) {
+	var stmts []ASTNode
+	for _, node := range nodes {
+		if _, ok := node.(*NoopNode); !ok {
+			stmts = append(stmts, node)
+		}
+	}
+	f.indent += 1
+	defer func() { f.indent -= 1 }()
+	for i, stmt := range stmts {
+		if err = Walk(f, stmt); err != nil {
+			return
+		}
+		if i+1 != len(stmts) {
+			f.appendLast(TokenNames[Semi])
+		}
+	}
+	return
+}
+
+// precedence of the binary operators, as in expr, simple_expr and term.
+func precedence(op TokenKind) int {
+	switch op {
+	case Equal, NotEqual, Less, LessEqual, Greater, GreaterEqual:
+		return 1
+	case Plus, Minus, Or:
+		return 2
+	}
+	return 3
+}
+
+// expr writes an expression, with parentheses only where they're needed.
+func (f *Formatter) expr(node ASTNode) string {
+	switch n := node.(type) {
+	case *NumNode:
+		return n.token.Value
+	case *BoolNode:
+		return f.keyword(n.token.Kind)
+	case *StrNode:
+		return n.token.Value
+	case *UnaryOpNode:
+		operand := f.expr(n.operand)
+		if _, ok := n.operand.(*BinOpNode); ok {
+			operand = TokenNames[LParen] + operand + TokenNames[RParen]
+		}
+		if n.op == Not {
+			return f.keyword(Not) + " " + operand
+		}
+		return TokenNames[n.op] + operand
+	case *BinOpNode:
+		left, right := f.expr(n.left), f.expr(n.right)
+		// NOTE: relational operators don't associate, other operators
+		// associate to the left.
+		if operand, ok := n.left.(*BinOpNode); ok &&
+			(precedence(operand.op) < precedence(n.op) || precedence(n.op) == 1) {
+			left = TokenNames[LParen] + left + TokenNames[RParen]
+		}
+		if operand, ok := n.right.(*BinOpNode); ok && precedence(operand.op) <= precedence(n.op) {
+			right = TokenNames[LParen] + right + TokenNames[RParen]
+		}
+		return strings.Join([]string{left, f.keyword(n.op), right}, " ")
+	case *VarNode:
+		return f.spell(n.token)
+	case *FunctionCallNode:
+		return f.spell(n.token) + f.args(n.actualParams)
+	case *IndexNode:
+		return f.expr(n.base) + TokenNames[LBracket] + f.expr(n.index) + TokenNames[RBracket]
+	case *FieldNode:
+		return f.expr(n.base) + TokenNames[Dot] + f.spell(n.token)
+	}
+	panic("unreachable")
+}
+
+func (f *Formatter) typ(node *TypNode) string {
+	if node.token.Kind == ID {
+		return f.spell(node.token)
+	}
+	return f.keyword(node.token.Kind)
+}
+
+// params writes formal parameters, those declared together are grouped.
+func (f *Formatter) params(params []*ParamNode) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var groups []string
+	for len(params) > 0 {
+		first := params[0]
+		var names []string
+		for len(params) > 0 && params[0].typNode == first.typNode {
+			names = append(names, f.spell(params[0].varNode.token))
+			params = params[1:]
+		}
+		group := fmt.Sprintf("%s %s %s", strings.Join(names, TokenNames[Comma]+" "), TokenNames[Colon], f.typ(first.typNode))
+		if first.byReference {
+			group = f.keyword(Var) + " " + group
+		}
+		groups = append(groups, group)
+	}
+	return TokenNames[LParen] + strings.Join(groups, TokenNames[Semi]+" ") + TokenNames[RParen]
+}
+
+func (f *Formatter) args(actualParams []ASTNode) string {
+	args := make([]string, len(actualParams))
+	for i, actualParam := range actualParams {
+		args[i] = f.expr(actualParam)
+	}
+	return TokenNames[LParen] + strings.Join(args, TokenNames[Comma]+" ") + TokenNames[RParen]
+}
+
+// keyword writes a keyword or an operator, keywords are lowercase.
+func (f *Formatter) keyword(kind TokenKind) string {
+	return strings.ToLower(TokenNames[kind])
+}
+
+// firstToken is the token an assignment target starts with.
+func (f *Formatter) firstToken(node ASTNode) *Token {
+	switch n := node.(type) {
+	case *IndexNode:
+		return f.firstToken(n.base)
+	case *FieldNode:
+		return f.firstToken(n.base)
+	}
+	return node.(*VarNode).token
+}
+
+// find returns the first token of kind after the last line's, nil if
+// there's none.
+func (f *Formatter) find(kind TokenKind) *Token {
+	for _, token := range f.tokens[f.next+1:] {
+		if token.Kind == kind {
+			return token
+		}
+	}
+	return nil
+}
+
+// tokenAfter returns the token written just after token in the source.
+func (f *Formatter) tokenAfter(token *Token) *Token {
+	return f.tokens[f.positions[[2]int{token.Row, token.Col}]+1]
+}
+
+// tokenBefore returns the token written just before token in the source.
+func (f *Formatter) tokenBefore(token *Token) *Token {
+	if i, ok := f.positions[[2]int{token.Row, token.Col}]; ok && i > 0 {
+		return f.tokens[i-1]
+	}
+	return nil
+}
+
+// line writes a line starting with token, the comments before the token
+// are written first, token may be nil if it's unknown.
+func (f *Formatter) line(token *Token, text string) {
+	if token != nil {
+		if i, ok := f.positions[[2]int{token.Row, token.Col}]; ok && i > f.next {
+			f.comments(i)
+		}
+	}
+	f.lines = append(f.lines, strings.Repeat(formatIndent, f.indent)+text)
+}
+
+// comments writes the comments attached to the tokens up to the index
+// last, a comment on the row of the token before it ends that token's line.
+func (f *Formatter) comments(last int) {
+	for i := f.next + 1; i <= last; i++ {
+		for _, comment := range f.tokens[i].Comments {
+			if i > 0 && comment.Row == f.tokens[i-1].Row && f.trailing(comment.Text) {
+				continue
+			}
+			f.lines = append(f.lines, strings.Repeat(formatIndent, f.indent)+comment.Text)
+		}
+	}
+	f.next = last
+}
+
+// trailing appends a comment to the last line that isn't blank.
+func (f *Formatter) trailing(comment string) bool {
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		if f.lines[i] != "" {
+			f.lines[i] += " " + comment
+			return true
+		}
+	}
+	return false
+}
+
+// blank writes an empty line, unless there's one already.
+func (f *Formatter) blank() {
+	if len(f.lines) > 0 && f.lines[len(f.lines)-1] != "" {
+		f.lines = append(f.lines, "")
+	}
+}
+
+// appendLast appends s to the last line that isn't blank.
+func (f *Formatter) appendLast(s string) {
+	for i := len(f.lines) - 1; i >= 0; i-- {
+		if f.lines[i] != "" {
+			f.lines[i] += s
+			return
+		}
+	}
+}
+
+// FormatFiles implements the fmt subcommand: without files it formats the
+// standard input, -l lists the files whose formatting differs and -w
+// writes the formatted source back to them. It returns the exit code.
+func FormatFiles(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	list := flags.Bool("l", false, "list files whose formatting differs")
+	write := flags.Bool("w", false, "write the result to the source file instead of the standard output")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		source, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err = NewFormatter(stdout).Format(string(source)); err != nil {
+			RenderError(stderr, err, "<stdin>", string(source))
+			return 1
+		}
+		return 0
+	}
+
+	code := 0
+	for _, name := range flags.Args() {
+		source, err := ioutil.ReadFile(name)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			code = 1
+			continue
+		}
+		formatted := bytes.Buffer{}
+		if err = NewFormatter(&formatted).Format(string(source)); err != nil {
+			RenderError(stderr, err, name, string(source))
+			code = 1
+			continue
+		}
+		changed := !bytes.Equal(source, formatted.Bytes())
+		if *list && changed {
+			fmt.Fprintln(stdout, name)
+		}
+		if *write && changed {
+			if err = ioutil.WriteFile(name, formatted.Bytes(), 0644); err != nil {
+				fmt.Fprintln(stderr, err)
+				code = 1
+			}
+		}
+		if !*list && !*write {
+			stdout.Write(formatted.Bytes())
+		}
+	}
+	return code
+}
+
+// RenderError writes err of the source named name as the command line
+// prints it, rendered with its source line if it has a span.
+func RenderError(writer io.Writer, err error, name, source string) {
+	switch e := err.(type) {
+	case Error:
+		fmt.Fprint(writer, e.Render(name, source))
+	case ErrorList:
+		fmt.Fprint(writer, e.Render(name, source))
+	default:
+		fmt.Fprintln(writer, err)
+	}
+}
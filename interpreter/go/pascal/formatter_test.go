@@ -0,0 +1,399 @@
+package pascal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatter_Format(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		wantSource  string
+	}{
+		"keywords and spellings": {
+			givenSource: `
+PROGRAM Main; VAR Total, i : INTEGER;
+BEGIN total := 0; FOR I := 1 TO 10 DO total := TOTAL + i; WRITELN(Total) END.
+`,
+			wantSource: `
+program Main;
+
+var
+  Total, i : integer;
+
+begin
+  Total := 0;
+  for i := 1 to 10 do
+    Total := Total + i;
+  WRITELN(Total)
+end.
+`,
+		},
+		"routines": {
+			givenSource: `
+program Main;
+var x : integer;
+procedure Alpha(a, b : integer; var c : real);
+   var y : integer;
+   function Beta(n : integer) : integer;
+   begin Beta := n * 2 end;
+begin y := Beta(a); c := y / b end;
+begin Alpha(1, 2, x); end.
+`,
+			wantSource: `
+program Main;
+
+var
+  x : integer;
+
+procedure Alpha(a, b : integer; var c : real);
+var
+  y : integer;
+
+  function Beta(n : integer) : integer;
+  begin
+    Beta := n * 2
+  end;
+
+begin
+  y := Beta(a);
+  c := y / b
+end;
+
+begin
+  Alpha(1, 2, x)
+end.
+`,
+		},
+		"spellings by scope": {
+			givenSource: `
+program Main;
+const N = 3;
+type R = record X : integer end;
+var p : R;
+procedure Show(r : integer); var n : integer; begin n := R; writeln(r, n) end;
+begin p.x := n; Show(P.X) end.
+`,
+			wantSource: `
+program Main;
+
+const
+  N = 3;
+type
+  R = record
+    X : integer;
+  end;
+var
+  p : R;
+
+procedure Show(r : integer);
+var
+  n : integer;
+begin
+  n := r;
+  writeln(r, n)
+end;
+
+begin
+  p.X := N;
+  Show(p.X)
+end.
+`,
+		},
+		"uses clause": {
+			givenSource: `
+PROGRAM Main; USES Shapes,COUNTER;
+BEGIN Tick(1) END.
+`,
+			wantSource: `
+program Main;
+uses Shapes, COUNTER;
+
+begin
+  Tick(1)
+end.
+`,
+		},
+		"types": {
+			givenSource: `
+program Main;
+type Vec = array[-1..3] of integer; Point = record x, y : real; tag : char end;
+var v : Vec; grid : array[1..2] of array[1..2] of Point; s : string;
+begin end.
+`,
+			wantSource: `
+program Main;
+
+type
+  Vec = array[-1..3] of integer;
+  Point = record
+    x, y : real;
+    tag : char;
+  end;
+var
+  v : Vec;
+  grid : array[1..2] of array[1..2] of Point;
+  s : string;
+
+begin
+end.
+`,
+		},
+		"constants and enumerated types": {
+			givenSource: `
+program Main;
+CONST Max = 2 * 3; greeting = 'hi'; type Color = (RED, Green,blue);
+var c : color;
+begin c := SUCC(red); writeln(Greeting, MAX) end.
+`,
+			wantSource: `
+program Main;
+
+const
+  Max = 2 * 3;
+  greeting = 'hi';
+type
+  Color = (RED, Green, blue);
+var
+  c : Color;
+
+begin
+  c := SUCC(RED);
+  writeln(greeting, Max)
+end.
+`,
+		},
+		"structured statements": {
+			givenSource: `
+program Main;
+var i : integer; done : boolean;
+begin
+  if i = 1 then writeln('one') else if i = 2 then begin writeln('two'); done := true end else writeln('many');
+  while not done do done := true;
+  repeat i := i - 1; until i <= 0;
+  for i := 10 downto 1 do begin end
+end.
+`,
+			wantSource: `
+program Main;
+
+var
+  i : integer;
+  done : boolean;
+
+begin
+  if i = 1 then
+    writeln('one')
+  else if i = 2 then
+  begin
+    writeln('two');
+    done := true
+  end
+  else
+    writeln('many');
+  while not done do
+    done := true;
+  repeat
+    i := i - 1
+  until i <= 0;
+  for i := 10 downto 1 do
+  begin
+  end
+end.
+`,
+		},
+		"parentheses": {
+			givenSource: `
+program Main;
+var a, b, c : integer; p : boolean;
+begin
+  a := ((a + b)) * c - (b - c) - -(a + 1);
+  a := a - (b + c) + (a * b);
+  p := not (a < b) and ((a = b) or p)
+end.
+`,
+			wantSource: `
+program Main;
+
+var
+  a, b, c : integer;
+  p : boolean;
+
+begin
+  a := (a + b) * c - (b - c) - -(a + 1);
+  a := a - (b + c) + a * b;
+  p := not (a < b) and ((a = b) or p)
+end.
+`,
+		},
+		"comments": {
+			givenSource: `
+{ Header }
+program Main; { the program }
+var x : integer; { a counter }
+{ Alpha adds one }
+procedure Alpha;
+begin x := x + 1 end;
+begin { Main }
+   { reset }
+   x := 0;      { start }
+   Alpha
+end.  { Main }
+`,
+			wantSource: `
+{ Header }
+program Main; { the program }
+
+var
+  x : integer; { a counter }
+
+{ Alpha adds one }
+procedure Alpha;
+begin
+  x := x + 1
+end;
+
+begin { Main }
+  { reset }
+  x := 0; { start }
+  Alpha
+end. { Main }
+`,
+		},
+		"unit": {
+			givenSource: `
+UNIT shapes; { the shapes }
+INTERFACE USES counter;
+TYPE color = (red, green);
+FUNCTION area(w, h : INTEGER) : INTEGER;
+PROCEDURE reset;
+VAR total : integer;
+IMPLEMENTATION
+VAR calls : integer;
+function Area(w, h : integer) : integer;
+begin calls := calls + 1; AREA := w * h end;
+procedure Reset; begin total := 0 end;
+BEGIN total := 0; count := 1 END.
+`,
+			wantSource: `
+unit shapes; { the shapes }
+
+interface
+uses counter;
+
+type
+  color = (red, green);
+
+function area(w, h : integer) : integer;
+procedure reset;
+
+var
+  total : integer;
+
+implementation
+
+var
+  calls : integer;
+
+function area(w, h : integer) : integer;
+begin
+  calls := calls + 1;
+  area := w * h
+end;
+
+procedure reset;
+begin
+  total := 0
+end;
+
+begin
+  total := 0;
+  count := 1
+end.
+`,
+		},
+		"unit without statements": {
+			givenSource: `unit Empty; interface implementation END.`,
+			wantSource: `
+unit Empty;
+
+interface
+
+implementation
+
+end.
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := bytes.NewBuffer(nil)
+			err := NewFormatter(w).Format(tc.givenSource)
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimPrefix(tc.wantSource, "\n"), w.String())
+
+			// formatting a formatted source changes nothing.
+			again := bytes.NewBuffer(nil)
+			err = NewFormatter(again).Format(w.String())
+			assert.NoError(t, err)
+			assert.Equal(t, w.String(), again.String())
+		})
+	}
+}
+
+func TestFormatter_FormatError(t *testing.T) {
+	err := NewFormatter(ioutil.Discard).Format("program Main; begin x := end.")
+	assert.Error(t, err)
+}
+
+func TestFormatFiles(t *testing.T) {
+	dir := t.TempDir()
+	formatted := filepath.Join(dir, "formatted.pas")
+	unformatted := filepath.Join(dir, "unformatted.pas")
+	assert.NoError(t, ioutil.WriteFile(formatted, []byte("program Main;\n\nbegin\nend.\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(unformatted, []byte("PROGRAM Main; BEGIN END."), 0644))
+
+	stdout, stderr := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	code := FormatFiles([]string{"-l", formatted, unformatted}, nil, stdout, stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, unformatted+"\n", stdout.String())
+
+	stdout.Reset()
+	code = FormatFiles([]string{"-w", unformatted}, nil, stdout, stderr)
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stdout.String())
+	source, err := ioutil.ReadFile(unformatted)
+	assert.NoError(t, err)
+	assert.Equal(t, "program Main;\n\nbegin\nend.\n", string(source))
+
+	stdout.Reset()
+	code = FormatFiles(nil, strings.NewReader("PROGRAM Main; BEGIN END."), stdout, stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "program Main;\n\nbegin\nend.\n", stdout.String())
+
+	unit := filepath.Join(dir, "mathx.pas")
+	assert.NoError(t, ioutil.WriteFile(unit, []byte("unit MathX; interface implementation end."), 0644))
+	stdout.Reset()
+	code = FormatFiles([]string{unit}, nil, stdout, stderr)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "unit MathX;\n\ninterface\n\nimplementation\n\nend.\n", stdout.String())
+
+	empty := filepath.Join(dir, "empty.pas")
+	assert.NoError(t, ioutil.WriteFile(empty, nil, 0644))
+	stderr.Reset()
+	code = FormatFiles([]string{empty}, nil, stdout, stderr)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "expected PROGRAM")
+
+	code = FormatFiles([]string{filepath.Join(dir, "missing.pas")}, nil, stdout, stderr)
+	assert.Equal(t, 1, code)
+	_, err = os.Stat(filepath.Join(dir, "missing.pas"))
+	assert.True(t, os.IsNotExist(err))
+}
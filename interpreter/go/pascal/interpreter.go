@@ -0,0 +1,684 @@
+package pascal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxCallDepth bounds the number of activation records on the call stack,
+// deeper calls fail with ErrorCodeStackOverflow.
+const maxCallDepth = 4096
+
+// Limits bound a run of a program so that an untrusted one can't run
+// forever, a zero limit is no limit. Exceeding one fails the run with a
+// runtime Error of its own code.
+type Limits struct {
+	// Steps bounds the statements the Interpreter runs, or the
+	// instructions the VM runs, ErrorCodeStepLimitExceeded.
+	Steps int
+	// CallDepth bounds the nested calls, maxCallDepth if zero since the
+	// Interpreter recurses on the Go stack, ErrorCodeStackOverflow.
+	CallDepth int
+	// Memory bounds the members of the activation records on the call
+	// stack, or the slots of the VM's frames, an ARRAY or a RECORD counting
+	// its elements or fields, ErrorCodeMemoryLimitExceeded. One too large to
+	// count is never allocated.
+	Memory int
+}
+
+// withDefaults returns the limits with CallDepth set.
+func (l Limits) withDefaults() Limits {
+	if l.CallDepth <= 0 {
+		l.CallDepth = maxCallDepth
+	}
+	return l
+}
+
+// NewInterpreter creates an Interpreter, READLN reads from reader and
+// WRITE/WRITELN write to writer.
+func NewInterpreter(reader io.Reader, writer io.Writer) *Interpreter {
+	return &Interpreter{
+		callStack: new(CallStack),
+		reader:    bufio.NewReader(reader),
+		writer:    writer,
+		limits:    Limits{}.withDefaults(),
+		ctx:       context.Background(),
+	}
+}
+
+var _ Visitor = (*Interpreter)(nil)
+
+type Interpreter struct {
+	callStack *CallStack
+	reader    *bufio.Reader
+	writer    io.Writer
+	limits    Limits
+	// NOTE: the Visitor methods can't take a context, so the one of the
+	// run is kept here, it's checked before each statement.
+	ctx   context.Context
+	steps int
+	// hook is called before each node is run, the Debugger stops the
+	// program in it.
+	hook func(node ASTNode) error
+	// enter and leave are called as the record of a call of a routine is
+	// pushed and popped, the Profiler times calls with them.
+	enter func(node ASTNode)
+	leave func(node ASTNode)
+	// optimizer rewrites the tree before it's run, if set.
+	optimizer *Optimizer
+}
+
+func (it *Interpreter) Interpret(source string) (err error) {
+	program, err := Compile(source)
+	if err != nil {
+		return
+	}
+	if program, err = program.optimize(it.optimizer); err != nil {
+		return
+	}
+	return it.run(context.Background(), program.root)
+}
+
+// run walks the checked tree of a program until it ends or ctx is done.
+func (it *Interpreter) run(ctx context.Context, root ASTNode) (err error) {
+	it.ctx, it.steps = ctx, 0
+	// NOTE: runtime errors are returned, a panic means a bug in the interpreter,
+	// still it must not bring down the process embedding it.
+	defer func() {
+		if r := recover(); r != nil {
+			err = it.internal("%v", r)
+		}
+	}()
+	return Walk(it, root)
+}
+
+func (it *Interpreter) Before(node ASTNode) (shouldStepIn bool, err error) {
+	if token := statementToken(node); token != nil {
+		if err = it.step(token); err != nil {
+			return
+		}
+	}
+	if it.hook != nil {
+		if err = it.hook(node); err != nil {
+			return
+		}
+	}
+	switch n := node.(type) {
+	case *ProgramNode:
+		// NOTE: the units are initialized first, the record of each is
+		// nested in the one before and the program's in the last one, so
+		// that the static links reach all of them.
+		var enclosing *ActivationRecord
+		for _, unit := range n.units {
+			ar := NewActivationRecord(unit.name, ARKindUnit, unit.level)
+			ar.StaticLink, enclosing = enclosing, ar
+			it.callStack.Push(ar)
+			log.Debugf("ENTER: UNIT %s\n", unit.name)
+			if err = Walk(it, unit.block); err != nil {
+				return
+			}
+		}
+		ar := NewActivationRecord(n.name, ARKindProgram, len(n.units)+1)
+		ar.StaticLink = enclosing
+		it.callStack.Push(ar)
+		log.Debugf("ENTER: PROGRAM %s\n", n.name)
+		log.Debugln(it.callStack)
+	case *BlockNode:
+	case *CompoundNode:
+	case *VarDeclNode:
+		// an ARRAY or a RECORD is allocated as it's declared, once it's
+		// known to fit the memory limit.
+		switch n.varNode.typ.(type) {
+		case *ArrayTypeSymbol, *RecordTypeSymbol:
+			used := 0
+			if it.limits.Memory > 0 {
+				used = it.callStack.Members()
+			}
+			if problem := allocation(n.varNode.typ, used, it.limits.Memory); problem != "" {
+				return false, it.error(ErrorCodeMemoryLimitExceeded, n.varNode.token, problem)
+			}
+			it.callStack.Peek().Set(n.varNode.value, newValue(n.varNode.typ))
+		}
+		return false, nil
+	case *ConstDeclNode, *TypeDeclNode:
+		return false, nil
+	case *ProcedureDeclNode:
+		return false, nil
+	case *FunctionDeclNode:
+		return false, nil
+	case *ProcedureCallNode:
+		if n.builtin != nil {
+			return false, it.callBuiltin(n)
+		}
+		ar := NewActivationRecord(n.name, ARKindProcedure, n.procSymbol.scopeLevel+1)
+		if err = it.bind(ar, n.procSymbol.formalParams, n.actualParams); err != nil {
+			return
+		}
+		if err = it.push(ar, n.token); err != nil {
+			return
+		}
+		if it.enter != nil {
+			it.enter(n)
+		}
+		log.Debugf("ENTER: PROCEDURE %s", n.name)
+		log.Debugln(it.callStack)
+	case *AssignNode:
+		var rhs interface{}
+		if rhs, err = it.expr(n.right); err != nil {
+			return
+		}
+		return false, it.assign(n.left, coerce(typeOf(n.left), copyValue(rhs)))
+	case *IfNode:
+		var cond bool
+		if cond, err = it.condition(n.condition); err != nil {
+			return
+		}
+		if cond {
+			return false, Walk(it, n.thenStmt)
+		}
+		if n.elseStmt != nil {
+			return false, Walk(it, n.elseStmt)
+		}
+		return false, nil
+	case *WhileNode:
+		for {
+			var cond bool
+			if cond, err = it.condition(n.condition); err != nil || !cond {
+				return false, err
+			}
+			if err = Walk(it, n.body); err != nil {
+				return
+			}
+			// NOTE: each iteration is a step, the body may have none.
+			if err = it.step(n.token); err != nil {
+				return
+			}
+		}
+	case *RepeatNode:
+		for {
+			for _, child := range n.children {
+				if err = Walk(it, child); err != nil {
+					return
+				}
+			}
+			var cond bool
+			if cond, err = it.condition(n.condition); err != nil || cond {
+				return false, err
+			}
+			if err = it.step(n.token); err != nil {
+				return
+			}
+		}
+	case *ForNode:
+		// NOTE: both bounds are evaluated once, before the first iteration.
+		var start, end interface{}
+		if start, err = it.expr(n.start); err != nil {
+			return
+		}
+		if end, err = it.expr(n.end); err != nil {
+			return
+		}
+		step := int64(1)
+		if n.downto {
+			step = -1
+		}
+		for i := start.(int64); (!n.downto && i <= end.(int64)) || (n.downto && i >= end.(int64)); i += step {
+			it.record(n.varNode).Set(n.varNode.value, i)
+			if err = Walk(it, n.body); err != nil {
+				return
+			}
+			if err = it.step(n.token); err != nil {
+				return
+			}
+		}
+		return false, nil
+	case *NoopNode:
+	default:
+		return false, it.internal("unexpected node %T", n)
+	}
+	return true, nil
+}
+
+func (it *Interpreter) After(node ASTNode) (err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		log.Debugf("LEAVE: PROGRAM %s", n.name)
+		log.Debugln(it.callStack)
+		it.callStack.Pop()
+		for range n.units {
+			it.callStack.Pop()
+		}
+	case *ProcedureCallNode:
+		if it.leave != nil {
+			it.leave(n)
+		}
+		log.Debugf("LEAVE: PROCEDURE %s", n.name)
+		log.Debugln(it.callStack)
+		it.callStack.Pop()
+	}
+	return
+}
+
+func (it *Interpreter) expr(node ASTNode) (value interface{}, err error) {
+	switch n := node.(type) {
+	case *NumNode:
+		if n.token.Kind == IntegerConst {
+			return int64(n.intValue), nil
+		} else if n.token.Kind == RealConst {
+			return n.floatValue, nil
+		}
+		return nil, it.internal("invalid token in NumNode: %s", n.token)
+	case *BoolNode:
+		return n.value, nil
+	case *StrNode:
+		return n.value, nil
+	case *FunctionCallNode:
+		if n.builtin != nil {
+			return it.callBuiltinFunction(n)
+		}
+		// NOTE: every call gets a fresh record, recursive calls share the nesting level.
+		ar := NewActivationRecord(n.name, ARKindFunction, n.funcSymbol.scopeLevel+1)
+		if err = it.bind(ar, n.funcSymbol.formalParams, n.actualParams); err != nil {
+			return
+		}
+		if err = it.push(ar, n.token); err != nil {
+			return
+		}
+		if it.enter != nil {
+			it.enter(n)
+		}
+		log.Debugf("ENTER: FUNCTION %s", n.name)
+		log.Debugln(it.callStack)
+		if err = Walk(it, n.funcSymbol.blockNode); err != nil {
+			return
+		}
+		// the result is whatever was last assigned to the function name.
+		value, ok := ar.Get(n.name)
+		if !ok {
+			return nil, it.error(ErrorCodeUninitializedVariable, n.token, "function result is not set")
+		}
+		if it.leave != nil {
+			it.leave(n)
+		}
+		log.Debugf("LEAVE: FUNCTION %s", n.name)
+		log.Debugln(it.callStack)
+		it.callStack.Pop()
+		return value, nil
+	case *VarNode:
+		if n.constant != nil {
+			return n.constant.value, nil
+		}
+		if v, ok := it.record(n).Get(n.value); ok {
+			return v, nil
+		}
+		return nil, it.error(ErrorCodeUninitializedVariable, n.token, "variable is read before it is assigned")
+	case *IndexNode:
+		var array *ArrayValue
+		var index int64
+		if array, index, err = it.element(n); err != nil {
+			return
+		}
+		value, _ = array.Get(index)
+		if value == nil {
+			return nil, it.error(ErrorCodeUninitializedVariable, n.token, "element is read before it is assigned")
+		}
+		return value, nil
+	case *FieldNode:
+		var record *RecordValue
+		if record, err = it.recordOf(n); err != nil {
+			return
+		}
+		if value = record.fields[n.index]; value == nil {
+			return nil, it.error(ErrorCodeUninitializedVariable, n.token, "field is read before it is assigned")
+		}
+		return value, nil
+	case *UnaryOpNode:
+		if value, err = it.expr(n.operand); err != nil {
+			return
+		}
+		switch n.op {
+		case Minus:
+			return negate(value), nil
+		case Not:
+			return !value.(bool), nil
+		}
+		return value, nil
+	case *BinOpNode:
+		var lhs, rhs interface{}
+		if lhs, err = it.expr(n.left); err != nil {
+			return
+		}
+		// NOTE: AND and OR short-circuit, the right operand is only evaluated when needed.
+		switch {
+		case n.op == And && !lhs.(bool):
+			return false, nil
+		case n.op == Or && lhs.(bool):
+			return true, nil
+		}
+		if rhs, err = it.expr(n.right); err != nil {
+			return
+		}
+		if n.op == And || n.op == Or {
+			return rhs, nil
+		}
+		if (n.op == IntegerDiv || n.op == FloatDiv) && isZero(rhs) {
+			return nil, it.error(ErrorCodeDivisionByZero, n.token, "division by zero")
+		}
+		return binaryOp(n.op, lhs, rhs), nil
+	}
+	return nil, it.internal("unexpected expression %T", node)
+}
+
+// condition evaluates the BOOLEAN condition of a structured statement.
+func (it *Interpreter) condition(node ASTNode) (cond bool, err error) {
+	value, err := it.expr(node)
+	if err != nil {
+		return
+	}
+	return value.(bool), nil
+}
+
+func (it *Interpreter) callBuiltin(n *ProcedureCallNode) (err error) {
+	switch n.name {
+	case "WRITE", "WRITELN":
+		// NOTE: the arguments are all evaluated before any is written, as
+		// on the VM, so that an error writes nothing.
+		values := make([]interface{}, len(n.actualParams))
+		for i, actualParam := range n.actualParams {
+			if values[i], err = it.expr(actualParam); err != nil {
+				return
+			}
+		}
+		for _, value := range values {
+			if _, err = io.WriteString(it.writer, formatValue(value)); err != nil {
+				return
+			}
+		}
+		if n.name == "WRITELN" {
+			_, err = io.WriteString(it.writer, "\n")
+		}
+	case "READLN":
+		fields, problem, readErr := readFields(it.reader, len(n.actualParams), restField(n.actualParams))
+		if readErr != nil {
+			return readErr
+		}
+		if problem != "" {
+			return it.error(ErrorCodeInvalidInput, n.token, problem)
+		}
+		for i, actualParam := range n.actualParams {
+			typ := typeOf(actualParam)
+			var value interface{}
+			if value, err = parseValue(typ, fields[i]); err != nil {
+				return it.error(ErrorCodeInvalidInput, n.token, fmt.Sprintf("invalid %s %q", typ, fields[i]))
+			}
+			if err = it.assign(actualParam, value); err != nil {
+				return
+			}
+		}
+	default:
+		return it.internal("unknown builtin procedure %s", n.name)
+	}
+	return
+}
+
+func (it *Interpreter) callBuiltinFunction(n *FunctionCallNode) (value interface{}, err error) {
+	args := make([]interface{}, len(n.actualParams))
+	for i, actualParam := range n.actualParams {
+		if args[i], err = it.expr(actualParam); err != nil {
+			return
+		}
+	}
+	value, problem := callBuiltinFunction(n.name, args)
+	if problem != "" {
+		return nil, it.error(ErrorCodeInvalidArgument, n.token, problem)
+	}
+	return value, nil
+}
+
+// assign stores value into a variable, an element or a field.
+func (it *Interpreter) assign(node ASTNode, value interface{}) (err error) {
+	switch n := node.(type) {
+	case *VarNode:
+		it.record(n).Set(n.value, value)
+	case *IndexNode:
+		var array *ArrayValue
+		var index int64
+		if array, index, err = it.element(n); err != nil {
+			return
+		}
+		array.Set(index, value)
+	case *FieldNode:
+		var record *RecordValue
+		if record, err = it.recordOf(n); err != nil {
+			return
+		}
+		record.fields[n.index] = value
+	default:
+		return it.internal("unexpected assignment target %T", n)
+	}
+	return
+}
+
+// element evaluates the array and the index of an element, the index must
+// be within the bounds of the array.
+func (it *Interpreter) element(n *IndexNode) (array *ArrayValue, index int64, err error) {
+	base, err := it.expr(n.base)
+	if err != nil {
+		return
+	}
+	value, err := it.expr(n.index)
+	if err != nil {
+		return
+	}
+	array, index = base.(*ArrayValue), value.(int64)
+	if _, inRange := array.Get(index); !inRange {
+		return nil, 0, it.error(ErrorCodeIndexOutOfRange, n.token, fmt.Sprintf("index %d out of range %s", index, array.Bounds()))
+	}
+	return
+}
+
+// reference returns the Reference passed for a variable, an element or a
+// field to a VAR parameter, the index of an element is evaluated once.
+func (it *Interpreter) reference(node ASTNode) (ref *Reference, err error) {
+	switch n := node.(type) {
+	case *VarNode:
+		return it.record(n).Reference(n.value), nil
+	case *IndexNode:
+		var array *ArrayValue
+		var index int64
+		if array, index, err = it.element(n); err != nil {
+			return
+		}
+		return &Reference{slot: array.Slot(index)}, nil
+	case *FieldNode:
+		var record *RecordValue
+		if record, err = it.recordOf(n); err != nil {
+			return
+		}
+		return &Reference{slot: &record.fields[n.index]}, nil
+	}
+	return nil, it.internal("unexpected VAR argument %T", node)
+}
+
+// recordOf evaluates the record of a field.
+func (it *Interpreter) recordOf(n *FieldNode) (record *RecordValue, err error) {
+	base, err := it.expr(n.base)
+	if err != nil {
+		return
+	}
+	return base.(*RecordValue), nil
+}
+
+// readFields reads a line holding count fields for READLN, the field rest
+// being the rest of the line, problem describes invalid input. Blanks
+// separate the fields.
+func readFields(reader *bufio.Reader, count, rest int) (fields []string, problem string, err error) {
+	line, err := reader.ReadString('\n')
+	if err == io.EOF {
+		if line == "" && count > 0 {
+			return nil, "unexpected end of input", nil
+		}
+		err = nil
+	} else if err != nil {
+		return
+	}
+	line = strings.TrimRight(line, "\r\n")
+	for len(fields) < count {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if len(fields) == rest {
+			fields = append(fields, line)
+			break
+		}
+		end := strings.IndexFunc(line, unicode.IsSpace)
+		if end < 0 {
+			end = len(line)
+		}
+		if end == 0 {
+			break
+		}
+		fields, line = append(fields, line[:end]), line[end:]
+	}
+	if len(fields) < count {
+		return nil, fmt.Sprintf("want %d values, got %d", count, len(fields)), nil
+	}
+	return fields, "", nil
+}
+
+// restField returns the index of the first STRING variable READLN reads,
+// it reads the rest of the line, len(targets) if there's none.
+func restField(targets []ASTNode) int {
+	for i, target := range targets {
+		if isType(typeOf(target), String) {
+			return i
+		}
+	}
+	return len(targets)
+}
+
+// bind evaluates actual parameters in the caller's record and stores them
+// under the names of the formal parameters in the callee's record, a VAR
+// parameter is bound to the caller's variable, element or field instead.
+func (it *Interpreter) bind(ar *ActivationRecord, formalParams []*VarSymbol, actualParams []ASTNode) (err error) {
+	if len(formalParams) != len(actualParams) {
+		return it.internal("%d actual parameters for %d formal parameters", len(actualParams), len(formalParams))
+	}
+	for i := 0; i < len(formalParams); i++ {
+		if formalParams[i].byReference {
+			var ref *Reference
+			if ref, err = it.reference(actualParams[i]); err != nil {
+				return
+			}
+			ar.Bind(formalParams[i].GetName(), ref)
+			continue
+		}
+		var value interface{}
+		if value, err = it.expr(actualParams[i]); err != nil {
+			return
+		}
+		ar.Set(formalParams[i].GetName(), coerce(formalParams[i].typ, copyValue(value)))
+	}
+	return
+}
+
+// push pushes the record of a call made at token, unless the call stack is
+// full. Its static link is the record of the routine the callee is declared
+// in, found from the caller's record.
+func (it *Interpreter) push(ar *ActivationRecord, token *Token) error {
+	if it.callStack.Len() >= it.limits.CallDepth {
+		return it.error(ErrorCodeStackOverflow, token, fmt.Sprintf("more than %d nested calls", it.limits.CallDepth))
+	}
+	ar.StaticLink = it.callStack.Peek().Enclosing(ar.NestingLevel - 1)
+	it.callStack.Push(ar)
+	return nil
+}
+
+// step counts the statement at token before it runs, the run stops there
+// if its context is done or a limit is exceeded.
+func (it *Interpreter) step(token *Token) error {
+	select {
+	case <-it.ctx.Done():
+		return it.error(ErrorCodeCanceled, token, it.ctx.Err().Error())
+	default:
+	}
+	if it.steps++; it.limits.Steps > 0 && it.steps > it.limits.Steps {
+		return it.error(ErrorCodeStepLimitExceeded, token, fmt.Sprintf("more than %d steps", it.limits.Steps))
+	}
+	// NOTE: counting the members walks the call stack, so only when needed.
+	if it.limits.Memory > 0 && it.callStack.Members() > it.limits.Memory {
+		return it.error(ErrorCodeMemoryLimitExceeded, token, fmt.Sprintf("more than %d variables", it.limits.Memory))
+	}
+	return nil
+}
+
+// record returns the activation record holding a variable, the current one
+// or one of the enclosing routines' found through static links.
+func (it *Interpreter) record(n *VarNode) *ActivationRecord {
+	return it.callStack.Peek().Enclosing(n.level)
+}
+
+// trace describes the records on the call stack, innermost first.
+func (it *Interpreter) trace() []string {
+	trace := make([]string, 0, it.callStack.Len())
+	for i := it.callStack.Len() - 1; i >= 0; i-- {
+		ar := it.callStack.records[i]
+		trace = append(trace, fmt.Sprintf("%d: %s %s", ar.NestingLevel, ar.Kind, ar.Name))
+	}
+	return trace
+}
+
+func (it *Interpreter) error(code ErrorCode, token *Token, message string) error {
+	return Error{
+		Code:       code,
+		Module:     ModuleInterpreter,
+		Message:    fmt.Sprintf("%s,token:%s", message, token),
+		Span:       token.Span(),
+		Suggestion: runtimeSuggestion(code),
+		Trace:      it.trace(),
+	}
+}
+
+// internal reports a bug of the interpreter, the checked program can't
+// cause it.
+func (it *Interpreter) internal(format string, args ...interface{}) error {
+	return Error{
+		Code:       ErrorCodeInternal,
+		Module:     ModuleInterpreter,
+		Message:    fmt.Sprintf(format, args...),
+		Suggestion: runtimeSuggestion(ErrorCodeInternal),
+		Trace:      it.trace(),
+	}
+}
+
+// runtimeSuggestion hints at how to avoid a runtime error, for both the
+// Interpreter and the VM.
+func runtimeSuggestion(code ErrorCode) string {
+	switch code {
+	case ErrorCodeInvalidInput:
+		return "type one value per variable on a single line, separated by spaces"
+	case ErrorCodeDivisionByZero:
+		return "check the divisor before dividing"
+	case ErrorCodeUninitializedVariable:
+		return "assign a value before reading it"
+	case ErrorCodeStackOverflow:
+		return "check that the recursion reaches its base case"
+	case ErrorCodeIndexOutOfRange:
+		return "check the index against the bounds of the array"
+	case ErrorCodeInvalidArgument:
+		return "check the argument against what the builtin function accepts"
+	case ErrorCodeCanceled:
+		return "check that every loop ends, the run was stopped before the program did"
+	case ErrorCodeStepLimitExceeded:
+		return "check that every loop ends, or raise the limit on steps"
+	case ErrorCodeMemoryLimitExceeded:
+		return "use fewer variables in recursive routines, or raise the limit on memory"
+	case ErrorCodeInternal:
+		return "the program is fine but hit a bug of the interpreter, report it along with the program"
+	}
+	return ""
+}
@@ -0,0 +1,773 @@
+package pascal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// interpretTests are shared by the backends, they must behave the same.
+var interpretTests = map[string]struct {
+	givenProgram string
+	givenInput   string
+	wantOutput   string
+}{
+	"noop": {
+		givenProgram: `
+			PROGRAM p;
+			BEGIN
+			END.
+		`,
+		wantOutput: "",
+	},
+	"default test": {
+		givenProgram: `
+			PROGRAM p;
+			VAR
+				number, a, b, c, x : INTEGER;
+			BEGIN
+				BEGIN
+					number := 2;
+					a := number;
+					b := 10 * a + 10 * number div 4;
+					c := a - - b
+				END;
+				x := 11;
+				WRITELN(a);
+				WRITELN(b);
+				WRITELN(c);
+				WRITELN(number);
+				WRITELN(x)
+			END.
+		`,
+		wantOutput: "2\n25\n27\n2\n11\n",
+	},
+	"case insensitivity": {
+		givenProgram: `
+			PROGRAM p;
+			VAR
+				number, a, b, c, x : INTEGER;
+			BEGIN
+				BEGIN
+					number := 2;
+					a := NumBer;
+					B := 10 * a + 10 * NUMBER Div 4;
+					c := a - - b
+				end;
+				x := 11;
+				writeln(A, B, c, Number, X);
+			END.
+		`,
+		wantOutput: "22527211\n",
+	},
+	"example from part10": {
+		givenProgram: `
+			PROGRAM Part10AST;
+			VAR
+				a, b : INTEGER;
+				y 	 : REAL;
+
+			BEGIN {Part10AST}
+				a := 2;
+				b := 10 * a + 10 * a DIV 4;
+				y := 20 / 7 + 3.14;
+				Write(a);
+				Write(b);
+				WriteLn;
+				WriteLn(y)
+			END. {Part10AST}
+		`,
+		wantOutput: "225\n5.997142857142857\n",
+	},
+	"booleans": {
+		givenProgram: `
+			program Main;
+			begin
+				writeln(1 < 2, 1 > 2)
+			end.
+		`,
+		wantOutput: "TRUEFALSE\n",
+	},
+	"while": {
+		givenProgram: `
+			program Main;
+				var i, sum : integer;
+			begin
+				i := 0; sum := 0;
+				while i < 5 do
+				begin
+					i := i + 1;
+					sum := sum + i
+				end;
+				writeln(i);
+				writeln(sum)
+			end.
+		`,
+		wantOutput: "5\n15\n",
+	},
+	"repeat runs at least once": {
+		givenProgram: `
+			program Main;
+				var i : integer;
+			begin
+				i := 10;
+				repeat i := i + 1 until true;
+				writeln(i)
+			end.
+		`,
+		wantOutput: "11\n",
+	},
+	"for to and downto": {
+		givenProgram: `
+			program Main;
+				var i, j, up, down : integer;
+			begin
+				up := 0; down := 0;
+				for i := 1 to 3 do
+					for j := i downto 1 do
+						up := up + 1;
+				for i := 1 downto 3 do
+					down := down + 1;
+				writeln(up);
+				writeln(down)
+			end.
+		`,
+		wantOutput: "6\n0\n",
+	},
+	"recursive functions": {
+		givenProgram: `
+			program Main;
+				function Fact(n : integer): integer;
+				begin
+					if n <= 1 then Fact := 1 else Fact := n * Fact(n - 1)
+				end;
+				function Fib(n : integer): integer;
+				begin
+					if n < 2 then
+						Fib := n
+					else
+						Fib := Fib(n - 1) + Fib(n - 2)
+				end;
+			begin
+				writeln(Fact(10));
+				writeln(Fib(15))
+			end.
+		`,
+		wantOutput: "3628800\n610\n",
+	},
+	"integers and reals": {
+		givenProgram: `
+			program Main;
+				var i : integer; r : real;
+			begin
+				i := 7 div 2;
+				r := i;
+				writeln(i);
+				writeln(r / 2);
+				writeln(r * 2);
+				writeln(9007199254740993)
+			end.
+		`,
+		wantOutput: "3\n1.5\n6\n9007199254740993\n",
+	},
+	"var parameters": {
+		givenProgram: `
+			program Main;
+				var a, b : integer;
+				procedure Swap(var x, y : integer);
+					var t : integer;
+				begin
+					t := x; x := y; y := t
+				end;
+				procedure SwapTwice(var x : integer; var y : integer);
+				begin
+					Swap(x, y); Swap(y, x)
+				end;
+				procedure Read(var n : integer);
+				begin
+					readln(n)
+				end;
+				function Next(var n : integer): integer;
+				begin
+					n := n + 1; Next := n
+				end;
+			begin
+				a := 1; b := 2;
+				Swap(a, b);
+				writeln(a, b);
+				SwapTwice(a, b);
+				writeln(a, b);
+				Read(a);
+				writeln(Next(a) + a)
+			end.
+		`,
+		givenInput: "5",
+		wantOutput: "21\n21\n12\n",
+	},
+	"var parameters of elements and fields": {
+		givenProgram: `
+			program Main;
+				type Point = record x, y : integer end;
+				var a : array[1..3] of integer;
+				    p : Point;
+				    ps : array[1..2] of Point;
+				    i : integer;
+				procedure Swap(var x, y : integer);
+					var t : integer;
+				begin
+					t := x; x := y; y := t
+				end;
+				procedure Bump(var n : integer);
+				begin
+					i := i + 1; n := n + 1
+				end;
+				procedure Move(var q : Point);
+				begin
+					Bump(q.x); q.y := q.y * 2
+				end;
+			begin
+				a[1] := 1; a[2] := 2; a[3] := 3;
+				Swap(a[1], a[3]);
+				writeln(a[1], a[2], a[3]);
+				i := 2;
+				Bump(a[i]);
+				writeln(a[2], i);
+				p.x := 1; p.y := 2;
+				Swap(p.x, p.y);
+				writeln(p.x, p.y);
+				ps[2] := p;
+				Move(ps[2]);
+				Bump(ps[2].y);
+				writeln(ps[2].x, ps[2].y, p.x)
+			end.
+		`,
+		wantOutput: "321\n33\n21\n332\n",
+	},
+	"nested scopes": {
+		givenProgram: `
+			program Main;
+				var b, x, y : real;
+				var z : integer;
+				procedure AlphaA(a : integer);
+					var b : integer;
+					procedure Beta(c : integer);
+						var y : integer;
+						procedure Gamma(c : integer);
+							var x : integer;
+						begin { Gamma }
+							x := 1;
+							x := a + b + c + x + y + z;
+							writeln(x)
+						end;  { Gamma }
+					begin { Beta }
+						y := 10;
+						Gamma(c * 2)
+					end;  { Beta }
+				begin { AlphaA }
+					b := 100;
+					Beta(a + 1)
+				end;  { AlphaA }
+				procedure AlphaB(a : integer);
+					var c : real;
+				begin { AlphaB }
+					c := a + b;
+					writeln(c)
+				end;  { AlphaB }
+			begin { Main }
+				b := 0.5;
+				z := 1000;
+				AlphaA(1);
+				AlphaB(2)
+			end.  { Main }
+		`,
+		wantOutput: "1116\n2.5\n",
+	},
+	"static scoping": {
+		givenProgram: `
+			program Main;
+				var x, calls : integer;
+				procedure Show;
+				begin
+					calls := calls + 1;
+					writeln(x)
+				end;
+				procedure Shadow;
+					var x : integer;
+				begin
+					x := 2;
+					Show
+				end;
+				function Sum(n : integer): integer;
+					procedure Add;
+					begin
+						calls := calls + 1;
+						Sum := n + Sum(n - 1)
+					end;
+				begin
+					if n = 0 then Sum := 0 else Add
+				end;
+			begin
+				x := 1;
+				calls := 0;
+				Shadow;
+				Show;
+				writeln(Sum(4));
+				writeln(calls)
+			end.
+		`,
+		wantOutput: "1\n1\n10\n6\n",
+	},
+	"arrays": {
+		givenProgram: `
+			program Main;
+				type
+					Vec = array[1..5] of integer;
+				var
+					v, w : Vec;
+					i : integer;
+					grid : array[-1..0] of array[0..1] of boolean;
+				procedure Sort(var a : Vec);
+					var i, j, t : integer;
+				begin
+					for i := 1 to 4 do
+						for j := 1 to 5 - i do
+							if a[j] > a[j + 1] then
+							begin
+								t := a[j]; a[j] := a[j + 1]; a[j + 1] := t
+							end
+				end;
+				function Sum(a : Vec): integer;
+					var i, s : integer;
+				begin
+					s := 0;
+					a[1] := 100;
+					for i := 1 to 5 do s := s + a[i];
+					Sum := s
+				end;
+			begin
+				for i := 1 to 5 do v[i] := 6 - i;
+				w := v;
+				Sort(v);
+				for i := 1 to 5 do write(v[i]);
+				writeln;
+				writeln(w[1]);
+				writeln(Sum(v));
+				writeln(v[1]);
+				grid[-1][1] := true;
+				writeln(grid[-1][1]);
+				readln(v[2]);
+				writeln(v[2])
+			end.
+		`,
+		givenInput: "42",
+		wantOutput: "12345\n5\n114\n1\nTRUE\n42\n",
+	},
+	"records": {
+		givenProgram: `
+			program Main;
+				type
+					Point = record x, y : real end;
+					Segment = record
+						ends : array[1..2] of Point;
+						visible : boolean;
+					end;
+				var
+					p : Point;
+					s : Segment;
+				function Origin: Point;
+					var o : Point;
+				begin
+					o.x := 0; o.y := 0;
+					Origin := o
+				end;
+			begin
+				p := Origin();
+				p.x := 1.5;
+				s.ends[1] := p;
+				s.ends[2] := p;
+				s.ends[2].y := 2;
+				p.x := 3;
+				writeln(s.ends[1].x);
+				writeln(s.ends[2].y + s.ends[2].x)
+			end.
+		`,
+		wantOutput: "1.5\n3.5\n",
+	},
+	"strings": {
+		givenProgram: `
+			program Main;
+				var s : string;
+				    c : char;
+				    i : integer;
+				function Reverse(s : string): string;
+					var i : integer; r : string;
+				begin
+					r := '';
+					for i := length(s) downto 1 do r := r + copy(s, i, 1);
+					Reverse := r
+				end;
+			begin
+				s := 'Hello';
+				c := '!';
+				s := s + ', it''s me' + c;
+				writeln(s);
+				writeln(length(s), ' ', pos('it', s), ' ', pos('?', s));
+				writeln(copy(s, 8, 4), '|', copy(s, 14, 10), '|');
+				writeln(ord('A'), chr(ord(c) + 1));
+				writeln('abc' < 'abd', ' ', 'b' > 'abc', ' ', c = '!');
+				writeln(Reverse('stressed'));
+				readln(c, i, s);
+				writeln(c, s, i)
+			end.
+		`,
+		givenInput: "? 7 two words",
+		wantOutput: "Hello, it's me!\n15 8 0\nit's|e!|\n65\"\nTRUE TRUE TRUE\ndesserts\n?two words7\n",
+	},
+	"strings of multi-byte characters": {
+		givenProgram: `
+			program Main;
+				var s : string; c : char;
+			begin
+				s := 'héllo wörld';
+				c := 'é';
+				writeln(length(s), ' ', copy(s, 2, 4), ' ', pos('w', s), ' ', ord(c), ' ', chr(ord(c) + 1), succ(c));
+				readln(s);
+				writeln(s, '|', length(s))
+			end.
+		`,
+		givenInput: "  ça va  bien \n",
+		wantOutput: "11 éllo 7 233 êê\nça va  bien |12\n",
+	},
+	"readln parses by variable type": {
+		givenProgram: `
+			program Main;
+				var i : integer; r : real;
+			begin
+				readln(i, r);
+				writeln(i div 2);
+				writeln(r)
+			end.
+		`,
+		givenInput: "7 2.5",
+		wantOutput: "3\n2.5\n",
+	},
+	"readln": {
+		givenProgram: `
+			program Main;
+				var a, b, c : integer;
+			begin
+				readln(a, b);
+				readln;
+				readln(c);
+				writeln(a + b + c)
+			end.
+		`,
+		givenInput: "1 2\nskipped\n3",
+		wantOutput: "6\n",
+	},
+	"constants": {
+		givenProgram: `
+			program Main;
+				const Size = 3; Last = Size * 2 - 1; Greeting = 'hi'; Half = 0.5;
+				var a : array[0..Last] of integer; i : integer;
+			begin
+				for i := 0 to Last do a[i] := i * Size;
+				writeln(Greeting, ' ', a[Last], ' ', Last + Half, ' ', -Size)
+			end.
+		`,
+		wantOutput: "hi 15 5.5 -3\n",
+	},
+	"enumerated types": {
+		givenProgram: `
+			program Main;
+				type Color = (Red, Green, Blue);
+				var c : Color; counts : array[0..2] of integer; i : integer;
+				function Next(c : Color): Color;
+				begin
+					if c = Blue then Next := Red else Next := succ(c)
+				end;
+			begin
+				c := Red;
+				for i := 0 to 2 do counts[i] := 0;
+				for i := 1 to 7 do
+				begin
+					counts[ord(c)] := counts[ord(c)] + 1;
+					c := Next(c)
+				end;
+				writeln(c, ' ', pred(c), ' ', counts[0], counts[1], counts[2]);
+				writeln(Red < Blue, ' ', c = Green, ' ', ord(Blue))
+			end.
+		`,
+		wantOutput: "GREEN RED 322\nTRUE TRUE 2\n",
+	},
+	"ordinal functions": {
+		givenProgram: `
+			program Main;
+			begin
+				writeln(succ('a'), pred(10), ' ', succ(false), ' ', ord(true), ' ', ord(7))
+			end.
+		`,
+		wantOutput: "b9 TRUE 1 7\n",
+	},
+}
+
+var runtimeErrorTests = map[string]struct {
+	givenProgram string
+	wantOutput   string
+	wantError    string
+	wantTrace    []string
+}{
+	"index out of range": {
+		givenProgram: `
+			program Main;
+				var a : array[1..3] of integer;
+				    i : integer;
+			begin
+				for i := 3 downto 0 do a[i] := i
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=IndexOutOfRange,message="index 0 out of range 1..3,token:(kind=[,value=[,pos=(6,29))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"var parameter of an element out of range": {
+		givenProgram: `
+			program Main;
+				var a : array[1..3] of integer;
+				    i : integer;
+				procedure Clear(var n : integer);
+				begin
+					n := 0
+				end;
+			begin
+				i := 4;
+				Clear(a[i])
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=IndexOutOfRange,message="index 4 out of range 1..3,token:(kind=[,value=[,pos=(11,12))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"write of an argument in error": {
+		givenProgram: `
+			program Main;
+				var a : array[1..2] of integer;
+				    i : integer;
+			begin
+				a[1] := 1;
+				write('start ');
+				i := 3;
+				writeln(a[1], ' ', a[i])
+			end.
+		`,
+		wantOutput: "start ",
+		wantError:  `<Error: module=Interpreter,code=IndexOutOfRange,message="index 3 out of range 1..2,token:(kind=[,value=[,pos=(9,25))">`,
+		wantTrace:  []string{"1: PROGRAM MAIN"},
+	},
+	"character code out of range": {
+		givenProgram: `
+			program Main;
+				var i : integer;
+			begin
+				i := 1114111;
+				writeln(chr(i + 1))
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=InvalidArgument,message="no character has code 1114112,token:(kind=ID,value=CHR,pos=(6,13))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"no successor": {
+		givenProgram: `
+			program Main;
+				type Color = (Red, Green, Blue);
+				var c : Color;
+			begin
+				c := Blue;
+				c := succ(c)
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=InvalidArgument,message="BLUE has no successor,token:(kind=ID,value=SUCC,pos=(7,10))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"uninitialized element": {
+		givenProgram: `
+			program Main;
+				var r : record a : array[1..3] of integer end;
+			begin
+				r.a[1] := 1;
+				writeln(r.a[1] + r.a[2])
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=UninitializedVariable,message="element is read before it is assigned,token:(kind=[,value=[,pos=(6,25))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"integer division by zero": {
+		givenProgram: `
+			program Main;
+				var a : integer;
+				function Half(n : integer): integer;
+				begin
+					Half := 2 div n
+				end;
+			begin
+				a := Half(0)
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=DivisionByZero,message="division by zero,token:(kind=DIV,value=DIV,pos=(6,16))">`,
+		wantTrace: []string{"2: FUNCTION HALF", "1: PROGRAM MAIN"},
+	},
+	"real division by zero": {
+		givenProgram: `
+			program Main;
+				var r : real;
+			begin
+				r := 0.0;
+				r := 1 / r
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=DivisionByZero,message="division by zero,token:(kind=/,value=/,pos=(6,12))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"uninitialized variable": {
+		givenProgram: `
+			program Main;
+				var a, b : integer;
+			begin
+				a := b + 1
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=UninitializedVariable,message="variable is read before it is assigned,token:(kind=ID,value=B,pos=(5,10))">`,
+		wantTrace: []string{"1: PROGRAM MAIN"},
+	},
+	"function result not set": {
+		givenProgram: `
+			program Main;
+				var a : integer;
+				function F: integer;
+				begin
+				end;
+			begin
+				a := F()
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=UninitializedVariable,message="function result is not set,token:(kind=ID,value=F,pos=(8,10))">`,
+		wantTrace: []string{"2: FUNCTION F", "1: PROGRAM MAIN"},
+	},
+	"stack overflow": {
+		givenProgram: `
+			program Main;
+				procedure Forever;
+				begin
+					Forever
+				end;
+			begin
+				Forever
+			end.
+		`,
+		wantError: `<Error: module=Interpreter,code=StackOverflow,message="more than 4096 nested calls,token:(kind=ID,value=FOREVER,pos=(5,6))">`,
+	},
+}
+
+func TestInterpreter_Interpret(t *testing.T) {
+	for name, tc := range interpretTests {
+		t.Run(name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			it := NewInterpreter(strings.NewReader(tc.givenInput), output)
+			assert.NoError(t, it.Interpret(tc.givenProgram))
+			assert.Equal(t, tc.wantOutput, output.String())
+		})
+	}
+
+	t.Run("runtime errors", func(t *testing.T) {
+		for name, tc := range runtimeErrorTests {
+			t.Run(name, func(t *testing.T) {
+				output := bytes.NewBuffer(nil)
+				err := NewInterpreter(nil, output).Interpret(tc.givenProgram)
+				assert.Equal(t, tc.wantError, err.Error())
+				assert.Equal(t, tc.wantOutput, output.String())
+				if tc.wantTrace != nil {
+					assert.Equal(t, tc.wantTrace, err.(Error).Trace)
+				} else {
+					assert.Len(t, err.(Error).Trace, maxCallDepth)
+				}
+			})
+		}
+	})
+
+	t.Run("readln at end of input", func(t *testing.T) {
+		it := NewInterpreter(strings.NewReader(""), ioutil.Discard)
+		err := it.Interpret(`
+			program Main;
+				var a : integer;
+			begin
+				readln(a)
+			end.
+		`)
+		assert.Equal(t, `<Error: module=Interpreter,code=InvalidInput,message="unexpected end of input,token:(kind=ID,value=READLN,pos=(5,5))">`, err.Error())
+	})
+
+	t.Run("internal errors", func(t *testing.T) {
+		it := NewInterpreter(nil, ioutil.Discard)
+		ar := NewActivationRecord("MAIN", ARKindProgram, 1)
+		it.callStack.Push(ar)
+		_, err := it.expr(&NoopNode{})
+		assert.Equal(t, `<Error: module=Interpreter,code=Internal,message="unexpected expression *pascal.NoopNode">`, err.Error())
+		err = it.bind(ar, []*VarSymbol{NewVarSymbol("N", nil)}, nil)
+		assert.Equal(t, `<Error: module=Interpreter,code=Internal,message="0 actual parameters for 1 formal parameters">`, err.Error())
+		assert.Equal(t, []string{"1: PROGRAM MAIN"}, err.(Error).Trace)
+	})
+}
+
+func TestInterpreter_expr(t *testing.T) {
+	tests := map[string]struct {
+		givenExpr    string
+		givenMembers map[string]interface{}
+		wantValue    interface{}
+		wantError    string
+	}{
+		"arithmetic":           {givenExpr: "10 * a + 10 * a div 4", givenMembers: map[string]interface{}{"A": int64(2)}, wantValue: int64(25)},
+		"real arithmetic":      {givenExpr: "a / 4 + 0.5", givenMembers: map[string]interface{}{"A": int64(2)}, wantValue: 1.0},
+		"mixed arithmetic":     {givenExpr: "-a * 1.5", givenMembers: map[string]interface{}{"A": int64(2)}, wantValue: -3.0},
+		"relational":           {givenExpr: "a + 1 >= 3", givenMembers: map[string]interface{}{"A": int64(2)}, wantValue: true},
+		"mixed relational":     {givenExpr: "a < 2.5", givenMembers: map[string]interface{}{"A": int64(2)}, wantValue: true},
+		"not equal":            {givenExpr: "a <> 2", givenMembers: map[string]interface{}{"A": int64(2)}, wantValue: false},
+		"boolean operators":    {givenExpr: "not ok and (1 < 2) or false", givenMembers: map[string]interface{}{"OK": false}, wantValue: true},
+		"boolean ordering":     {givenExpr: "false < true", wantValue: true},
+		"short circuit and":    {givenExpr: "false and undefined", wantValue: false},
+		"short circuit or":     {givenExpr: "true or undefined", wantValue: true},
+		"relational then bool": {givenExpr: "(1 = 1) = ok", givenMembers: map[string]interface{}{"OK": true}, wantValue: true},
+		"division by zero": {
+			givenExpr:    "a div (a - 2)",
+			givenMembers: map[string]interface{}{"A": int64(2)},
+			wantError:    `<Error: module=Interpreter,code=DivisionByZero,message="division by zero,token:(kind=DIV,value=DIV,pos=(1,3))">`,
+		},
+		"uninitialized variable": {
+			givenExpr: "b + 1",
+			wantError: `<Error: module=Interpreter,code=UninitializedVariable,message="variable is read before it is assigned,token:(kind=ID,value=B,pos=(1,1))">`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			parser, err := NewParser(NewLexer(tc.givenExpr))
+			assert.NoError(t, err)
+			node, err := parser.expr()
+			assert.NoError(t, err)
+
+			it := NewInterpreter(nil, nil)
+			ar := NewActivationRecord("MAIN", ARKindProgram, 1)
+			for k, v := range tc.givenMembers {
+				ar.Set(k, v)
+			}
+			it.callStack.Push(ar)
+			value, err := it.expr(node)
+			if tc.wantError != "" {
+				assert.EqualError(t, err, tc.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantValue, value)
+		})
+	}
+}
@@ -0,0 +1,494 @@
+package pascal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+func PeekNextToken(lex *Lexer) (token *Token, err error) {
+	lexCopy := *lex
+	return (&lexCopy).GetNextToken()
+}
+
+func NewLexer(input string) *Lexer {
+	// NOTE: an empty input has no current rune, it's at its end already.
+	if len(input) == 0 {
+		return &Lexer{text: input, Row: 1, Col: 1}
+	}
+
+	firstRune := rune(input[0])
+
+	return &Lexer{
+		text:     input,
+		pos:      0,
+		currRune: &firstRune,
+		Row:      1,
+		Col:      1,
+	}
+}
+
+type Lexer struct {
+	text     string
+	pos      int
+	currRune *rune
+	Row      int
+	Col      int
+	// comments are read since the last token, they're attached to the next one.
+	comments []*Comment
+}
+
+// GetNextToken returns the next token, along with the comments before it.
+func (lex *Lexer) GetNextToken() (token *Token, err error) {
+	token, err = lex.nextToken()
+	if token != nil && len(lex.comments) > 0 {
+		token.Comments, lex.comments = lex.comments, nil
+	}
+	return
+}
+
+func (lex *Lexer) nextToken() (token *Token, err error) {
+	for lex.currRune != nil {
+		// spaces
+		if unicode.IsSpace(*lex.currRune) {
+			lex.skipWhiteSpaces()
+			continue
+		}
+		// comments
+		if *lex.currRune == '{' {
+			if err = lex.comment(); err != nil {
+				return
+			}
+			continue
+		}
+		// numbers
+		if unicode.IsDigit(*lex.currRune) {
+			return lex.number(), nil
+		}
+		// id token
+		if *lex.currRune == '_' || isAlpha(*lex.currRune) {
+			return lex.id(), nil
+		}
+		// string literals
+		if *lex.currRune == '\'' {
+			return lex.string()
+		}
+		// double-character token
+		if nextRune := lex.peek(); nextRune != nil {
+			if kind, ok := TokenValues[string([]rune{*lex.currRune, *nextRune})]; ok {
+				lex.advance()
+				lex.advance()
+				return lex.staticToken(kind), nil
+			}
+		}
+		// single-character token
+		if kind, ok := TokenValues[string(*lex.currRune)]; ok {
+			lex.advance()
+			return lex.staticToken(kind), nil
+		}
+		err = lex.error(ErrorCodeUnknownRune, Span{Row: lex.Row, Col: lex.Col, Len: 1}, "")
+		// skip the rune, so that the caller may carry on with the next token.
+		lex.advance()
+		return
+	}
+	return NewStaticToken(EOF, lex.Row, lex.Col), nil
+}
+
+func (lex *Lexer) number() *Token {
+	sb := strings.Builder{}
+	for lex.currRune != nil && unicode.IsDigit(*lex.currRune) {
+		sb.WriteRune(*lex.currRune)
+		lex.advance()
+	}
+
+	// NOTE: in 1..10 the dots are a DOTDOT, not the fraction of a REAL.
+	if lex.currRune != nil && *lex.currRune == '.' && (lex.peek() == nil || *lex.peek() != '.') {
+		sb.WriteRune(*lex.currRune)
+		lex.advance()
+
+		for lex.currRune != nil && unicode.IsDigit(*lex.currRune) {
+			sb.WriteRune(*lex.currRune)
+			lex.advance()
+		}
+		return lex.dynamicToken(RealConst, sb.String())
+	}
+	return lex.dynamicToken(IntegerConst, sb.String())
+}
+
+func (lex *Lexer) id() *Token {
+	sb := strings.Builder{}
+	for lex.currRune != nil &&
+		(*lex.currRune == '_' ||
+			isAlpha(*lex.currRune) ||
+			unicode.IsDigit(*lex.currRune)) {
+		sb.WriteRune(*lex.currRune)
+		lex.advance()
+	}
+
+	idName := normalizeKeyword(sb.String())
+
+	if IsReservedKeyword(idName) {
+		return lex.staticToken(TokenValues[idName])
+	}
+	return lex.dynamicToken(ID, idName)
+}
+
+// string reads a quoted literal, a quote inside it is written twice.
+// The value of the token is the literal as written, quotes included.
+// NOTE: the lexer reads bytes, they're kept so that UTF-8 text is too.
+func (lex *Lexer) string() (token *Token, err error) {
+	start := Span{Row: lex.Row, Col: lex.Col, Len: 1}
+	sb := strings.Builder{}
+	sb.WriteByte(byte(*lex.currRune))
+	lex.advance()
+	for {
+		// NOTE: a literal can't span lines.
+		if lex.currRune == nil || *lex.currRune == '\n' {
+			err = lex.error(ErrorCodeUnclosedString, start, "close the string with ' on the same line")
+			return
+		}
+		quote := *lex.currRune == '\''
+		sb.WriteByte(byte(*lex.currRune))
+		lex.advance()
+		if quote {
+			if lex.currRune == nil || *lex.currRune != '\'' {
+				return lex.dynamicToken(StringConst, sb.String()), nil
+			}
+			sb.WriteByte(byte(*lex.currRune))
+			lex.advance()
+		}
+	}
+}
+
+func (lex *Lexer) skipWhiteSpaces() {
+	for lex.currRune != nil && unicode.IsSpace(*lex.currRune) {
+		lex.advance()
+	}
+}
+
+// comment reads a comment in curly braces, it's kept until the next token.
+func (lex *Lexer) comment() (err error) {
+	start := Span{Row: lex.Row, Col: lex.Col, Len: 1}
+	begin := lex.pos
+	for lex.currRune == nil || *lex.currRune != '}' {
+		if lex.currRune == nil {
+			err = lex.error(ErrorCodeUnclosedComment, start, "close the comment with }")
+			return
+		}
+		lex.advance()
+	}
+	lex.advance()
+	lex.comments = append(lex.comments, &Comment{Text: lex.text[begin:lex.pos], Row: start.Row, Col: start.Col})
+	return
+}
+
+func (lex *Lexer) advance() {
+	if *lex.currRune == '\n' {
+		lex.Row += 1
+		lex.Col = 0
+	}
+
+	lex.pos += 1
+	lex.Col += 1
+	if lex.pos >= len(lex.text) {
+		lex.currRune = nil
+		return
+	}
+
+	nextRune := rune(lex.text[lex.pos])
+	lex.currRune = &nextRune
+}
+
+func (lex *Lexer) peek() *rune {
+	peekPos := lex.pos + 1
+	if peekPos >= len(lex.text) {
+		return nil
+	}
+
+	nextRune := rune(lex.text[peekPos])
+	return &nextRune
+}
+
+func (lex *Lexer) error(code ErrorCode, span Span, suggestion string) error {
+	var lexeme string
+	// NOTE: an unclosed string stops at the end of its line, not shown.
+	if lex.currRune != nil && *lex.currRune != '\n' {
+		lexeme = string(*lex.currRune)
+	}
+	return Error{
+		Code:   code,
+		Module: ModuleLexer,
+		Message: fmt.Sprintf("lexeme=%s,pos=(%d,%d)",
+			lexeme, lex.Row, lex.Col),
+		Span:       span,
+		Suggestion: suggestion,
+	}
+}
+
+func (lex *Lexer) staticToken(kind TokenKind) *Token {
+	return NewStaticToken(kind, lex.Row, lex.Col-len(kind.String()))
+}
+
+func (lex *Lexer) dynamicToken(kind TokenKind, value string) *Token {
+	return NewDynamicToken(kind, value, lex.Row, lex.Col-len(value))
+}
+
+func normalizeKeyword(keyword string) string {
+	return strings.ToUpper(keyword)
+}
+
+func NewStaticToken(kind TokenKind, row, col int) *Token {
+	return &Token{Kind: kind, Value: TokenNames[kind], Row: row, Col: col}
+}
+
+func NewDynamicToken(kind TokenKind, value string, row, col int) *Token {
+	return &Token{Kind: kind, Value: value, Row: row, Col: col}
+}
+
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Row   int
+	Col   int
+	// Comments are the comments between the previous token and this one.
+	Comments []*Comment
+}
+
+// Comment is a comment in curly braces, Text includes them.
+type Comment struct {
+	Text string
+	Row  int
+	Col  int
+}
+
+// Span returns the part of the source the token was read from.
+func (t Token) Span() Span {
+	if t.Kind == EOF {
+		return Span{Row: t.Row, Col: t.Col, Len: 1}
+	}
+	return Span{Row: t.Row, Col: t.Col, Len: len(t.Value)}
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("(kind=%s,value=%s,pos=(%d,%d))",
+		t.Kind.String(), t.Value, t.Row, t.Col)
+}
+
+type TokenKind int32
+
+func (k TokenKind) String() string {
+	if name, ok := TokenNames[k]; ok {
+		return name
+	}
+	return "unknown keyword"
+}
+
+const (
+	// single-character token kinds.
+	Plus     TokenKind = 1
+	Minus    TokenKind = 2
+	Mul      TokenKind = 3
+	FloatDiv TokenKind = 4
+	LParen   TokenKind = 5
+	RParen   TokenKind = 6
+	Semi     TokenKind = 7
+	Dot      TokenKind = 8
+	Colon    TokenKind = 9
+	Comma    TokenKind = 10
+	Equal    TokenKind = 11
+	Less     TokenKind = 12
+	Greater  TokenKind = 13
+	LBracket TokenKind = 14
+	RBracket TokenKind = 15
+	// reserved keywords.
+	Program        TokenKind = 1000
+	Integer        TokenKind = 1001
+	Real           TokenKind = 1002
+	IntegerDiv     TokenKind = 1003
+	Var            TokenKind = 1004
+	Procedure      TokenKind = 1005
+	Begin          TokenKind = 1006
+	End            TokenKind = 1007
+	Boolean        TokenKind = 1008
+	True           TokenKind = 1009
+	False          TokenKind = 1010
+	And            TokenKind = 1011
+	Or             TokenKind = 1012
+	Not            TokenKind = 1013
+	If             TokenKind = 1014
+	Then           TokenKind = 1015
+	Else           TokenKind = 1016
+	While          TokenKind = 1017
+	Do             TokenKind = 1018
+	Repeat         TokenKind = 1019
+	Until          TokenKind = 1020
+	For            TokenKind = 1021
+	To             TokenKind = 1022
+	Downto         TokenKind = 1023
+	Function       TokenKind = 1024
+	Type           TokenKind = 1025
+	Array          TokenKind = 1026
+	Of             TokenKind = 1027
+	Record         TokenKind = 1028
+	String         TokenKind = 1029
+	Char           TokenKind = 1030
+	Const          TokenKind = 1031
+	Unit           TokenKind = 1032
+	Interface      TokenKind = 1033
+	Implementation TokenKind = 1034
+	Uses           TokenKind = 1035
+	// misc.
+	ID           TokenKind = 2001
+	IntegerConst TokenKind = 2002
+	RealConst    TokenKind = 2003
+	Assign       TokenKind = 2004
+	EOF          TokenKind = 2005
+	NotEqual     TokenKind = 2006
+	LessEqual    TokenKind = 2007
+	GreaterEqual TokenKind = 2008
+	DotDot       TokenKind = 2009
+	StringConst  TokenKind = 2010
+)
+
+var TokenNames = map[TokenKind]string{
+	Plus:           "+",
+	Minus:          "-",
+	Mul:            "*",
+	FloatDiv:       "/",
+	LParen:         "(",
+	RParen:         ")",
+	Semi:           ";",
+	Dot:            ".",
+	Colon:          ":",
+	Comma:          ",",
+	Equal:          "=",
+	Less:           "<",
+	Greater:        ">",
+	LBracket:       "[",
+	RBracket:       "]",
+	Program:        "PROGRAM",
+	Integer:        "INTEGER",
+	Real:           "REAL",
+	IntegerDiv:     "DIV",
+	Var:            "VAR",
+	Procedure:      "PROCEDURE",
+	Begin:          "BEGIN",
+	End:            "END",
+	Boolean:        "BOOLEAN",
+	True:           "TRUE",
+	False:          "FALSE",
+	And:            "AND",
+	Or:             "OR",
+	Not:            "NOT",
+	If:             "IF",
+	Then:           "THEN",
+	Else:           "ELSE",
+	While:          "WHILE",
+	Do:             "DO",
+	Repeat:         "REPEAT",
+	Until:          "UNTIL",
+	For:            "FOR",
+	To:             "TO",
+	Downto:         "DOWNTO",
+	Function:       "FUNCTION",
+	Type:           "TYPE",
+	Array:          "ARRAY",
+	Of:             "OF",
+	Record:         "RECORD",
+	String:         "STRING",
+	Char:           "CHAR",
+	Const:          "CONST",
+	Unit:           "UNIT",
+	Interface:      "INTERFACE",
+	Implementation: "IMPLEMENTATION",
+	Uses:           "USES",
+	ID:             "ID",
+	IntegerConst:   "INTEGER_CONST",
+	RealConst:      "REAL_CONST",
+	Assign:         ":=",
+	EOF:            "EOF",
+	NotEqual:       "<>",
+	LessEqual:      "<=",
+	GreaterEqual:   ">=",
+	DotDot:         "..",
+	StringConst:    "STRING_CONST",
+}
+
+var TokenValues = map[string]TokenKind{
+	"+":              Plus,
+	"-":              Minus,
+	"*":              Mul,
+	"/":              FloatDiv,
+	"(":              LParen,
+	")":              RParen,
+	";":              Semi,
+	".":              Dot,
+	":":              Colon,
+	",":              Comma,
+	"=":              Equal,
+	"<":              Less,
+	">":              Greater,
+	"[":              LBracket,
+	"]":              RBracket,
+	"PROGRAM":        Program,
+	"INTEGER":        Integer,
+	"REAL":           Real,
+	"DIV":            IntegerDiv,
+	"VAR":            Var,
+	"PROCEDURE":      Procedure,
+	"BEGIN":          Begin,
+	"END":            End,
+	"BOOLEAN":        Boolean,
+	"TRUE":           True,
+	"FALSE":          False,
+	"AND":            And,
+	"OR":             Or,
+	"NOT":            Not,
+	"IF":             If,
+	"THEN":           Then,
+	"ELSE":           Else,
+	"WHILE":          While,
+	"DO":             Do,
+	"REPEAT":         Repeat,
+	"UNTIL":          Until,
+	"FOR":            For,
+	"TO":             To,
+	"DOWNTO":         Downto,
+	"FUNCTION":       Function,
+	"TYPE":           Type,
+	"ARRAY":          Array,
+	"OF":             Of,
+	"RECORD":         Record,
+	"STRING":         String,
+	"CHAR":           Char,
+	"CONST":          Const,
+	"UNIT":           Unit,
+	"INTERFACE":      Interface,
+	"IMPLEMENTATION": Implementation,
+	"USES":           Uses,
+	"ID":             ID,
+	"INTEGER_CONST":  IntegerConst,
+	"REAL_CONST":     RealConst,
+	":=":             Assign,
+	"EOF":            EOF,
+	"<>":             NotEqual,
+	"<=":             LessEqual,
+	">=":             GreaterEqual,
+	"..":             DotDot,
+	"STRING_CONST":   StringConst,
+}
+
+func IsReservedKeyword(name string) bool {
+	tk := TokenValues[name]
+	return tk >= 1000 && tk < 2000
+}
+
+func isAlpha(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func init() {
+	if len(TokenNames) != len(TokenValues) {
+		panic("TokenNames and TokenValues don't match")
+	}
+}
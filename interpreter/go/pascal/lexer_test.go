@@ -1,4 +1,4 @@
-package main
+package pascal
 
 import (
 	"log"
@@ -12,6 +12,15 @@ func TestLexer_GetNextToken(t *testing.T) {
 		givenText  string
 		wantTokens []*Token
 	}{
+		"empty": {
+			givenText: "",
+		},
+		"multi-byte string": {
+			givenText: "'héllo'",
+			wantTokens: []*Token{
+				NewDynamicToken(StringConst, "'héllo'", 1, 1),
+			},
+		},
 		"simple": {
 			givenText: "BEGIN a := 2; END.",
 			wantTokens: []*Token{
@@ -115,7 +124,24 @@ func TestLexer_GetNextToken(t *testing.T) {
 				NewStaticToken(End, 4, 5), NewStaticToken(Dot, 4, 8),
 			},
 		},
-		"ignore comments": {
+		"relational and boolean operators": {
+			givenText: "IF (a <> 1) AND NOT (b >= 2) OR (c <= d) THEN x := a < b ELSE x := a > b = TRUE",
+			wantTokens: []*Token{
+				NewStaticToken(If, 1, 1), NewStaticToken(LParen, 1, 4), NewDynamicToken(ID, "A", 1, 5),
+				NewStaticToken(NotEqual, 1, 7), NewDynamicToken(IntegerConst, "1", 1, 10), NewStaticToken(RParen, 1, 11),
+				NewStaticToken(And, 1, 13), NewStaticToken(Not, 1, 17), NewStaticToken(LParen, 1, 21),
+				NewDynamicToken(ID, "B", 1, 22), NewStaticToken(GreaterEqual, 1, 24), NewDynamicToken(IntegerConst, "2", 1, 27),
+				NewStaticToken(RParen, 1, 28), NewStaticToken(Or, 1, 30), NewStaticToken(LParen, 1, 33),
+				NewDynamicToken(ID, "C", 1, 34), NewStaticToken(LessEqual, 1, 36), NewDynamicToken(ID, "D", 1, 39),
+				NewStaticToken(RParen, 1, 40), NewStaticToken(Then, 1, 42),
+				NewDynamicToken(ID, "X", 1, 47), NewStaticToken(Assign, 1, 49), NewDynamicToken(ID, "A", 1, 52),
+				NewStaticToken(Less, 1, 54), NewDynamicToken(ID, "B", 1, 56), NewStaticToken(Else, 1, 58),
+				NewDynamicToken(ID, "X", 1, 63), NewStaticToken(Assign, 1, 65), NewDynamicToken(ID, "A", 1, 68),
+				NewStaticToken(Greater, 1, 70), NewDynamicToken(ID, "B", 1, 72), NewStaticToken(Equal, 1, 74),
+				NewStaticToken(True, 1, 76),
+			},
+		},
+		"comments": {
 			givenText: `
 				BEGIN
 				{ writeln('a = ', a); }
@@ -128,7 +154,53 @@ func TestLexer_GetNextToken(t *testing.T) {
 			`,
 			wantTokens: []*Token{
 				NewStaticToken(Begin, 2, 5),
-				NewStaticToken(End, 9, 5), NewStaticToken(Semi, 9, 8),
+				withComments(NewStaticToken(End, 9, 5),
+					&Comment{Text: "{ writeln('a = ', a); }", Row: 3, Col: 5},
+					&Comment{Text: "{ writeln('b = ', b); }", Row: 4, Col: 5},
+					&Comment{Text: "{ writeln('c = ', c); }", Row: 5, Col: 5},
+					&Comment{Text: "{ writeln('number = ', number); }", Row: 6, Col: 5},
+					&Comment{Text: "{ writeln('x = ', x); }", Row: 7, Col: 5},
+					&Comment{Text: "{ writeln('y = ', y); }", Row: 8, Col: 5}),
+				NewStaticToken(Semi, 9, 8),
+			},
+		},
+		"arrays and records": {
+			givenText: "a: ARRAY[1..10] OF REAL; r.x := 2.5",
+			wantTokens: []*Token{
+				NewDynamicToken(ID, "A", 1, 1),
+				NewStaticToken(Colon, 1, 2),
+				NewStaticToken(Array, 1, 4),
+				NewStaticToken(LBracket, 1, 9),
+				NewDynamicToken(IntegerConst, "1", 1, 10),
+				NewStaticToken(DotDot, 1, 11),
+				NewDynamicToken(IntegerConst, "10", 1, 13),
+				NewStaticToken(RBracket, 1, 15),
+				NewStaticToken(Of, 1, 17),
+				NewStaticToken(Real, 1, 20),
+				NewStaticToken(Semi, 1, 24),
+				NewDynamicToken(ID, "R", 1, 26),
+				NewStaticToken(Dot, 1, 27),
+				NewDynamicToken(ID, "X", 1, 28),
+				NewStaticToken(Assign, 1, 30),
+				NewDynamicToken(RealConst, "2.5", 1, 33),
+			},
+		},
+		"string literals": {
+			givenText: "s := 'it''s' + '' + 'a': string; c: char",
+			wantTokens: []*Token{
+				NewDynamicToken(ID, "S", 1, 1),
+				NewStaticToken(Assign, 1, 3),
+				NewDynamicToken(StringConst, "'it''s'", 1, 6),
+				NewStaticToken(Plus, 1, 14),
+				NewDynamicToken(StringConst, "''", 1, 16),
+				NewStaticToken(Plus, 1, 19),
+				NewDynamicToken(StringConst, "'a'", 1, 21),
+				NewStaticToken(Colon, 1, 24),
+				NewStaticToken(String, 1, 26),
+				NewStaticToken(Semi, 1, 32),
+				NewDynamicToken(ID, "C", 1, 34),
+				NewStaticToken(Colon, 1, 35),
+				NewStaticToken(Char, 1, 37),
 			},
 		},
 	}
@@ -160,6 +232,16 @@ func TestLexer_GetNextToken(t *testing.T) {
 		log.Println(token)
 		assert.Equal(t, `<Error: module=Lexer,code=UnclosedComment,message="lexeme=,pos=(1,6)">`, err.Error())
 	})
+
+	t.Run("unclosed string", func(t *testing.T) {
+		l := NewLexer("'it''s\nend")
+		_, err := l.GetNextToken()
+		assert.Equal(t, `<Error: module=Lexer,code=UnclosedString,message="lexeme=,pos=(1,7)">`, err.Error())
+		assert.Equal(t, Span{Row: 1, Col: 1, Len: 1}, err.(Error).Span)
+		token, err := l.GetNextToken()
+		assert.NoError(t, err)
+		assert.Equal(t, End, token.Kind)
+	})
 }
 
 func TestPeekNextToken(t *testing.T) {
@@ -178,3 +260,9 @@ func TestPeekNextToken(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedNextToken, nextToken)
 }
+
+// withComments attaches comments to an expected token.
+func withComments(token *Token, comments ...*Comment) *Token {
+	token.Comments = comments
+	return token
+}
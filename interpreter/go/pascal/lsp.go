@@ -0,0 +1,617 @@
+package pascal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// LSP symbol kinds and diagnostic severities.
+const (
+	lspSymbolKindStruct   = 23
+	lspSymbolKindFunction = 12
+	lspSymbolKindVariable = 13
+	lspSymbolKindConstant = 14
+
+	lspSeverityError   = 1
+	lspSeverityWarning = 2
+)
+
+// maxContentLength bounds the content of a message, 64 MiB.
+const maxContentLength = 64 << 20
+
+// errExitWithoutShutdown is returned when the client exits before asking
+// the server to shut down.
+var errExitWithoutShutdown = errors.New("exit without shutdown")
+
+// NewLanguageServer creates a LanguageServer reading messages from reader
+// and writing responses and notifications to writer.
+func NewLanguageServer(reader io.Reader, writer io.Writer) *LanguageServer {
+	return &LanguageServer{
+		reader:    textproto.NewReader(bufio.NewReader(reader)),
+		writer:    writer,
+		documents: make(map[string]*document),
+	}
+}
+
+// LanguageServer speaks the Language Server Protocol over a stream: it
+// publishes the diagnostics of the documents open in the editor and finds
+// the definitions, descriptions and symbols of their names.
+type LanguageServer struct {
+	reader    *textproto.Reader
+	writer    io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspHover struct {
+	Contents lspMarkupContent `json:"contents"`
+	Range    lspRange         `json:"range"`
+}
+
+type lspMarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspDocumentSymbol struct {
+	Name           string              `json:"name"`
+	Detail         string              `json:"detail"`
+	Kind           int                 `json:"kind"`
+	Range          lspRange            `json:"range"`
+	SelectionRange lspRange            `json:"selectionRange"`
+	Children       []lspDocumentSymbol `json:"children,omitempty"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+// Serve handles messages until the client exits, the error is nil if it
+// asked the server to shut down first.
+func (l *LanguageServer) Serve() error {
+	for {
+		body, err := l.read()
+		if err != nil {
+			return err
+		}
+		var request rpcRequest
+		if err = json.Unmarshal(body, &request); err != nil {
+			if err = l.respond(json.RawMessage("null"), nil, &rpcError{Code: rpcParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if request.Method == "exit" {
+			if !l.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, err := l.handle(request)
+		rpcErr, ok := err.(*rpcError)
+		if err != nil && !ok {
+			return err
+		}
+		// notifications have no id and get no response.
+		if len(request.ID) == 0 {
+			continue
+		}
+		if err = l.respond(request.ID, result, rpcErr); err != nil {
+			return err
+		}
+	}
+}
+
+// read returns the content of the next message, after its headers.
+func (l *LanguageServer) read() ([]byte, error) {
+	header, err := l.reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 || length > maxContentLength {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err = io.ReadFull(l.reader.R, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (l *LanguageServer) write(message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(l.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (l *LanguageServer) respond(id json.RawMessage, result interface{}, rpcErr *rpcError) error {
+	response := rpcResponse{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if rpcErr == nil {
+		body, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		response.Result = body
+	}
+	return l.write(response)
+}
+
+func (l *LanguageServer) notify(method string, params interface{}) error {
+	return l.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// handle runs a request or a notification, the result of a notification
+// is dropped. An *rpcError is sent to the client, other errors stop the
+// server.
+func (l *LanguageServer) handle(request rpcRequest) (result interface{}, err error) {
+	switch request.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// the full text is sent on every change.
+				"textDocumentSync":       1,
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "go-playground"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		l.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err = unmarshalParams(request.Params, &params); err != nil {
+			return
+		}
+		return nil, l.open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err = unmarshalParams(request.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return
+		}
+		return nil, l.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var params lspTextDocumentPositionParams
+		if err = unmarshalParams(request.Params, &params); err != nil {
+			return
+		}
+		delete(l.documents, params.TextDocument.URI)
+		return nil, l.publish(params.TextDocument.URI, []lspDiagnostic{})
+	case "textDocument/definition":
+		var params lspTextDocumentPositionParams
+		if err = unmarshalParams(request.Params, &params); err != nil {
+			return
+		}
+		doc, ref := l.reference(params)
+		if ref == nil {
+			return nil, nil
+		}
+		token, ok := doc.definitions[ref.symbol]
+		if !ok {
+			return nil, nil
+		}
+		return lspLocation{URI: params.TextDocument.URI, Range: doc.tokenRange(token)}, nil
+	case "textDocument/hover":
+		var params lspTextDocumentPositionParams
+		if err = unmarshalParams(request.Params, &params); err != nil {
+			return
+		}
+		doc, ref := l.reference(params)
+		if ref == nil {
+			return nil, nil
+		}
+		return lspHover{
+			Contents: lspMarkupContent{Kind: "markdown", Value: "```pascal\n" + describeSymbol(ref.symbol) + "\n```"},
+			Range:    doc.tokenRange(ref.token),
+		}, nil
+	case "textDocument/documentSymbol":
+		var params lspTextDocumentPositionParams
+		if err = unmarshalParams(request.Params, &params); err != nil {
+			return
+		}
+		doc, ok := l.documents[params.TextDocument.URI]
+		if !ok || doc.symbols == nil {
+			return []lspDocumentSymbol{}, nil
+		}
+		return doc.symbols, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method not found: %s", request.Method)}
+}
+
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// open analyzes the text of a document and publishes its diagnostics.
+func (l *LanguageServer) open(uri, text string) error {
+	doc := analyzeDocument(uri, text)
+	l.documents[uri] = doc
+	return l.publish(uri, doc.diagnostics)
+}
+
+func (l *LanguageServer) publish(uri string, diagnostics []lspDiagnostic) error {
+	return l.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
+
+// reference returns the name at the position of params, if any.
+func (l *LanguageServer) reference(params lspTextDocumentPositionParams) (*document, *reference) {
+	doc, ok := l.documents[params.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	row, col := doc.sourcePosition(params.Position)
+	for i, ref := range doc.references {
+		if ref.token.Row == row && ref.token.Col <= col && col < ref.token.Col+len(ref.token.Value) {
+			return doc, &doc.references[i]
+		}
+	}
+	return doc, nil
+}
+
+// document is an open source file and what its analysis found.
+type document struct {
+	lines       []string
+	diagnostics []lspDiagnostic
+	// references are the names of the program, in order, and the symbols
+	// they stand for.
+	references []reference
+	// definitions are the names declaring the symbols of the program, those
+	// imported from units have none.
+	definitions map[Symbol]*Token
+	symbols     []lspDocumentSymbol
+}
+
+type reference struct {
+	token  *Token
+	symbol Symbol
+}
+
+// analyzeDocument parses and analyzes text, a program or a unit, the
+// document at uri whose units are read from its directory. A document that
+// doesn't parse only has diagnostics.
+func analyzeDocument(uri, text string) *document {
+	doc := &document{
+		lines:       strings.Split(text, "\n"),
+		diagnostics: []lspDiagnostic{},
+		definitions: make(map[Symbol]*Token),
+	}
+	root, err := parseSource(text)
+	if err != nil {
+		doc.diagnose(err)
+		return doc
+	}
+
+	semanticAnalyzer := NewSemanticAnalyzer()
+	semanticAnalyzer.loader = searchPath(documentDirs(uri))
+	semanticAnalyzer.resolved = func(token *Token, symbol Symbol) {
+		doc.references = append(doc.references, reference{token: token, symbol: symbol})
+		// a symbol is declared before it's used, builtins aren't declared.
+		if _, ok := doc.definitions[symbol]; !ok && !isBuiltin(symbol) {
+			doc.definitions[symbol] = token
+		}
+	}
+	if err = Walk(semanticAnalyzer, root); err != nil {
+		doc.diagnose(err)
+	}
+	// NOTE: the symbols of the units are declared in their own files, the
+	// first name resolved to one is a use.
+	for _, unit := range semanticAnalyzer.order {
+		for _, symbol := range unit.exports {
+			delete(doc.definitions, symbol)
+		}
+	}
+	for _, warning := range semanticAnalyzer.Warnings() {
+		doc.diagnose(warning)
+	}
+	switch n := root.(type) {
+	case *ProgramNode:
+		doc.symbols = doc.documentSymbols(n.block)
+	case *UnitNode:
+		doc.symbols = doc.documentSymbols(n.block)
+	}
+	return doc
+}
+
+// documentDirs returns the directory of the file at uri, none if it's not
+// a file and the units are searched in the current directory.
+func documentDirs(uri string) []string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return nil
+	}
+	return []string{filepath.Dir(filepath.FromSlash(u.Path))}
+}
+
+func isBuiltin(symbol Symbol) bool {
+	switch symbol.(type) {
+	case *BuiltinTypeSymbol, *BuiltinProcedureSymbol, *BuiltinFunctionSymbol:
+		return true
+	}
+	return false
+}
+
+// diagnose adds the diagnostics of err, an Error or an ErrorList.
+func (d *document) diagnose(err error) {
+	if errs, ok := err.(ErrorList); ok {
+		for _, err := range errs {
+			d.diagnose(err)
+		}
+		return
+	}
+	e, ok := err.(Error)
+	if !ok {
+		d.diagnostics = append(d.diagnostics, lspDiagnostic{Severity: lspSeverityError, Source: "pascal", Message: err.Error()})
+		return
+	}
+	diagnostic := lspDiagnostic{
+		Range:    d.spanRange(e.Span),
+		Severity: lspSeverityError,
+		Code:     e.Code.String(),
+		Source:   "pascal",
+		Message:  e.Suggestion,
+	}
+	if e.Warning {
+		diagnostic.Severity = lspSeverityWarning
+	}
+	if diagnostic.Message == "" {
+		diagnostic.Message = fmt.Sprintf("%s %s", e.Module, e.Code)
+	}
+	d.diagnostics = append(d.diagnostics, diagnostic)
+}
+
+// documentSymbols returns the types, variables and routines declared in
+// block, those of a routine are its children.
+func (d *document) documentSymbols(block *BlockNode) []lspDocumentSymbol {
+	symbols := []lspDocumentSymbol{}
+	for _, decl := range block.declarations {
+		switch n := decl.(type) {
+		case *ConstDeclNode:
+			symbols = append(symbols, d.documentSymbol(n.token, lspSymbolKindConstant, nil))
+		case *TypeDeclNode:
+			symbols = append(symbols, d.documentSymbol(n.token, lspSymbolKindStruct, nil))
+		case *VarDeclNode:
+			symbols = append(symbols, d.documentSymbol(n.varNode.token, lspSymbolKindVariable, nil))
+		case *ProcedureDeclNode:
+			symbols = append(symbols, d.documentSymbol(n.token, lspSymbolKindFunction, d.routineSymbols(n.params, n.block)))
+		case *FunctionDeclNode:
+			symbols = append(symbols, d.documentSymbol(n.token, lspSymbolKindFunction, d.routineSymbols(n.params, n.block)))
+		}
+	}
+	return symbols
+}
+
+func (d *document) routineSymbols(params []*ParamNode, block *BlockNode) []lspDocumentSymbol {
+	var symbols []lspDocumentSymbol
+	for _, param := range params {
+		symbols = append(symbols, d.documentSymbol(param.varNode.token, lspSymbolKindVariable, nil))
+	}
+	return append(symbols, d.documentSymbols(block)...)
+}
+
+func (d *document) documentSymbol(token *Token, kind int, children []lspDocumentSymbol) lspDocumentSymbol {
+	symbol := lspDocumentSymbol{
+		Name:           token.Value,
+		Kind:           kind,
+		Range:          d.tokenRange(token),
+		SelectionRange: d.tokenRange(token),
+		Children:       children,
+	}
+	for _, ref := range d.references {
+		if ref.token == token {
+			symbol.Detail = describeSymbol(ref.symbol)
+			break
+		}
+	}
+	return symbol
+}
+
+func (d *document) tokenRange(token *Token) lspRange {
+	return d.spanRange(Span{Row: token.Row, Col: token.Col, Len: len(token.Value)})
+}
+
+func (d *document) spanRange(span Span) lspRange {
+	return lspRange{
+		Start: d.lspPosition(span.Row, span.Col),
+		End:   d.lspPosition(span.Row, span.Col+span.Len),
+	}
+}
+
+// lspPosition converts a 1-based row and byte column of the source into a
+// 0-based line and UTF-16 character of LSP.
+func (d *document) lspPosition(row, col int) lspPosition {
+	if row < 1 || row > len(d.lines) {
+		return lspPosition{}
+	}
+	line := d.lines[row-1]
+	if col-1 > len(line) {
+		col = len(line) + 1
+	}
+	if col < 1 {
+		col = 1
+	}
+	return lspPosition{Line: row - 1, Character: len(utf16.Encode([]rune(line[:col-1])))}
+}
+
+// sourcePosition converts an LSP position back into a row and a column.
+func (d *document) sourcePosition(position lspPosition) (row, col int) {
+	row = position.Line + 1
+	if position.Line < 0 || position.Line >= len(d.lines) {
+		return row, position.Character + 1
+	}
+	units := 0
+	for i, r := range d.lines[position.Line] {
+		if units >= position.Character {
+			return row, i + 1
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return row, len(d.lines[position.Line]) + 1
+}
+
+// describeSymbol writes a symbol as it would be declared.
+func describeSymbol(symbol Symbol) string {
+	switch sym := symbol.(type) {
+	case *BuiltinTypeSymbol:
+		return "type " + sym.name
+	case *ArrayTypeSymbol:
+		return fmt.Sprintf("type %s = %s", sym.name, sym.describe())
+	case *RecordTypeSymbol:
+		return fmt.Sprintf("type %s = %s", sym.name, sym.describe())
+	case *EnumTypeSymbol:
+		return fmt.Sprintf("type %s = %s", sym.name, sym.describe())
+	case *TypeAliasSymbol:
+		return fmt.Sprintf("type %s = %s", sym.name, typeName(sym.typ))
+	case *ConstSymbol:
+		if _, ok := sym.value.(EnumValue); ok {
+			return fmt.Sprintf("%s : %s", sym.name, typeName(sym.typ))
+		}
+		return fmt.Sprintf("const %s = %s", sym.name, formatLiteral(sym.value))
+	case *VarSymbol:
+		return describeVar(sym)
+	case *ProcedureSymbol:
+		return "procedure " + sym.name + describeParams(sym.formalParams)
+	case *FunctionSymbol:
+		return fmt.Sprintf("function %s%s : %s", sym.name, describeParams(sym.formalParams), typeName(sym.returnType))
+	case *BuiltinProcedureSymbol:
+		return fmt.Sprintf("procedure %s(...)", sym.name)
+	case *BuiltinFunctionSymbol:
+		return fmt.Sprintf("function %s%s : %s", sym.name, describeParams(sym.formalParams), typeName(sym.returnType))
+	}
+	return symbol.String()
+}
+
+func describeVar(symbol *VarSymbol) string {
+	prefix := ""
+	if symbol.byReference {
+		prefix = "var "
+	}
+	return fmt.Sprintf("%s%s : %s", prefix, symbol.name, typeName(symbol.typ))
+}
+
+func describeParams(params []*VarSymbol) string {
+	if len(params) == 0 {
+		return ""
+	}
+	described := make([]string, len(params))
+	for i, param := range params {
+		described[i] = describeVar(param)
+	}
+	return "(" + strings.Join(described, "; ") + ")"
+}
+
+// typeName returns the name of a type, an anonymous one is described.
+func typeName(typ Symbol) string {
+	switch t := typ.(type) {
+	case nil:
+		return "?"
+	case *ArrayTypeSymbol:
+		if t.name == "" {
+			return t.describe()
+		}
+	case *RecordTypeSymbol:
+		if t.name == "" {
+			return t.describe()
+		}
+	case *EnumTypeSymbol:
+		if t.name == "" {
+			return t.describe()
+		}
+	}
+	return typ.GetName()
+}
@@ -0,0 +1,311 @@
+package pascal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const lspTestURI = "file:///main.pas"
+
+const lspTestSource = `program Main;
+type Vec = array[1..3] of integer;
+var v : Vec;
+    unused : real;
+
+procedure Fill(var a : Vec; x : integer);
+var i : integer;
+begin
+   for i := 1 to 3 do a[i] := x
+end;
+
+begin
+   Fill(v, 7);
+   writeln(v[1])
+end.`
+
+// lspScript frames the messages of a client, as the server reads them.
+func lspScript(messages ...string) io.Reader {
+	sb := strings.Builder{}
+	for _, message := range messages {
+		fmt.Fprintf(&sb, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	}
+	return strings.NewReader(sb.String())
+}
+
+func lspRequest(id int, method string, params interface{}) string {
+	body, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	return string(body)
+}
+
+func lspNotification(method string, params interface{}) string {
+	body, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+	return string(body)
+}
+
+// lspMessages splits the output of the server into its messages.
+func lspMessages(t *testing.T, output []byte) (messages []string) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(output)))
+	for {
+		header, err := reader.ReadMIMEHeader()
+		if err == io.EOF {
+			return
+		}
+		assert.NoError(t, err)
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		assert.NoError(t, err)
+		body := make([]byte, length)
+		_, err = io.ReadFull(reader.R, body)
+		assert.NoError(t, err)
+		messages = append(messages, string(body))
+	}
+}
+
+func lspPositionParams(line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": lspTestURI},
+		"position":     map[string]int{"line": line, "character": character},
+	}
+}
+
+func TestLanguageServer_Serve(t *testing.T) {
+	didOpen := lspNotification("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": lspTestURI, "languageId": "pascal", "version": 1, "text": lspTestSource},
+	})
+
+	tests := map[string]struct {
+		givenMessages []string
+		wantMessages  []string
+	}{
+		"initialize": {
+			givenMessages: []string{lspRequest(1, "initialize", map[string]interface{}{}), lspNotification("initialized", map[string]interface{}{})},
+			wantMessages: []string{`{"jsonrpc": "2.0", "id": 1, "result": {
+				"capabilities": {"textDocumentSync": 1, "definitionProvider": true, "hoverProvider": true, "documentSymbolProvider": true},
+				"serverInfo": {"name": "go-playground"}
+			}}`},
+		},
+		"diagnostics": {
+			givenMessages: []string{
+				didOpen,
+				lspNotification("textDocument/didChange", map[string]interface{}{
+					"textDocument":   map[string]interface{}{"uri": lspTestURI, "version": 2},
+					"contentChanges": []map[string]string{{"text": "program Main;\nbegin\n  x := 1\nend."}},
+				}),
+				lspNotification("textDocument/didChange", map[string]interface{}{
+					"textDocument":   map[string]interface{}{"uri": lspTestURI, "version": 3},
+					"contentChanges": []map[string]string{{"text": "program Main;\nbegin\n  x := 1\n  y := 2\nend."}},
+				}),
+				lspNotification("textDocument/didClose", map[string]interface{}{"textDocument": map[string]string{"uri": lspTestURI}}),
+			},
+			wantMessages: []string{
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 3, "character": 4}, "end": {"line": 3, "character": 10}}, "severity": 2,
+					 "code": "UnusedVariable", "source": "pascal", "message": "UNUSED is declared but never used, remove it"}
+				]}}`,
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 2, "character": 2}, "end": {"line": 2, "character": 3}}, "severity": 1,
+					 "code": "IdNotFound", "source": "pascal", "message": "declare X before using it"}
+				]}}`,
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 3, "character": 2}, "end": {"line": 3, "character": 3}}, "severity": 1,
+					 "code": "UnexpectedToken", "source": "pascal", "message": "expected ;"}
+				]}}`,
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": []}}`,
+			},
+		},
+		"empty document": {
+			givenMessages: []string{
+				lspNotification("textDocument/didOpen", map[string]interface{}{
+					"textDocument": map[string]interface{}{"uri": lspTestURI, "languageId": "pascal", "version": 1, "text": ""},
+				}),
+				lspNotification("textDocument/didChange", map[string]interface{}{
+					"textDocument":   map[string]interface{}{"uri": lspTestURI, "version": 2},
+					"contentChanges": []map[string]string{{"text": ""}},
+				}),
+			},
+			wantMessages: []string{
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 0}}, "severity": 1,
+					 "code": "UnexpectedToken", "source": "pascal", "message": "expected PROGRAM"}
+				]}}`,
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 0, "character": 0}, "end": {"line": 0, "character": 0}}, "severity": 1,
+					 "code": "UnexpectedToken", "source": "pascal", "message": "expected PROGRAM"}
+				]}}`,
+			},
+		},
+		"definition": {
+			givenMessages: []string{
+				didOpen,
+				lspRequest(1, "textDocument/definition", lspPositionParams(12, 4)),
+				lspRequest(2, "textDocument/definition", lspPositionParams(8, 30)),
+				lspRequest(3, "textDocument/definition", lspPositionParams(2, 9)),
+				lspRequest(4, "textDocument/definition", lspPositionParams(13, 5)),
+				lspRequest(5, "textDocument/definition", lspPositionParams(7, 0)),
+			},
+			wantMessages: []string{
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 3, "character": 4}, "end": {"line": 3, "character": 10}}, "severity": 2,
+					 "code": "UnusedVariable", "source": "pascal", "message": "UNUSED is declared but never used, remove it"}
+				]}}`,
+				`{"jsonrpc": "2.0", "id": 1, "result": {"uri": "file:///main.pas", "range": {"start": {"line": 5, "character": 10}, "end": {"line": 5, "character": 14}}}}`,
+				`{"jsonrpc": "2.0", "id": 2, "result": {"uri": "file:///main.pas", "range": {"start": {"line": 5, "character": 28}, "end": {"line": 5, "character": 29}}}}`,
+				`{"jsonrpc": "2.0", "id": 3, "result": {"uri": "file:///main.pas", "range": {"start": {"line": 1, "character": 5}, "end": {"line": 1, "character": 8}}}}`,
+				`{"jsonrpc": "2.0", "id": 4, "result": null}`,
+				`{"jsonrpc": "2.0", "id": 5, "result": null}`,
+			},
+		},
+		"hover": {
+			givenMessages: []string{
+				didOpen,
+				lspRequest(1, "textDocument/hover", lspPositionParams(12, 3)),
+				lspRequest(2, "textDocument/hover", lspPositionParams(8, 22)),
+				lspRequest(3, "textDocument/hover", lspPositionParams(13, 6)),
+				lspRequest(4, "textDocument/hover", lspPositionParams(1, 5)),
+			},
+			wantMessages: []string{
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 3, "character": 4}, "end": {"line": 3, "character": 10}}, "severity": 2,
+					 "code": "UnusedVariable", "source": "pascal", "message": "UNUSED is declared but never used, remove it"}
+				]}}`,
+				`{"jsonrpc": "2.0", "id": 1, "result": {
+					"contents": {"kind": "markdown", "value": "` + "```pascal\\nprocedure FILL(var A : VEC; X : INTEGER)\\n```" + `"},
+					"range": {"start": {"line": 12, "character": 3}, "end": {"line": 12, "character": 7}}
+				}}`,
+				`{"jsonrpc": "2.0", "id": 2, "result": {
+					"contents": {"kind": "markdown", "value": "` + "```pascal\\nvar A : VEC\\n```" + `"},
+					"range": {"start": {"line": 8, "character": 22}, "end": {"line": 8, "character": 23}}
+				}}`,
+				`{"jsonrpc": "2.0", "id": 3, "result": {
+					"contents": {"kind": "markdown", "value": "` + "```pascal\\nprocedure WRITELN(...)\\n```" + `"},
+					"range": {"start": {"line": 13, "character": 3}, "end": {"line": 13, "character": 10}}
+				}}`,
+				`{"jsonrpc": "2.0", "id": 4, "result": {
+					"contents": {"kind": "markdown", "value": "` + "```pascal\\ntype VEC = ARRAY[1..3] OF INTEGER\\n```" + `"},
+					"range": {"start": {"line": 1, "character": 5}, "end": {"line": 1, "character": 8}}
+				}}`,
+			},
+		},
+		"document symbols": {
+			givenMessages: []string{
+				didOpen,
+				lspRequest(1, "textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]string{"uri": lspTestURI}}),
+			},
+			wantMessages: []string{
+				`{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "file:///main.pas", "diagnostics": [
+					{"range": {"start": {"line": 3, "character": 4}, "end": {"line": 3, "character": 10}}, "severity": 2,
+					 "code": "UnusedVariable", "source": "pascal", "message": "UNUSED is declared but never used, remove it"}
+				]}}`,
+				`{"jsonrpc": "2.0", "id": 1, "result": [
+					{"name": "VEC", "detail": "type VEC = ARRAY[1..3] OF INTEGER", "kind": 23,
+					 "range": {"start": {"line": 1, "character": 5}, "end": {"line": 1, "character": 8}},
+					 "selectionRange": {"start": {"line": 1, "character": 5}, "end": {"line": 1, "character": 8}}},
+					{"name": "V", "detail": "V : VEC", "kind": 13,
+					 "range": {"start": {"line": 2, "character": 4}, "end": {"line": 2, "character": 5}},
+					 "selectionRange": {"start": {"line": 2, "character": 4}, "end": {"line": 2, "character": 5}}},
+					{"name": "UNUSED", "detail": "UNUSED : REAL", "kind": 13,
+					 "range": {"start": {"line": 3, "character": 4}, "end": {"line": 3, "character": 10}},
+					 "selectionRange": {"start": {"line": 3, "character": 4}, "end": {"line": 3, "character": 10}}},
+					{"name": "FILL", "detail": "procedure FILL(var A : VEC; X : INTEGER)", "kind": 12,
+					 "range": {"start": {"line": 5, "character": 10}, "end": {"line": 5, "character": 14}},
+					 "selectionRange": {"start": {"line": 5, "character": 10}, "end": {"line": 5, "character": 14}},
+					 "children": [
+						{"name": "A", "detail": "var A : VEC", "kind": 13,
+						 "range": {"start": {"line": 5, "character": 19}, "end": {"line": 5, "character": 20}},
+						 "selectionRange": {"start": {"line": 5, "character": 19}, "end": {"line": 5, "character": 20}}},
+						{"name": "X", "detail": "X : INTEGER", "kind": 13,
+						 "range": {"start": {"line": 5, "character": 28}, "end": {"line": 5, "character": 29}},
+						 "selectionRange": {"start": {"line": 5, "character": 28}, "end": {"line": 5, "character": 29}}},
+						{"name": "I", "detail": "I : INTEGER", "kind": 13,
+						 "range": {"start": {"line": 6, "character": 4}, "end": {"line": 6, "character": 5}},
+						 "selectionRange": {"start": {"line": 6, "character": 4}, "end": {"line": 6, "character": 5}}}
+					 ]}
+				]}`,
+			},
+		},
+		"errors": {
+			givenMessages: []string{
+				lspRequest(1, "textDocument/rename", map[string]interface{}{}),
+				lspRequest(2, "textDocument/hover", []int{1}),
+				`{"jsonrpc": "2.0", "id": 3, "method": `,
+			},
+			wantMessages: []string{
+				`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32601, "message": "method not found: textDocument/rename"}}`,
+				`{"jsonrpc": "2.0", "id": 2, "error": {"code": -32602, "message": "json: cannot unmarshal array into Go value of type pascal.lspTextDocumentPositionParams"}}`,
+				`{"jsonrpc": "2.0", "id": null, "error": {"code": -32700, "message": "unexpected end of JSON input"}}`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			messages := append(tc.givenMessages, lspRequest(99, "shutdown", nil), lspNotification("exit", nil))
+			output := bytes.NewBuffer(nil)
+			assert.NoError(t, NewLanguageServer(lspScript(messages...), output).Serve())
+
+			got := lspMessages(t, output.Bytes())
+			assert.Len(t, got, len(tc.wantMessages)+1)
+			for i, want := range tc.wantMessages {
+				if i < len(got) {
+					assert.JSONEq(t, want, got[i])
+				}
+			}
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 99, "result": null}`, got[len(got)-1])
+		})
+	}
+
+	t.Run("units", func(t *testing.T) {
+		dir := writeTestUnits(t, map[string]string{"counter.pas": counterTestUnit})
+		uri := "file://" + filepath.ToSlash(dir) + "/main.pas"
+		unitURI := "file://" + filepath.ToSlash(dir) + "/shapes.pas"
+		output := bytes.NewBuffer(nil)
+		assert.NoError(t, NewLanguageServer(lspScript(
+			lspNotification("textDocument/didOpen", map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": uri, "languageId": "pascal", "version": 1,
+					"text": "program Main;\nuses Counter;\nbegin\n  Tick(count)\nend."},
+			}),
+			lspNotification("textDocument/didOpen", map[string]interface{}{
+				"textDocument": map[string]interface{}{"uri": unitURI, "languageId": "pascal", "version": 1, "text": shapesTestUnit},
+			}),
+			lspRequest(1, "textDocument/documentSymbol", map[string]interface{}{"textDocument": map[string]string{"uri": unitURI}}),
+			lspRequest(2, "textDocument/definition", map[string]interface{}{
+				"textDocument": map[string]string{"uri": uri}, "position": map[string]int{"line": 3, "character": 3},
+			}),
+			lspRequest(3, "textDocument/definition", map[string]interface{}{
+				"textDocument": map[string]string{"uri": uri}, "position": map[string]int{"line": 3, "character": 8},
+			}),
+			lspRequest(99, "shutdown", nil), lspNotification("exit", nil),
+		), output).Serve())
+
+		got := lspMessages(t, output.Bytes())
+		if assert.Len(t, got, 6) {
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "`+uri+`", "diagnostics": []}}`, got[0])
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "method": "textDocument/publishDiagnostics", "params": {"uri": "`+unitURI+`", "diagnostics": []}}`, got[1])
+			assert.Contains(t, got[2], `"name":"AREA"`)
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 2, "result": null}`, got[3])
+			assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 3, "result": null}`, got[4])
+		}
+	})
+
+	t.Run("invalid content length", func(t *testing.T) {
+		for _, length := range []string{"-1", "1099511627776", "many"} {
+			err := NewLanguageServer(strings.NewReader("Content-Length: "+length+"\r\n\r\n{}"), ioutil.Discard).Serve()
+			assert.EqualError(t, err, fmt.Sprintf("invalid Content-Length %q", length))
+		}
+	})
+
+	t.Run("exit without shutdown", func(t *testing.T) {
+		err := NewLanguageServer(lspScript(lspNotification("exit", nil)), ioutil.Discard).Serve()
+		assert.Equal(t, errExitWithoutShutdown, err)
+	})
+}
@@ -0,0 +1,297 @@
+package pascal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+func NewOptimizer(writer io.Writer) *Optimizer {
+	return &Optimizer{writer: writer}
+}
+
+var _ Visitor = (*Optimizer)(nil)
+
+// Optimizer rewrites an analyzed tree without changing what the program
+// does: it folds constant expressions, drops the branches of IF and WHILE
+// statements that can't run, removes empty statements and the variables
+// and routines that are never used. The SemanticAnalyzer's warnings and
+// the changes are written to writer.
+type Optimizer struct {
+	writer io.Writer
+	unused map[ASTNode]bool
+	// references count the names standing for each constant, its
+	// declaration included, declared maps the names declaring them.
+	references map[Symbol]int
+	declared   map[*Token]Symbol
+	changes    []Change
+}
+
+// Change is a rewrite of the Optimizer, at the source of the node rewritten.
+type Change struct {
+	Span    Span
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%d:%d: %s", c.Span.Row, c.Span.Col, c.Message)
+}
+
+// Optimize rewrites root, a removed routine may leave others unused so it's
+// analyzed again until there's nothing left to remove.
+func (o *Optimizer) Optimize(root ASTNode) (changes []Change, err error) {
+	o.changes = nil
+	for first := true; ; first = false {
+		semanticAnalyzer := NewSemanticAnalyzer()
+		o.references, o.declared = make(map[Symbol]int), make(map[*Token]Symbol)
+		semanticAnalyzer.resolved = func(token *Token, symbol Symbol) {
+			if _, ok := symbol.(*ConstSymbol); !ok {
+				return
+			}
+			if o.references[symbol] == 0 {
+				o.declared[token] = symbol
+			}
+			o.references[symbol]++
+		}
+		if err = Walk(semanticAnalyzer, root); err != nil {
+			return
+		}
+		if first {
+			for _, warning := range semanticAnalyzer.Warnings() {
+				fmt.Fprintf(o.writer, "%d:%d: warning %s: %s\n", warning.Span.Row, warning.Span.Col, warning.Code, warning.Suggestion)
+			}
+		}
+		o.unused = semanticAnalyzer.unused()
+		count := len(o.changes)
+		if err = Walk(o, root); err != nil {
+			return
+		}
+		if len(o.changes) == count {
+			break
+		}
+	}
+	sort.SliceStable(o.changes, func(i, j int) bool {
+		a, b := o.changes[i].Span, o.changes[j].Span
+		return a.Row < b.Row || (a.Row == b.Row && a.Col < b.Col)
+	})
+	for _, change := range o.changes {
+		fmt.Fprintln(o.writer, change)
+	}
+	return o.changes, nil
+}
+
+// declaresUsed reports whether a type declares enumerated values that are
+// used.
+func (o *Optimizer) declaresUsed(typNode ASTNode) bool {
+	for _, token := range enumValues(typNode) {
+		if symbol, ok := o.declared[token]; ok && o.references[symbol] > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Optimizer) Before(node ASTNode) (shouldStepIn bool, err error) {
+	switch n := node.(type) {
+	case *ProgramNode, *ProcedureDeclNode, *FunctionDeclNode:
+	case *BlockNode:
+		declarations := n.declarations[:0]
+		for _, decl := range n.declarations {
+			// NOTE: an unused variable whose type declares enumerated values
+			// stays if they're used.
+			if varDecl, ok := decl.(*VarDeclNode); !o.unused[decl] || ok && o.declaresUsed(varDecl.typNode) {
+				declarations = append(declarations, decl)
+				continue
+			}
+			switch d := decl.(type) {
+			case *VarDeclNode:
+				o.change(d.varNode.token, fmt.Sprintf("removed unused variable %s", d.varNode.value))
+			case *ProcedureDeclNode:
+				o.change(d.token, fmt.Sprintf("removed unused procedure %s", d.name))
+			case *FunctionDeclNode:
+				o.change(d.token, fmt.Sprintf("removed unused function %s", d.name))
+			}
+		}
+		n.declarations = declarations
+	case *ConstDeclNode, *VarDeclNode, *TypeDeclNode, *ParamNode:
+		return false, nil
+	case *CompoundNode:
+		n.children = o.stmts(n.children)
+	case *AssignNode:
+		if index, ok := n.left.(*IndexNode); ok {
+			index.index = o.fold(index.index)
+		}
+		n.right = o.fold(n.right)
+		return false, nil
+	case *ProcedureCallNode:
+		for i, actualParam := range n.actualParams {
+			n.actualParams[i] = o.fold(actualParam)
+		}
+		// NOTE: Walk would step into the block of the procedure called.
+		return false, nil
+	case *IfNode:
+		n.thenStmt = o.stmt(n.thenStmt)
+		if n.elseStmt != nil {
+			n.elseStmt = o.stmt(n.elseStmt)
+		}
+	case *WhileNode:
+		n.body = o.stmt(n.body)
+	case *RepeatNode:
+		n.condition = o.fold(n.condition)
+		n.children = o.stmts(n.children)
+	case *ForNode:
+		n.start, n.end = o.fold(n.start), o.fold(n.end)
+		n.body = o.stmt(n.body)
+	default:
+		// expressions are folded along with the statement they're part of.
+		return false, nil
+	}
+	return true, nil
+}
+
+func (o *Optimizer) After(node ASTNode) error {
+	return nil
+}
+
+// stmts rewrites the statements of a list, the empty ones are removed.
+func (o *Optimizer) stmts(nodes []ASTNode) []ASTNode {
+	var stmts []ASTNode
+	for _, node := range nodes {
+		node = o.stmt(node)
+		if _, ok := node.(*NoopNode); ok {
+			continue
+		}
+		stmts = append(stmts, node)
+	}
+	return stmts
+}
+
+// stmt rewrites a statement whose condition is constant into what runs of
+// it, an IF becomes one of its branches and a WHILE FALSE an empty statement.
+func (o *Optimizer) stmt(node ASTNode) ASTNode {
+	switch n := node.(type) {
+	case *IfNode:
+		n.condition = o.fold(n.condition)
+		cond, ok := n.condition.(*BoolNode)
+		if !ok {
+			return n
+		}
+		if cond.value {
+			if n.elseStmt != nil {
+				o.change(n.token, "removed the ELSE branch of an IF TRUE")
+			} else {
+				o.change(n.token, "removed the condition of an IF TRUE")
+			}
+			return o.stmt(n.thenStmt)
+		}
+		o.change(n.token, "removed the THEN branch of an IF FALSE")
+		if n.elseStmt == nil {
+			return noop
+		}
+		return o.stmt(n.elseStmt)
+	case *WhileNode:
+		n.condition = o.fold(n.condition)
+		if cond, ok := n.condition.(*BoolNode); ok && !cond.value {
+			o.change(n.token, "removed a WHILE FALSE")
+			return noop
+		}
+	}
+	return node
+}
+
+// fold evaluates the operations on literals and constants of an
+// expression, a division by zero is left to fail at runtime.
+func (o *Optimizer) fold(node ASTNode) ASTNode {
+	switch n := node.(type) {
+	case *UnaryOpNode:
+		n.operand = o.fold(n.operand)
+		if value, ok := constantValue(n); ok {
+			return o.folded(n, n.token, value)
+		}
+	case *BinOpNode:
+		n.left, n.right = o.fold(n.left), o.fold(n.right)
+		if value, ok := constantValue(n); ok {
+			return o.folded(n, n.token, value)
+		}
+	case *FunctionCallNode:
+		for i, actualParam := range n.actualParams {
+			n.actualParams[i] = o.fold(actualParam)
+		}
+	case *IndexNode:
+		n.base, n.index = o.fold(n.base), o.fold(n.index)
+	case *FieldNode:
+		n.base = o.fold(n.base)
+	}
+	return node
+}
+
+// folded returns the literal of value replacing node, of node's type.
+func (o *Optimizer) folded(node ASTNode, token *Token, value interface{}) ASTNode {
+	var result ASTNode
+	switch v := value.(type) {
+	case int64:
+		result = &NumNode{
+			token:    NewDynamicToken(IntegerConst, strconv.FormatInt(v, 10), token.Row, token.Col),
+			intValue: int(v),
+			typ:      typeOf(node),
+		}
+	case float64:
+		result = &NumNode{
+			token:      NewDynamicToken(RealConst, strconv.FormatFloat(v, 'f', -1, 64), token.Row, token.Col),
+			floatValue: v,
+			typ:        typeOf(node),
+		}
+	case bool:
+		kind := False
+		if v {
+			kind = True
+		}
+		result = &BoolNode{token: NewStaticToken(kind, token.Row, token.Col), value: v, typ: typeOf(node)}
+	case string:
+		result = &StrNode{token: NewDynamicToken(StringConst, quote(v), token.Row, token.Col), value: v, typ: typeOf(node)}
+	default:
+		return node
+	}
+	o.change(token, fmt.Sprintf("folded a constant expression into %s", formatLiteral(value)))
+	return result
+}
+
+func (o *Optimizer) change(token *Token, message string) {
+	o.changes = append(o.changes, Change{Span: token.Span(), Message: message})
+}
+
+// literal returns the value of a literal node.
+func literal(node ASTNode) (value interface{}, ok bool) {
+	switch n := node.(type) {
+	case *NumNode:
+		if n.token.Kind == IntegerConst {
+			return int64(n.intValue), true
+		}
+		return n.floatValue, true
+	case *BoolNode:
+		return n.value, true
+	case *StrNode:
+		return n.value, true
+	}
+	return nil, false
+}
+
+// quote writes a string literal, a quote inside it is written twice.
+func quote(s string) string {
+	quoted := "'"
+	for _, r := range s {
+		if r == '\'' {
+			quoted += "'"
+		}
+		quoted += string(r)
+	}
+	return quoted + "'"
+}
+
+func formatLiteral(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return quote(s)
+	}
+	return formatValue(value)
+}
@@ -0,0 +1,159 @@
+package pascal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimizer_Optimize(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		wantChanges []string
+		wantOutput  string
+	}{
+		"constant expressions": {
+			givenSource: `
+				program Main;
+					var x : integer; s : string;
+				begin
+					x := 2 * 3 + x;
+					s := 'a' + 'b';
+					writeln(not (1 < 2), -(4 div 2), 10 / 4);
+					x := x div 0
+				end.
+			`,
+			wantChanges: []string{
+				"5:13: folded a constant expression into 6",
+				"6:15: folded a constant expression into 'ab'",
+				"7:14: folded a constant expression into FALSE",
+				"7:21: folded a constant expression into TRUE",
+				"7:27: folded a constant expression into -2",
+				"7:31: folded a constant expression into 2",
+				"7:42: folded a constant expression into 2.5",
+			},
+		},
+		"dead branches": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					if 1 < 2 then x := 1 else x := 2;
+					if false then x := 3;
+					while not true do x := 4;
+					repeat x := x + 1 until true;
+					if true then x := 5
+				end.
+			`,
+			wantChanges: []string{
+				"5:6: removed the ELSE branch of an IF TRUE",
+				"5:11: folded a constant expression into TRUE",
+				"6:6: removed the THEN branch of an IF FALSE",
+				"7:6: removed a WHILE FALSE",
+				"7:12: folded a constant expression into FALSE",
+				"9:6: removed the condition of an IF TRUE",
+			},
+		},
+		"unused declarations": {
+			givenSource: `
+				program Main;
+					var x, unused : integer;
+					procedure Helper;
+					begin
+					end;
+					procedure Caller;
+					begin
+						Helper
+					end;
+					function Double(a : integer) : integer;
+					begin
+						Double := a * 2
+					end;
+				begin
+					x := Double(1)
+				end.
+			`,
+			wantChanges: []string{
+				"3:13: removed unused variable UNUSED",
+				"4:16: removed unused procedure HELPER",
+				"7:16: removed unused procedure CALLER",
+			},
+			wantOutput: "3:13: warning UnusedVariable: UNUSED is declared but never used, remove it\n" +
+				"7:16: warning UnusedRoutine: CALLER is declared but never used, remove it\n",
+		},
+		"unused variable declaring used enumerated values": {
+			givenSource: `
+				program Main;
+					var c : (Red, Green);
+						d : (Blue, Yellow);
+						x : integer;
+				begin
+					x := ord(Green);
+					writeln(x)
+				end.
+			`,
+			wantChanges: []string{
+				"4:7: removed unused variable D",
+			},
+			wantOutput: "3:10: warning UnusedVariable: C is declared but never used, remove it\n" +
+				"4:7: warning UnusedVariable: D is declared but never used, remove it\n",
+		},
+		"nothing to do": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					readln(x);
+					writeln(x + 1)
+				end.
+			`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root, err := parse(tc.givenSource)
+			assert.NoError(t, err)
+			w := bytes.NewBuffer(nil)
+			changes, err := NewOptimizer(w).Optimize(root)
+			assert.NoError(t, err)
+
+			var got []string
+			for _, change := range changes {
+				got = append(got, change.String())
+			}
+			assert.Equal(t, tc.wantChanges, got)
+			wantOutput := tc.wantOutput
+			if len(got) > 0 {
+				wantOutput += strings.Join(got, "\n") + "\n"
+			}
+			assert.Equal(t, wantOutput, w.String())
+
+			// the optimized tree is still valid and has nothing left to change.
+			changes, err = NewOptimizer(ioutil.Discard).Optimize(root)
+			assert.NoError(t, err)
+			assert.Empty(t, changes)
+		})
+	}
+}
+
+func TestOptimizer_Interpret(t *testing.T) {
+	for name, tc := range interpretTests {
+		t.Run(name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			it := NewInterpreter(strings.NewReader(tc.givenInput), output)
+			it.optimizer = NewOptimizer(ioutil.Discard)
+			assert.NoError(t, it.Interpret(tc.givenProgram))
+			assert.Equal(t, tc.wantOutput, output.String())
+
+			output.Reset()
+			vm := NewVM(strings.NewReader(tc.givenInput), output)
+			vm.optimizer = NewOptimizer(ioutil.Discard)
+			assert.NoError(t, vm.Interpret(tc.givenProgram))
+			assert.Equal(t, tc.wantOutput, output.String())
+		})
+	}
+}
@@ -0,0 +1,1730 @@
+package pascal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Visitor interface {
+	Before(node ASTNode) (bool, error)
+	After(node ASTNode) error
+}
+
+type ASTNode interface{}
+
+var (
+	_ ASTNode = (*ProgramNode)(nil)
+	_ ASTNode = (*UnitNode)(nil)
+	_ ASTNode = (*BlockNode)(nil)
+	_ ASTNode = (*ProcedureDeclNode)(nil)
+	_ ASTNode = (*FunctionDeclNode)(nil)
+	_ ASTNode = (*ParamNode)(nil)
+	_ ASTNode = (*VarDeclNode)(nil)
+	_ ASTNode = (*ConstDeclNode)(nil)
+	_ ASTNode = (*TypeDeclNode)(nil)
+	_ ASTNode = (*ArrayTypeNode)(nil)
+	_ ASTNode = (*RecordTypeNode)(nil)
+	_ ASTNode = (*EnumTypeNode)(nil)
+	_ ASTNode = (*CompoundNode)(nil)
+	_ ASTNode = (*AssignNode)(nil)
+	_ ASTNode = (*ProcedureCallNode)(nil)
+	_ ASTNode = (*FunctionCallNode)(nil)
+	_ ASTNode = (*VarNode)(nil)
+	_ ASTNode = (*IndexNode)(nil)
+	_ ASTNode = (*FieldNode)(nil)
+	_ ASTNode = (*TypNode)(nil)
+	_ ASTNode = (*NumNode)(nil)
+	_ ASTNode = (*UnaryOpNode)(nil)
+	_ ASTNode = (*BinOpNode)(nil)
+	_ ASTNode = (*NoopNode)(nil)
+	_ ASTNode = (*BoolNode)(nil)
+	_ ASTNode = (*StrNode)(nil)
+	_ ASTNode = (*IfNode)(nil)
+	_ ASTNode = (*WhileNode)(nil)
+	_ ASTNode = (*RepeatNode)(nil)
+	_ ASTNode = (*ForNode)(nil)
+)
+
+type ProgramNode struct {
+	name string
+	// uses are the names of the units in the USES clause.
+	uses  []*Token
+	block *BlockNode
+	// units are the units the program uses, directly or not, annotated by
+	// the SemanticAnalyzer in the order they're initialized: a unit comes
+	// after the units it uses.
+	units []*UnitNode
+}
+
+// UnitNode is a unit, a source of its own whose INTERFACE declares what the
+// programs and units using it see. Its block holds the declarations of the
+// INTERFACE, the first public ones, then those of the IMPLEMENTATION and
+// the statements initializing the unit, if any.
+type UnitNode struct {
+	token *Token
+	name  string
+	// file is the file the unit is read from.
+	file string
+	uses []*Token
+	// headings are the procedures and functions of the INTERFACE, without
+	// a block, the IMPLEMENTATION declares them in full.
+	headings []ASTNode
+	public   int
+	block    *BlockNode
+	// level is the nesting level of the unit's declarations, annotated by
+	// the SemanticAnalyzer: the units are nested in the order they're
+	// initialized and the program in the last one.
+	level int
+	// exports are the symbols of the INTERFACE, annotated by the
+	// SemanticAnalyzer.
+	exports []Symbol
+}
+
+func NewProcedureDeclNode(token *Token, params []*ParamNode, block *BlockNode) *ProcedureDeclNode {
+	return &ProcedureDeclNode{
+		token:  token,
+		name:   token.Value,
+		params: params,
+		block:  block,
+	}
+}
+
+// ProcedureDeclNode declares a procedure, token is its name.
+type ProcedureDeclNode struct {
+	token  *Token
+	name   string
+	params []*ParamNode
+	block  *BlockNode
+}
+
+func NewFunctionDeclNode(token *Token, params []*ParamNode, returnType *TypNode, block *BlockNode) *FunctionDeclNode {
+	return &FunctionDeclNode{
+		token:      token,
+		name:       token.Value,
+		params:     params,
+		returnType: returnType,
+		block:      block,
+	}
+}
+
+// FunctionDeclNode declares a function, token is its name.
+type FunctionDeclNode struct {
+	token      *Token
+	name       string
+	params     []*ParamNode
+	returnType *TypNode
+	block      *BlockNode
+}
+
+func NewParamNode(varNode *VarNode, typNode *TypNode) *ParamNode {
+	return &ParamNode{
+		varNode: varNode,
+		typNode: typNode,
+	}
+}
+
+type ParamNode struct {
+	varNode *VarNode
+	typNode *TypNode
+	// byReference marks a VAR parameter.
+	byReference bool
+}
+
+type BlockNode struct {
+	declarations []ASTNode
+	compoundStmt *CompoundNode
+}
+
+func NewVarDeclNode(varNode *VarNode, typNode ASTNode) *VarDeclNode {
+	return &VarDeclNode{varNode: varNode, typNode: typNode}
+}
+
+// VarDeclNode declares a variable, typNode is a TypNode naming its type,
+// an ArrayTypeNode or a RecordTypeNode.
+type VarDeclNode struct {
+	varNode *VarNode
+	typNode ASTNode
+}
+
+func NewTypeDeclNode(token *Token, typNode ASTNode) *TypeDeclNode {
+	return &TypeDeclNode{
+		token:   token,
+		name:    token.Value,
+		typNode: typNode,
+	}
+}
+
+// ConstDeclNode is name = value in a CONST section, the value is evaluated
+// by the SemanticAnalyzer.
+type ConstDeclNode struct {
+	token *Token
+	name  string
+	value ASTNode
+}
+
+type TypeDeclNode struct {
+	token   *Token
+	name    string
+	typNode ASTNode
+	// typ is the type declared, annotated by the SemanticAnalyzer, the one
+	// named of an alias.
+	typ Symbol
+}
+
+// ArrayTypeNode is ARRAY[low..high] OF elemType, the bounds are constants.
+type ArrayTypeNode struct {
+	token    *Token
+	low      ASTNode
+	high     ASTNode
+	elemType ASTNode
+}
+
+type RecordTypeNode struct {
+	token  *Token
+	fields []*VarDeclNode
+}
+
+// EnumTypeNode is (value, ...), token is the LPAREN.
+type EnumTypeNode struct {
+	token  *Token
+	values []*Token
+}
+
+func NewTypNode(token *Token) *TypNode {
+	return &TypNode{
+		token: token,
+		value: token.Value,
+	}
+}
+
+type TypNode struct {
+	token *Token
+	value string
+}
+
+type CompoundNode struct {
+	children []ASTNode
+}
+
+// AssignNode stores right into left, a VarNode, an IndexNode or a FieldNode.
+type AssignNode struct {
+	token *Token
+	left  ASTNode
+	right ASTNode
+}
+
+func NewProcedureCallNode(token *Token, actualParams []ASTNode) *ProcedureCallNode {
+	return &ProcedureCallNode{
+		token:        token,
+		name:         token.Value,
+		actualParams: actualParams,
+	}
+}
+
+type ProcedureCallNode struct {
+	token        *Token
+	name         string
+	actualParams []ASTNode
+	procSymbol   *ProcedureSymbol
+	builtin      *BuiltinProcedureSymbol
+}
+
+func NewFunctionCallNode(token *Token, actualParams []ASTNode) *FunctionCallNode {
+	return &FunctionCallNode{
+		token:        token,
+		name:         token.Value,
+		actualParams: actualParams,
+	}
+}
+
+type FunctionCallNode struct {
+	token        *Token
+	name         string
+	actualParams []ASTNode
+	funcSymbol   *FunctionSymbol
+	builtin      *BuiltinFunctionSymbol
+	typ          Symbol
+}
+
+func NewVarNode(token *Token) *VarNode {
+	return &VarNode{
+		token: token,
+		value: token.Value,
+	}
+}
+
+type VarNode struct {
+	token *Token
+	value string
+	typ   Symbol
+	// level is the nesting level of the activation record holding the
+	// variable, annotated by the SemanticAnalyzer.
+	level int
+	// constant is set if the node names a constant rather than a variable,
+	// annotated by the SemanticAnalyzer.
+	constant *ConstSymbol
+}
+
+// IndexNode is base[index], token is the LBRACKET.
+type IndexNode struct {
+	token *Token
+	base  ASTNode
+	index ASTNode
+	typ   Symbol
+}
+
+// FieldNode is base.field, token is the field's ID.
+type FieldNode struct {
+	token *Token
+	base  ASTNode
+	field string
+	typ   Symbol
+	// index is the position of the field in its record, annotated by the
+	// SemanticAnalyzer.
+	index int
+}
+
+func NewIntegerNumNode(token *Token) *NumNode {
+	intValue, err := strconv.Atoi(token.Value)
+	if err != nil {
+		panic(fmt.Sprintf("invalid integer token: %s", token))
+	}
+	return &NumNode{
+		token:    token,
+		intValue: intValue,
+	}
+}
+
+func NewRealNumNode(token *Token) *NumNode {
+	floatValue, err := strconv.ParseFloat(token.Value, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid real token: %s", token))
+	}
+	return &NumNode{
+		token:      token,
+		floatValue: floatValue,
+	}
+}
+
+type NumNode struct {
+	token      *Token
+	intValue   int
+	floatValue float64
+	typ        Symbol
+}
+
+func NewBoolNode(token *Token) *BoolNode {
+	return &BoolNode{
+		token: token,
+		value: token.Kind == True,
+	}
+}
+
+type BoolNode struct {
+	token *Token
+	value bool
+	typ   Symbol
+}
+
+// NewStrNode creates the node of a string literal, its value is the text
+// between the quotes with doubled quotes undoubled.
+func NewStrNode(token *Token) *StrNode {
+	text := token.Value[1 : len(token.Value)-1]
+	return &StrNode{
+		token: token,
+		value: strings.ReplaceAll(text, "''", "'"),
+	}
+}
+
+type StrNode struct {
+	token *Token
+	value string
+	typ   Symbol
+}
+
+type UnaryOpNode struct {
+	token   *Token
+	operand ASTNode
+	op      TokenKind
+	typ     Symbol
+}
+
+func NewBinOpNode(token *Token, left, right ASTNode) *BinOpNode {
+	return &BinOpNode{
+		token: token,
+		left:  left,
+		right: right,
+		op:    token.Kind,
+	}
+}
+
+type BinOpNode struct {
+	token *Token
+	left  ASTNode
+	right ASTNode
+	op    TokenKind
+	typ   Symbol
+}
+
+func NewIfNode(token *Token, condition, thenStmt, elseStmt ASTNode) *IfNode {
+	return &IfNode{
+		token:     token,
+		condition: condition,
+		thenStmt:  thenStmt,
+		elseStmt:  elseStmt,
+	}
+}
+
+type IfNode struct {
+	token     *Token
+	condition ASTNode
+	thenStmt  ASTNode
+	elseStmt  ASTNode
+}
+
+func NewWhileNode(token *Token, condition, body ASTNode) *WhileNode {
+	return &WhileNode{
+		token:     token,
+		condition: condition,
+		body:      body,
+	}
+}
+
+type WhileNode struct {
+	token     *Token
+	condition ASTNode
+	body      ASTNode
+}
+
+func NewRepeatNode(token *Token, children []ASTNode, condition ASTNode) *RepeatNode {
+	return &RepeatNode{
+		token:     token,
+		children:  children,
+		condition: condition,
+	}
+}
+
+type RepeatNode struct {
+	token     *Token
+	children  []ASTNode
+	condition ASTNode
+}
+
+func NewForNode(token *Token, varNode *VarNode, start, end ASTNode, downto bool, body ASTNode) *ForNode {
+	return &ForNode{
+		token:   token,
+		varNode: varNode,
+		start:   start,
+		end:     end,
+		downto:  downto,
+		body:    body,
+	}
+}
+
+type ForNode struct {
+	token   *Token
+	varNode *VarNode
+	start   ASTNode
+	end     ASTNode
+	downto  bool
+	body    ASTNode
+}
+
+type NoopNode struct{}
+
+var noop = &NoopNode{}
+
+func NewParser(lexer *Lexer) (parser *Parser, err error) {
+	parser = &Parser{lexer: lexer}
+	parser.advance()
+	return
+}
+
+// Parser implements the following Pascal CFG:
+//
+// program : PROGRAM variable SEMI uses_clause? block DOT
+// uses_clause : USES ID (COMMA ID)* SEMI
+// block : declarations compound_statement
+// declarations: (declaration)*
+// declaration: CONST (const_declaration SEMI)+
+//
+//	| TYPE (type_declaration SEMI)+
+//	| VAR (variable_declaration SEMI)+
+//	| procedure_declaration
+//	| function_declaration
+//	| empty
+//
+// const_declaration : ID EQUAL expr
+// type_declaration : ID EQUAL type_spec
+// procedure_declaration : PROCEDURE ID (LPAREN formal_parameter_list RPAREN)? SEMI block SEMI
+// function_declaration : FUNCTION ID (LPAREN formal_parameter_list RPAREN)? COLON simple_type SEMI block SEMI
+// variable_declaration : ID (COMMA ID)* COLON type_spec
+// formal_parameter_list: formal_parameters
+//
+//	| formal_parameters SEMI formal_parameter_list
+//
+// formal_parameters: VAR? ID (COMMA ID)* COLON simple_type
+// type_spec : simple_type | array_type | record_type | enum_type
+// simple_type : INTEGER | REAL | BOOLEAN | STRING | CHAR | ID
+// array_type : ARRAY LBRACKET constant DOTDOT constant RBRACKET OF type_spec
+// record_type : RECORD (variable_declaration SEMI)* variable_declaration? END
+// enum_type : LPAREN ID (COMMA ID)* RPAREN
+// constant : MINUS? (INTEGER_CONST | ID)
+// compound_statement : BEGIN statement_list END
+// statement_list : statement
+//
+//	| statement SEMI statement_list
+//
+// statement : compound_statement
+//
+//	| procedure_call_statement
+//	| assignment_statement
+//	| if_statement
+//	| while_statement
+//	| repeat_statement
+//	| for_statement
+//	| empty
+//
+// procedure_call_statement : ID actual_parameters?
+// actual_parameters : LPAREN (expr (COMMA expr)*)? RPAREN
+// assignment_statement : variable_access ASSIGN expr
+// if_statement : IF expr THEN statement (ELSE statement)?
+// while_statement : WHILE expr DO statement
+// repeat_statement : REPEAT statement_list UNTIL expr
+// for_statement : FOR variable ASSIGN expr (TO | DOWNTO) expr DO statement
+// empty :
+// expr: simple_expr ((EQUAL | NOT_EQUAL | LESS | LESS_EQUAL | GREATER | GREATER_EQUAL) simple_expr)?
+// simple_expr: term ((PLUS | MINUS | OR) term)*
+// term: factor ((MUL | INTEGER_DIV | FLOAT_DIV | AND) factor)*
+// factor : PLUS factor
+//
+//	| MINUS factor
+//	| NOT factor
+//	| INTEGER_CONST
+//	| REAL_CONST
+//	| STRING_CONST
+//	| TRUE
+//	| FALSE
+//	| LPAREN expr RPAREN
+//	| function_call
+//	| variable_access
+//
+// function_call : ID actual_parameters
+// variable_access : variable (LBRACKET expr RBRACKET | DOT ID)*
+// variable: ID
+//
+// A function is always called with its actual_parameters, even without any:
+// a bare ID is a variable_access, which the SemanticAnalyzer rejects when it
+// names a function.
+//
+// A unit, parsed by ParseUnit, is:
+//
+// unit : UNIT variable SEMI INTERFACE uses_clause? interface_declarations
+//
+//	IMPLEMENTATION declarations (compound_statement | END) DOT
+//
+// interface_declarations : (CONST (const_declaration SEMI)+
+//
+//	| TYPE (type_declaration SEMI)+
+//	| VAR (variable_declaration SEMI)+
+//	| procedure_heading
+//	| function_heading)*
+//
+// On errors the Parser recovers at statement and declaration boundaries by
+// skipping tokens (panic mode), Parse returns all of them as an ErrorList.
+type Parser struct {
+	lexer     *Lexer
+	currToken *Token
+	errors    ErrorList
+}
+
+func (p *Parser) Parse() (node ASTNode, err error) {
+	return p.end(p.program())
+}
+
+// ParseUnit parses the source of a unit rather than a program.
+func (p *Parser) ParseUnit() (node ASTNode, err error) {
+	return p.end(p.unit())
+}
+
+// end records err, or an error if the source goes on after node, node is
+// nil if there are errors.
+func (p *Parser) end(node ASTNode, err error) (ASTNode, error) {
+	if err != nil {
+		p.errors = append(p.errors, err)
+	} else if p.currToken.Kind != EOF {
+		p.errors = append(p.errors, p.error(ErrorCodeUnexpectedToken, expected(EOF)))
+	}
+	if err = p.errors.Err(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// program : PROGRAM variable SEMI uses_clause? block DOT
+func (p *Parser) program() (node *ProgramNode, err error) {
+	if err = p.eat(Program); err != nil {
+		return
+	}
+	var varNode *VarNode
+	if varNode, err = p.variable(); err != nil {
+		return
+	}
+	if err = p.eat(Semi); err != nil {
+		return
+	}
+	var uses []*Token
+	if uses, err = p.usesClause(); err != nil {
+		return
+	}
+	var blockNode *BlockNode
+	if blockNode, err = p.block(); err != nil {
+		return
+	}
+	if err = p.eat(Dot); err != nil {
+		return
+	}
+	node = &ProgramNode{
+		name:  varNode.value,
+		uses:  uses,
+		block: blockNode,
+	}
+	return
+}
+
+// uses_clause : USES ID (COMMA ID)* SEMI
+func (p *Parser) usesClause() (names []*Token, err error) {
+	if p.currToken.Kind != Uses {
+		return
+	}
+	if err = p.eat(Uses); err != nil {
+		return
+	}
+	for first := true; first || p.currToken.Kind == Comma; first = false {
+		if !first {
+			if err = p.eat(Comma); err != nil {
+				return
+			}
+		}
+		names = append(names, p.currToken)
+		if err = p.eat(ID); err != nil {
+			return
+		}
+	}
+	err = p.eat(Semi)
+	return
+}
+
+// unit : UNIT variable SEMI INTERFACE uses_clause? interface_declarations
+//
+//	IMPLEMENTATION declarations (compound_statement | END) DOT
+func (p *Parser) unit() (node *UnitNode, err error) {
+	if err = p.eat(Unit); err != nil {
+		return
+	}
+	node = &UnitNode{token: p.currToken, name: p.currToken.Value, block: &BlockNode{}}
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	if err = p.eat(Semi); err != nil {
+		return
+	}
+	if err = p.eat(Interface); err != nil {
+		return
+	}
+	if node.uses, err = p.usesClause(); err != nil {
+		return
+	}
+	if err = p.interfaceDeclarations(node); err != nil {
+		return
+	}
+	if err = p.eat(Implementation); err != nil {
+		return
+	}
+	var declarations []ASTNode
+	if declarations, err = p.declarations(); err != nil {
+		return
+	}
+	node.block.declarations = append(node.block.declarations, declarations...)
+	// a unit without statements to initialize it ends with END alone.
+	if p.currToken.Kind == End {
+		node.block.compoundStmt = &CompoundNode{}
+		err = p.eat(End)
+	} else {
+		node.block.compoundStmt, err = p.compoundStmt()
+	}
+	if err != nil {
+		return
+	}
+	err = p.eat(Dot)
+	return
+}
+
+// interface_declarations : (CONST (const_declaration SEMI)+
+//
+//	| TYPE (type_declaration SEMI)+
+//	| VAR (variable_declaration SEMI)+
+//	| procedure_heading
+//	| function_heading)*
+func (p *Parser) interfaceDeclarations(unit *UnitNode) (err error) {
+	for {
+		switch p.currToken.Kind {
+		case Const, Type, Var:
+			var declNodes []ASTNode
+			if declNodes, err = p.declaration(); err != nil {
+				p.recover(err, Const, Type, Var, Procedure, Function, Implementation)
+				continue
+			}
+			unit.block.declarations = append(unit.block.declarations, declNodes...)
+		case Procedure:
+			heading := &ProcedureDeclNode{}
+			if err = p.procedureHeading(heading); err != nil {
+				p.recoverHeading(err)
+				continue
+			}
+			unit.headings = append(unit.headings, heading)
+		case Function:
+			heading := &FunctionDeclNode{}
+			if err = p.functionHeading(heading); err != nil {
+				p.recoverHeading(err)
+				continue
+			}
+			unit.headings = append(unit.headings, heading)
+		default:
+			unit.public = len(unit.block.declarations)
+			return nil
+		}
+	}
+}
+
+// block : declarations compound_statement
+func (p *Parser) block() (node *BlockNode, err error) {
+	declarations, err := p.declarations()
+	if err != nil {
+		return
+	}
+	compoundStmt, err := p.compoundStmt()
+	if err != nil {
+		return
+	}
+	node = &BlockNode{
+		declarations: declarations,
+		compoundStmt: compoundStmt,
+	}
+	return
+}
+
+// declarations: (declaration)*
+func (p *Parser) declarations() (nodes []ASTNode, err error) {
+	for p.currToken.Kind == Const || p.currToken.Kind == Type || p.currToken.Kind == Var ||
+		p.currToken.Kind == Procedure || p.currToken.Kind == Function {
+		var declNodes []ASTNode
+		if declNodes, err = p.declaration(); err != nil {
+			p.recover(err, Const, Type, Var, Procedure, Function, Begin)
+			continue
+		}
+		nodes = append(nodes, declNodes...)
+	}
+	return nodes, nil
+}
+
+// declaration: CONST (const_declaration SEMI)+
+//
+//	| TYPE (type_declaration SEMI)+
+//	| VAR (variable_declaration SEMI)+
+//	| procedure_declaration
+//	| function_declaration
+//	| empty
+func (p *Parser) declaration() (nodes []ASTNode, err error) {
+	switch p.currToken.Kind {
+	case Const:
+		if err = p.eat(Const); err != nil {
+			return
+		}
+
+		for first := true; first || p.currToken.Kind == ID; first = false {
+			var constDeclNode *ConstDeclNode
+			if constDeclNode, err = p.constDeclaration(); err == nil {
+				err = p.eat(Semi)
+			}
+			if err != nil {
+				// skip the broken constant declaration only.
+				p.recover(err, Semi, Const, Type, Var, Procedure, Function, Begin)
+				if p.currToken.Kind == Semi {
+					p.advance()
+				}
+				continue
+			}
+			nodes = append(nodes, constDeclNode)
+		}
+		err = nil
+	case Type:
+		if err = p.eat(Type); err != nil {
+			return
+		}
+
+		for first := true; first || p.currToken.Kind == ID; first = false {
+			var typeDeclNode *TypeDeclNode
+			if typeDeclNode, err = p.typeDeclaration(); err == nil {
+				err = p.eat(Semi)
+			}
+			if err != nil {
+				// skip the broken type declaration only.
+				p.recover(err, Semi, Const, Type, Var, Procedure, Function, Begin)
+				if p.currToken.Kind == Semi {
+					p.advance()
+				}
+				continue
+			}
+			nodes = append(nodes, typeDeclNode)
+		}
+		err = nil
+	case Var:
+		if err = p.eat(Var); err != nil {
+			return
+		}
+
+		for first := true; first || p.currToken.Kind == ID; first = false {
+			var varDeclNodes []*VarDeclNode
+			if varDeclNodes, err = p.variableDeclaration(); err == nil {
+				err = p.eat(Semi)
+			}
+			if err != nil {
+				// skip the broken variable declaration only.
+				p.recover(err, Semi, Const, Type, Var, Procedure, Function, Begin)
+				if p.currToken.Kind == Semi {
+					p.advance()
+				}
+				continue
+			}
+			for _, varDeclNode := range varDeclNodes {
+				nodes = append(nodes, varDeclNode)
+			}
+		}
+		err = nil
+	case Procedure:
+		var procedureDeclNode *ProcedureDeclNode
+		if procedureDeclNode, err = p.procedureDeclaration(); err != nil {
+			return
+		}
+		nodes = append(nodes, procedureDeclNode)
+	case Function:
+		var functionDeclNode *FunctionDeclNode
+		if functionDeclNode, err = p.functionDeclaration(); err != nil {
+			return
+		}
+		nodes = append(nodes, functionDeclNode)
+	}
+	return
+}
+
+// procedure_declaration : PROCEDURE ID (LPAREN formal_parameter_list RPAREN)? SEMI block SEMI
+func (p *Parser) procedureDeclaration() (node *ProcedureDeclNode, err error) {
+	procedureDeclNode := &ProcedureDeclNode{}
+	if err = p.procedureHeading(procedureDeclNode); err != nil {
+		p.recoverHeading(err)
+	}
+	if procedureDeclNode.block, err = p.block(); err != nil {
+		return
+	}
+	if err = p.eat(Semi); err != nil {
+		return
+	}
+	node = procedureDeclNode
+	return
+}
+
+// procedure_heading : PROCEDURE ID (LPAREN formal_parameter_list RPAREN)? SEMI
+func (p *Parser) procedureHeading(procedureDeclNode *ProcedureDeclNode) (err error) {
+	if err = p.eat(Procedure); err != nil {
+		return
+	}
+	procedureDeclNode.token, procedureDeclNode.name = p.currToken, p.currToken.Value
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	if p.currToken.Kind == LParen {
+		if err = p.eat(LParen); err != nil {
+			return
+		}
+		if procedureDeclNode.params, err = p.formalParameterList(); err != nil {
+			return
+		}
+		if err = p.eat(RParen); err != nil {
+			return
+		}
+	}
+	err = p.eat(Semi)
+	return
+}
+
+// function_declaration : FUNCTION ID (LPAREN formal_parameter_list RPAREN)? COLON simple_type SEMI block SEMI
+func (p *Parser) functionDeclaration() (node *FunctionDeclNode, err error) {
+	functionDeclNode := &FunctionDeclNode{}
+	if err = p.functionHeading(functionDeclNode); err != nil {
+		p.recoverHeading(err)
+	}
+	if functionDeclNode.block, err = p.block(); err != nil {
+		return
+	}
+	if err = p.eat(Semi); err != nil {
+		return
+	}
+	node = functionDeclNode
+	return
+}
+
+// function_heading : FUNCTION ID (LPAREN formal_parameter_list RPAREN)? COLON simple_type SEMI
+func (p *Parser) functionHeading(functionDeclNode *FunctionDeclNode) (err error) {
+	if err = p.eat(Function); err != nil {
+		return
+	}
+	functionDeclNode.token, functionDeclNode.name = p.currToken, p.currToken.Value
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	if p.currToken.Kind == LParen {
+		if err = p.eat(LParen); err != nil {
+			return
+		}
+		if functionDeclNode.params, err = p.formalParameterList(); err != nil {
+			return
+		}
+		if err = p.eat(RParen); err != nil {
+			return
+		}
+	}
+	if err = p.eat(Colon); err != nil {
+		return
+	}
+	if functionDeclNode.returnType, err = p.simpleType(); err != nil {
+		return
+	}
+	err = p.eat(Semi)
+	return
+}
+
+// recoverHeading skips a broken procedure or function heading, its block
+// is then parsed as usual.
+func (p *Parser) recoverHeading(err error) {
+	p.recover(err, Semi, Const, Type, Var, Procedure, Function, Begin)
+	if p.currToken.Kind == Semi {
+		p.advance()
+	}
+}
+
+// const_declaration : ID EQUAL expr
+func (p *Parser) constDeclaration() (node *ConstDeclNode, err error) {
+	token := p.currToken
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	if err = p.eat(Equal); err != nil {
+		return
+	}
+	value, err := p.expr()
+	if err != nil {
+		return
+	}
+	return &ConstDeclNode{token: token, name: token.Value, value: value}, nil
+}
+
+// type_declaration : ID EQUAL type_spec
+func (p *Parser) typeDeclaration() (node *TypeDeclNode, err error) {
+	token := p.currToken
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	if err = p.eat(Equal); err != nil {
+		return
+	}
+	typNode, err := p.typeSpec()
+	if err != nil {
+		return
+	}
+	return NewTypeDeclNode(token, typNode), nil
+}
+
+// variable_declaration : ID (COMMA ID)* COLON type_spec
+func (p *Parser) variableDeclaration() (nodes []*VarDeclNode, err error) {
+	varNodes := []*VarNode{NewVarNode(p.currToken)}
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	for p.currToken.Kind == Comma {
+		if err = p.eat(Comma); err != nil {
+			return
+		}
+		varNodes = append(varNodes, NewVarNode(p.currToken))
+		if err = p.eat(ID); err != nil {
+			return
+		}
+	}
+	if err = p.eat(Colon); err != nil {
+		return
+	}
+
+	var typNode ASTNode
+	if typNode, err = p.typeSpec(); err != nil {
+		return
+	}
+
+	nodes = make([]*VarDeclNode, len(varNodes))
+	for i, varNode := range varNodes {
+		nodes[i] = NewVarDeclNode(varNode, typNode)
+	}
+	return
+}
+
+// formal_parameter_list: formal_parameters
+//
+//	| formal_parameters SEMI formal_parameter_list
+func (p *Parser) formalParameterList() (nodes []*ParamNode, err error) {
+	paramNodes, err := p.formalParameters()
+	if err != nil {
+		return
+	}
+	nodes = append(nodes, paramNodes...)
+	for p.currToken.Kind == Semi {
+		if err = p.eat(Semi); err != nil {
+			return
+		}
+		if paramNodes, err = p.formalParameterList(); err != nil {
+			return
+		}
+		nodes = append(nodes, paramNodes...)
+	}
+	return
+}
+
+// formal_parameters: VAR? ID (COMMA ID)* COLON simple_type
+func (p *Parser) formalParameters() (nodes []*ParamNode, err error) {
+	byReference := p.currToken.Kind == Var
+	if byReference {
+		if err = p.eat(Var); err != nil {
+			return
+		}
+	}
+	nodes = append(nodes, NewParamNode(NewVarNode(p.currToken), nil))
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	for p.currToken.Kind == Comma {
+		if err = p.eat(Comma); err != nil {
+			return
+		}
+		nodes = append(nodes, NewParamNode(NewVarNode(p.currToken), nil))
+		if err = p.eat(ID); err != nil {
+			return
+		}
+	}
+	if err = p.eat(Colon); err != nil {
+		return
+	}
+
+	var typNode *TypNode
+	if typNode, err = p.simpleType(); err != nil {
+		return
+	}
+	for _, node := range nodes {
+		node.typNode = typNode
+		node.byReference = byReference
+	}
+	return
+}
+
+// type_spec : simple_type | array_type | record_type | enum_type
+func (p *Parser) typeSpec() (node ASTNode, err error) {
+	switch p.currToken.Kind {
+	case Array:
+		return p.arrayType()
+	case Record:
+		return p.recordType()
+	case LParen:
+		return p.enumType()
+	}
+	return p.simpleType()
+}
+
+// simple_type : INTEGER | REAL | BOOLEAN | STRING | CHAR | ID
+func (p *Parser) simpleType() (node *TypNode, err error) {
+	switch p.currToken.Kind {
+	case Integer, Real, Boolean, String, Char, ID:
+	default:
+		err = p.error(ErrorCodeUnexpectedToken, expected(Integer, Real, Boolean, String, Char, ID, Array, Record, LParen))
+		return
+	}
+	node = NewTypNode(p.currToken)
+	err = p.eat(p.currToken.Kind)
+	return
+}
+
+// array_type : ARRAY LBRACKET constant DOTDOT constant RBRACKET OF type_spec
+func (p *Parser) arrayType() (node *ArrayTypeNode, err error) {
+	node = &ArrayTypeNode{token: p.currToken}
+	if err = p.eats(Array, LBracket); err != nil {
+		return
+	}
+	if node.low, err = p.constant(); err != nil {
+		return
+	}
+	if err = p.eat(DotDot); err != nil {
+		return
+	}
+	if node.high, err = p.constant(); err != nil {
+		return
+	}
+	if err = p.eats(RBracket, Of); err != nil {
+		return
+	}
+	node.elemType, err = p.typeSpec()
+	return
+}
+
+// record_type : RECORD (variable_declaration SEMI)* variable_declaration? END
+func (p *Parser) recordType() (node *RecordTypeNode, err error) {
+	node = &RecordTypeNode{token: p.currToken}
+	if err = p.eat(Record); err != nil {
+		return
+	}
+	for p.currToken.Kind == ID {
+		var fields []*VarDeclNode
+		if fields, err = p.variableDeclaration(); err != nil {
+			return
+		}
+		node.fields = append(node.fields, fields...)
+		if p.currToken.Kind != Semi {
+			break
+		}
+		if err = p.eat(Semi); err != nil {
+			return
+		}
+	}
+	err = p.eat(End)
+	return
+}
+
+// enum_type : LPAREN ID (COMMA ID)* RPAREN
+func (p *Parser) enumType() (node *EnumTypeNode, err error) {
+	node = &EnumTypeNode{token: p.currToken}
+	if err = p.eat(LParen); err != nil {
+		return
+	}
+	for {
+		node.values = append(node.values, p.currToken)
+		if err = p.eat(ID); err != nil {
+			return
+		}
+		if p.currToken.Kind != Comma {
+			break
+		}
+		if err = p.eat(Comma); err != nil {
+			return
+		}
+	}
+	err = p.eat(RParen)
+	return
+}
+
+// constant : MINUS? (INTEGER_CONST | ID)
+func (p *Parser) constant() (node ASTNode, err error) {
+	token := p.currToken
+	negative := token.Kind == Minus
+	if negative {
+		if err = p.eat(Minus); err != nil {
+			return
+		}
+	}
+	switch p.currToken.Kind {
+	case IntegerConst:
+		if node, err = p.factor(); err != nil {
+			return
+		}
+	case ID:
+		node = NewVarNode(p.currToken)
+		if err = p.eat(ID); err != nil {
+			return
+		}
+	default:
+		err = p.error(ErrorCodeUnexpectedToken, expected(IntegerConst, ID))
+		return
+	}
+	if negative {
+		node = &UnaryOpNode{op: Minus, token: token, operand: node}
+	}
+	return
+}
+
+// compound_statement : BEGIN statement_list END
+func (p *Parser) compoundStmt() (node *CompoundNode, err error) {
+	if err = p.eat(Begin); err != nil {
+		return
+	}
+	nodes, err := p.stmtList()
+	if err != nil {
+		return
+	}
+
+	if err = p.eat(End); err != nil {
+		return
+	}
+
+	node = &CompoundNode{children: nodes}
+	return
+}
+
+// statement_list : statement
+//
+//	| statement SEMI statement_list
+func (p *Parser) stmtList() (nodes []ASTNode, err error) {
+	for {
+		var node ASTNode
+		if node, err = p.stmt(); err != nil {
+			p.recover(err, Semi, End, Until)
+		} else {
+			nodes = append(nodes, node)
+		}
+
+		switch {
+		case p.currToken.Kind == Semi:
+			p.advance()
+		case startsStmt(p.currToken.Kind):
+			// most likely a forgotten SEMI, carry on with the next statement.
+			p.recover(p.error(ErrorCodeUnexpectedToken, expected(Semi)))
+		case p.currToken.Kind == End || p.currToken.Kind == Until || p.currToken.Kind == EOF:
+			return nodes, nil
+		default:
+			p.recover(p.error(ErrorCodeUnexpectedToken, expected(Semi)), Semi, End, Until)
+			if p.currToken.Kind == Semi {
+				p.advance()
+				continue
+			}
+			return nodes, nil
+		}
+	}
+}
+
+func startsStmt(kind TokenKind) bool {
+	switch kind {
+	case Begin, If, While, Repeat, For, ID:
+		return true
+	}
+	return false
+}
+
+// statement : compound_statement
+//
+//	| procedure_call_statement
+//	| assignment_statement
+//	| if_statement
+//	| while_statement
+//	| repeat_statement
+//	| for_statement
+//	| empty
+func (p *Parser) stmt() (node ASTNode, err error) {
+	switch p.currToken.Kind {
+	case Begin:
+		return p.compoundStmt()
+	case If:
+		return p.ifStmt()
+	case While:
+		return p.whileStmt()
+	case Repeat:
+		return p.repeatStmt()
+	case For:
+		return p.forStmt()
+	}
+
+	if p.currToken.Kind == ID {
+		switch p.peek().Kind {
+		case Assign, LBracket, Dot:
+			return p.assignStmt()
+		}
+		return p.procedureCallStmt()
+	}
+
+	node = p.empty()
+	return
+}
+
+// procedure_call_statement : ID actual_parameters?
+func (p *Parser) procedureCallStmt() (node ASTNode, err error) {
+	token := p.currToken
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	var arguments []ASTNode
+	if p.currToken.Kind == LParen {
+		if arguments, err = p.actualParameters(); err != nil {
+			return
+		}
+	}
+	return NewProcedureCallNode(token, arguments), nil
+}
+
+// function_call : ID actual_parameters
+func (p *Parser) functionCall() (node ASTNode, err error) {
+	token := p.currToken
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	arguments, err := p.actualParameters()
+	if err != nil {
+		return
+	}
+	return NewFunctionCallNode(token, arguments), nil
+}
+
+// actual_parameters : LPAREN (expr (COMMA expr)*)? RPAREN
+func (p *Parser) actualParameters() (nodes []ASTNode, err error) {
+	if err = p.eat(LParen); err != nil {
+		return
+	}
+	if p.currToken.Kind == RParen {
+		err = p.eat(RParen)
+		return
+	}
+
+	argument, err := p.expr()
+	if err != nil {
+		return
+	}
+	nodes = append(nodes, argument)
+	for p.currToken.Kind == Comma {
+		if err = p.eat(Comma); err != nil {
+			return
+		}
+
+		if argument, err = p.expr(); err != nil {
+			return
+		}
+
+		nodes = append(nodes, argument)
+	}
+
+	err = p.eat(RParen)
+	return
+}
+
+// assignment_statement : variable_access ASSIGN expr
+func (p *Parser) assignStmt() (node ASTNode, err error) {
+	id, err := p.variableAccess()
+	if err != nil {
+		return
+	}
+
+	token := p.currToken
+	if err = p.eat(Assign); err != nil {
+		return
+	}
+	expr, err := p.expr()
+	if err != nil {
+		return
+	}
+
+	node = &AssignNode{
+		token: token,
+		left:  id,
+		right: expr,
+	}
+	return
+}
+
+// if_statement : IF expr THEN statement (ELSE statement)?
+func (p *Parser) ifStmt() (node ASTNode, err error) {
+	token := p.currToken
+	if err = p.eat(If); err != nil {
+		return
+	}
+	condition, err := p.expr()
+	if err != nil {
+		return
+	}
+	if err = p.eat(Then); err != nil {
+		return
+	}
+	thenStmt, err := p.stmt()
+	if err != nil {
+		return
+	}
+	// NOTE: a dangling ELSE binds to the nearest IF.
+	var elseStmt ASTNode
+	if p.currToken.Kind == Else {
+		if err = p.eat(Else); err != nil {
+			return
+		}
+		if elseStmt, err = p.stmt(); err != nil {
+			return
+		}
+	}
+	return NewIfNode(token, condition, thenStmt, elseStmt), nil
+}
+
+// while_statement : WHILE expr DO statement
+func (p *Parser) whileStmt() (node ASTNode, err error) {
+	token := p.currToken
+	if err = p.eat(While); err != nil {
+		return
+	}
+	condition, err := p.expr()
+	if err != nil {
+		return
+	}
+	if err = p.eat(Do); err != nil {
+		return
+	}
+	body, err := p.stmt()
+	if err != nil {
+		return
+	}
+	return NewWhileNode(token, condition, body), nil
+}
+
+// repeat_statement : REPEAT statement_list UNTIL expr
+func (p *Parser) repeatStmt() (node ASTNode, err error) {
+	token := p.currToken
+	if err = p.eat(Repeat); err != nil {
+		return
+	}
+	children, err := p.stmtList()
+	if err != nil {
+		return
+	}
+	if err = p.eat(Until); err != nil {
+		return
+	}
+	condition, err := p.expr()
+	if err != nil {
+		return
+	}
+	return NewRepeatNode(token, children, condition), nil
+}
+
+// for_statement : FOR variable ASSIGN expr (TO | DOWNTO) expr DO statement
+func (p *Parser) forStmt() (node ASTNode, err error) {
+	token := p.currToken
+	if err = p.eat(For); err != nil {
+		return
+	}
+	varNode, err := p.variable()
+	if err != nil {
+		return
+	}
+	if err = p.eat(Assign); err != nil {
+		return
+	}
+	start, err := p.expr()
+	if err != nil {
+		return
+	}
+	downto := p.currToken.Kind == Downto
+	if downto {
+		err = p.eat(Downto)
+	} else {
+		err = p.eat(To)
+	}
+	if err != nil {
+		return
+	}
+	end, err := p.expr()
+	if err != nil {
+		return
+	}
+	if err = p.eat(Do); err != nil {
+		return
+	}
+	body, err := p.stmt()
+	if err != nil {
+		return
+	}
+	return NewForNode(token, varNode, start, end, downto, body), nil
+}
+
+func (p *Parser) empty() ASTNode {
+	return noop
+}
+
+// expr: simple_expr ((EQUAL | NOT_EQUAL | LESS | LESS_EQUAL | GREATER | GREATER_EQUAL) simple_expr)?
+func (p *Parser) expr() (node ASTNode, err error) {
+	if node, err = p.simpleExpr(); err != nil {
+		return
+	}
+	switch p.currToken.Kind {
+	case Equal, NotEqual, Less, LessEqual, Greater, GreaterEqual:
+		token := p.currToken
+		if err = p.eat(token.Kind); err != nil {
+			return
+		}
+		var right ASTNode
+		if right, err = p.simpleExpr(); err != nil {
+			return
+		}
+		node = NewBinOpNode(token, node, right)
+	}
+	return
+}
+
+// simple_expr : term ((PLUS | MINUS | OR) term)*
+func (p *Parser) simpleExpr() (node ASTNode, err error) {
+	if node, err = p.term(); err != nil {
+		return
+	}
+	for p.currToken.Kind == Plus || p.currToken.Kind == Minus || p.currToken.Kind == Or {
+		token := p.currToken
+		if err = p.eat(token.Kind); err != nil {
+			return
+		}
+		var term ASTNode
+		if term, err = p.term(); err != nil {
+			return
+		}
+		node = &BinOpNode{
+			left:  node,
+			right: term,
+			token: token,
+			op:    token.Kind,
+		}
+	}
+	return
+}
+
+// term: factor ((MUL | INTEGER_DIV | FLOAT_DIV | AND) factor)*
+func (p *Parser) term() (node ASTNode, err error) {
+	if node, err = p.factor(); err != nil {
+		return
+	}
+
+	for p.currToken.Kind == Mul || p.currToken.Kind == IntegerDiv || p.currToken.Kind == FloatDiv || p.currToken.Kind == And {
+		token := p.currToken
+		if err = p.eat(p.currToken.Kind); err != nil {
+			return
+		}
+		var factorNode ASTNode
+		if factorNode, err = p.factor(); err != nil {
+			return
+		}
+		node = &BinOpNode{
+			left:  node,
+			right: factorNode,
+			token: token,
+			op:    token.Kind,
+		}
+	}
+	return
+}
+
+// factor : PLUS factor
+//
+//	| MINUS factor
+//	| NOT factor
+//	| INTEGER_CONST
+//	| REAL_CONST
+//	| STRING_CONST
+//	| TRUE
+//	| FALSE
+//	| LPAREN expr RPAREN
+//	| function_call
+//	| variable_access
+func (p *Parser) factor() (node ASTNode, err error) {
+	token := p.currToken
+	switch token.Kind {
+	case Plus:
+		if err = p.eat(Plus); err != nil {
+			return
+		}
+		var operand ASTNode
+		if operand, err = p.factor(); err != nil {
+			return
+		}
+		node = &UnaryOpNode{op: Plus, token: token, operand: operand}
+		return
+	case Minus:
+		if err = p.eat(Minus); err != nil {
+			return
+		}
+		var operand ASTNode
+		if operand, err = p.factor(); err != nil {
+			return
+		}
+		node = &UnaryOpNode{op: Minus, token: token, operand: operand}
+		return
+	case Not:
+		if err = p.eat(Not); err != nil {
+			return
+		}
+		var operand ASTNode
+		if operand, err = p.factor(); err != nil {
+			return
+		}
+		node = &UnaryOpNode{op: Not, token: token, operand: operand}
+		return
+	case IntegerConst:
+		var iv int
+		if iv, err = strconv.Atoi(token.Value); err != nil {
+			err = p.error(ErrorCodeUnexpectedToken, "number out of range")
+			return
+		}
+		if err = p.eat(IntegerConst); err != nil {
+			return
+		}
+		node = &NumNode{token: token, intValue: iv}
+		return
+	case RealConst:
+		var fv float64
+		if fv, err = strconv.ParseFloat(token.Value, 64); err != nil {
+			err = p.error(ErrorCodeUnexpectedToken, "number out of range")
+			return
+		}
+		if err = p.eat(RealConst); err != nil {
+			return
+		}
+		node = &NumNode{token: token, floatValue: fv}
+		return
+	case StringConst:
+		if err = p.eat(StringConst); err != nil {
+			return
+		}
+		node = NewStrNode(token)
+		return
+	case True, False:
+		if err = p.eat(token.Kind); err != nil {
+			return
+		}
+		node = NewBoolNode(token)
+		return
+	case LParen:
+		if err = p.eat(LParen); err != nil {
+			return
+		}
+		if node, err = p.expr(); err != nil {
+			return
+		}
+		if err = p.eat(RParen); err != nil {
+			return
+		}
+		return
+	case ID:
+		if p.peek().Kind == LParen {
+			return p.functionCall()
+		}
+		return p.variableAccess()
+	default:
+		err = p.error(ErrorCodeUnexpectedToken, expected(Plus, Minus, Not, IntegerConst, RealConst, StringConst, True, False, LParen, ID))
+		return
+	}
+}
+
+// variable_access : variable (LBRACKET expr RBRACKET | DOT ID)*
+func (p *Parser) variableAccess() (node ASTNode, err error) {
+	if node, err = p.variable(); err != nil {
+		return
+	}
+	for {
+		token := p.currToken
+		switch token.Kind {
+		case LBracket:
+			if err = p.eat(LBracket); err != nil {
+				return
+			}
+			var index ASTNode
+			if index, err = p.expr(); err != nil {
+				return
+			}
+			if err = p.eat(RBracket); err != nil {
+				return
+			}
+			node = &IndexNode{token: token, base: node, index: index}
+		case Dot:
+			if err = p.eat(Dot); err != nil {
+				return
+			}
+			field := p.currToken
+			if err = p.eat(ID); err != nil {
+				return
+			}
+			node = &FieldNode{token: field, base: node, field: field.Value}
+		default:
+			return
+		}
+	}
+}
+
+// variable: ID
+func (p *Parser) variable() (node *VarNode, err error) {
+	token := p.currToken
+	if err = p.eat(ID); err != nil {
+		return
+	}
+	node = &VarNode{token: token, value: token.Value}
+	return
+}
+
+func (p *Parser) eat(kind TokenKind) (err error) {
+	if p.currToken.Kind != kind {
+		err = p.error(ErrorCodeUnexpectedToken, expected(kind))
+		return
+	}
+	p.advance()
+	return
+}
+
+// advance moves to the next token, lexer errors are recorded and the
+// offending runes skipped.
+func (p *Parser) advance() {
+	for {
+		token, err := p.lexer.GetNextToken()
+		if err == nil {
+			p.currToken = token
+			return
+		}
+		p.errors = append(p.errors, err)
+	}
+}
+
+// peek returns the token after the current one, lexer errors are left for
+// advance to record.
+func (p *Parser) peek() *Token {
+	lexCopy := *p.lexer
+	for {
+		if token, err := lexCopy.GetNextToken(); err == nil {
+			return token
+		}
+	}
+}
+
+// recover records err and, if kinds are given, skips tokens until one of
+// them or EOF.
+func (p *Parser) recover(err error, kinds ...TokenKind) {
+	p.errors = append(p.errors, err)
+	if len(kinds) == 0 {
+		return
+	}
+	for p.currToken.Kind != EOF {
+		for _, kind := range kinds {
+			if p.currToken.Kind == kind {
+				return
+			}
+		}
+		p.advance()
+	}
+}
+
+func (p *Parser) eats(kinds ...TokenKind) (err error) {
+	for _, kind := range kinds {
+		if err = p.eat(kind); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (p *Parser) error(code ErrorCode, suggestion string) error {
+	return Error{
+		Code:       code,
+		Module:     ModuleParser,
+		Message:    fmt.Sprintf("code=%s,token=%s", code, p.currToken),
+		Span:       p.currToken.Span(),
+		Suggestion: suggestion,
+	}
+}
@@ -0,0 +1,794 @@
+package pascal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Parse(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		wantNode    ASTNode
+	}{
+		"noop": {
+			givenSource: `
+				PROGRAM Part10AST;
+				BEGIN
+				END.`,
+			wantNode: &ProgramNode{
+				name: "PART10AST",
+				block: &BlockNode{
+					declarations: nil,
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{noop},
+					},
+				},
+			},
+		},
+		"default case": {
+			givenSource: `
+				PROGRAM Part10AST;
+				VAR
+					a, b : INTEGER;
+					y    : REAL;
+				BEGIN {Part10AST}
+					a := 2;
+					b := 10 * a + 10 * a DIV 4;
+					y := 20 / 7 + 3.14;
+				END.  {Part10AST}
+			`,
+			wantNode: &ProgramNode{
+				name: "PART10AST",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "A", 4, 6)),
+							typNode: NewTypNode(NewStaticToken(Integer, 4, 13)),
+						},
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "B", 4, 9)),
+							typNode: NewTypNode(NewStaticToken(Integer, 4, 13)),
+						},
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "Y", 5, 6)),
+							typNode: NewTypNode(NewStaticToken(Real, 5, 13)),
+						},
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							&AssignNode{
+								token: NewStaticToken(Assign, 7, 8),
+								left: NewVarNode(withComments(NewDynamicToken(ID, "A", 7, 6),
+									&Comment{Text: "{Part10AST}", Row: 6, Col: 11})),
+								right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "2", 7, 11)),
+							},
+							// b := 10 * a + 10 * a DIV 4;
+							&AssignNode{
+								token: NewStaticToken(Assign, 8, 8),
+								left:  NewVarNode(NewDynamicToken(ID, "B", 8, 6)),
+								right: &BinOpNode{
+									token: NewStaticToken(Plus, 8, 18),
+									left: &BinOpNode{
+										token: NewStaticToken(Mul, 8, 14),
+										left:  NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 8, 11)),
+										right: NewVarNode(NewDynamicToken(ID, "A", 8, 16)),
+										op:    Mul,
+									},
+									right: &BinOpNode{
+										token: NewStaticToken(IntegerDiv, 8, 27),
+										left: &BinOpNode{
+											token: NewStaticToken(Mul, 8, 23),
+											left:  NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 8, 20)),
+											right: NewVarNode(NewDynamicToken(ID, "A", 8, 25)),
+											op:    Mul,
+										},
+										right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "4", 8, 31)),
+										op:    IntegerDiv,
+									},
+									op: Plus,
+								},
+							},
+							&AssignNode{
+								token: NewStaticToken(Assign, 9, 8),
+								left:  NewVarNode(NewDynamicToken(ID, "Y", 9, 6)),
+								right: &BinOpNode{
+									token: NewStaticToken(Plus, 9, 18),
+									left: &BinOpNode{
+										token: NewStaticToken(FloatDiv, 9, 14),
+										left:  NewIntegerNumNode(NewDynamicToken(IntegerConst, "20", 9, 11)),
+										right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "7", 9, 16)),
+										op:    FloatDiv,
+									},
+									right: NewRealNumNode(NewDynamicToken(RealConst, "3.14", 9, 20)),
+									op:    Plus,
+								},
+							},
+							noop,
+						},
+					},
+				},
+			},
+		},
+		"part12 case": {
+			givenSource: `
+				PROGRAM Part12;
+				VAR
+					a : INTEGER;
+				PROCEDURE P1;
+				VAR
+					a : REAL;
+					k : INTEGER;
+					PROCEDURE P2;
+					VAR
+						a, z : INTEGER;
+					BEGIN {P2}
+						z := 777;
+					END;  {P2}
+				BEGIN {P1}
+				END;  {P1}
+				BEGIN {Part12}
+					a := 10;
+				END.  {Part12}
+			`,
+			wantNode: &ProgramNode{
+				name: "PART12",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "A", 4, 6)),
+							typNode: NewTypNode(NewStaticToken(Integer, 4, 10)),
+						},
+						&ProcedureDeclNode{
+							token: NewDynamicToken(ID, "P1", 5, 15),
+							name:  "P1",
+							block: &BlockNode{
+								declarations: []ASTNode{
+									&VarDeclNode{
+										varNode: NewVarNode(NewDynamicToken(ID, "A", 7, 6)),
+										typNode: NewTypNode(NewStaticToken(Real, 7, 10)),
+									},
+									&VarDeclNode{
+										varNode: NewVarNode(NewDynamicToken(ID, "K", 8, 6)),
+										typNode: NewTypNode(NewStaticToken(Integer, 8, 10)),
+									},
+									&ProcedureDeclNode{
+										token: NewDynamicToken(ID, "P2", 9, 16),
+										name:  "P2",
+										block: &BlockNode{
+											declarations: []ASTNode{
+												&VarDeclNode{
+													varNode: NewVarNode(NewDynamicToken(ID, "A", 11, 7)),
+													typNode: NewTypNode(NewStaticToken(Integer, 11, 14)),
+												},
+												&VarDeclNode{
+													varNode: NewVarNode(NewDynamicToken(ID, "Z", 11, 10)),
+													typNode: NewTypNode(NewStaticToken(Integer, 11, 14)),
+												},
+											},
+											compoundStmt: &CompoundNode{
+												children: []ASTNode{
+													&AssignNode{
+														token: NewStaticToken(Assign, 13, 9),
+														left: NewVarNode(withComments(NewDynamicToken(ID, "Z", 13, 7),
+															&Comment{Text: "{P2}", Row: 12, Col: 12})),
+														right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "777", 13, 12)),
+													},
+													noop,
+												},
+											},
+										},
+									},
+								},
+								compoundStmt: &CompoundNode{children: []ASTNode{noop}},
+							},
+						},
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							&AssignNode{
+								token: NewStaticToken(Assign, 18, 8),
+								left: NewVarNode(withComments(NewDynamicToken(ID, "A", 18, 6),
+									&Comment{Text: "{Part12}", Row: 17, Col: 11})),
+								right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 18, 11)),
+							},
+							noop,
+						},
+					},
+				},
+			},
+		},
+		"part 13: syntactically correct": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					x := y;
+				end.
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "X", 3, 10)),
+							typNode: NewTypNode(NewStaticToken(Integer, 3, 14)),
+						},
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							&AssignNode{
+								token: NewStaticToken(Assign, 5, 8),
+								left:  NewVarNode(NewDynamicToken(ID, "X", 5, 6)),
+								right: NewVarNode(NewDynamicToken(ID, "Y", 5, 11)),
+							},
+							noop,
+						},
+					},
+				},
+			},
+		},
+		"part14: nested scopes": {
+			givenSource: `
+				program Main;
+					var x, y: real;
+					procedure Alpha(a : integer);
+						var y : integer;
+					begin
+						x := a + x + y;
+					end;
+				begin { Main }
+				end.  { Main }
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "X", 3, 10)),
+							typNode: NewTypNode(NewStaticToken(Real, 3, 16)),
+						},
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "Y", 3, 13)),
+							typNode: NewTypNode(NewStaticToken(Real, 3, 16)),
+						},
+						&ProcedureDeclNode{
+							token: NewDynamicToken(ID, "ALPHA", 4, 16),
+							name:  "ALPHA",
+							params: []*ParamNode{
+								NewParamNode(
+									NewVarNode(NewDynamicToken(ID, "A", 4, 22)),
+									NewTypNode(NewStaticToken(Integer, 4, 26))),
+							},
+							block: &BlockNode{
+								declarations: []ASTNode{
+									NewVarDeclNode(
+										NewVarNode(NewDynamicToken(ID, "Y", 5, 11)),
+										NewTypNode(NewStaticToken(Integer, 5, 15))),
+								},
+								// x := a + x + y;
+								compoundStmt: &CompoundNode{
+									children: []ASTNode{
+										&AssignNode{
+											token: NewStaticToken(Assign, 7, 9),
+											left:  NewVarNode(NewDynamicToken(ID, "X", 7, 7)),
+											right: &BinOpNode{
+												token: NewStaticToken(Plus, 7, 18),
+												left: &BinOpNode{
+													token: NewStaticToken(Plus, 7, 14),
+													left:  NewVarNode(NewDynamicToken(ID, "A", 7, 12)),
+													right: NewVarNode(NewDynamicToken(ID, "X", 7, 16)),
+													op:    Plus,
+												},
+												right: NewVarNode(NewDynamicToken(ID, "Y", 7, 20)),
+												op:    Plus,
+											},
+										},
+										noop,
+									},
+								},
+							},
+						},
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{noop},
+					},
+				},
+			},
+		},
+		"multiple varDecls": {
+			givenSource: `
+				PROGRAM Part10AST;
+					VAR x : INTEGER;
+					VAR y, z : INTEGER;
+				BEGIN
+				END.`,
+			wantNode: &ProgramNode{
+				name: "PART10AST",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "X", 3, 10)),
+							typNode: NewTypNode(NewStaticToken(Integer, 3, 14)),
+						},
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "Y", 4, 10)),
+							typNode: NewTypNode(NewStaticToken(Integer, 4, 17)),
+						},
+						&VarDeclNode{
+							varNode: NewVarNode(NewDynamicToken(ID, "Z", 4, 13)),
+							typNode: NewTypNode(NewStaticToken(Integer, 4, 17)),
+						},
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{noop},
+					},
+				},
+			},
+		},
+		"part16: procedure call": {
+			givenSource: `
+				program Main;
+				procedure Alpha(a : integer; b : integer);
+					var x : integer;
+				begin
+					x := (a + b) * 2;
+				end;
+				begin { Main }
+					Alpha(3 + 5, 7);  { procedure call }
+				end.  { Main }
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&ProcedureDeclNode{
+							token: NewDynamicToken(ID, "ALPHA", 3, 15),
+							name:  "ALPHA",
+							params: []*ParamNode{
+								NewParamNode(
+									NewVarNode(NewDynamicToken(ID, "A", 3, 21)),
+									NewTypNode(NewStaticToken(Integer, 3, 25))),
+								NewParamNode(
+									NewVarNode(NewDynamicToken(ID, "B", 3, 34)),
+									NewTypNode(NewStaticToken(Integer, 3, 38))),
+							},
+							block: &BlockNode{
+								declarations: []ASTNode{
+									NewVarDeclNode(
+										NewVarNode(NewDynamicToken(ID, "X", 4, 10)),
+										NewTypNode(NewStaticToken(Integer, 4, 14))),
+								},
+								compoundStmt: &CompoundNode{
+									children: []ASTNode{
+										&AssignNode{
+											token: NewStaticToken(Assign, 6, 8),
+											left:  NewVarNode(NewDynamicToken(ID, "X", 6, 6)),
+											right: &BinOpNode{
+												token: NewStaticToken(Mul, 6, 19),
+												left: &BinOpNode{
+													token: NewStaticToken(Plus, 6, 14),
+													left:  NewVarNode(NewDynamicToken(ID, "A", 6, 12)),
+													right: NewVarNode(NewDynamicToken(ID, "B", 6, 16)),
+													op:    Plus,
+												},
+												right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "2", 6, 21)),
+												op:    Mul,
+											},
+										},
+										noop,
+									},
+								},
+							},
+						},
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							&ProcedureCallNode{
+								token: withComments(NewDynamicToken(ID, "ALPHA", 9, 6),
+									&Comment{Text: "{ Main }", Row: 8, Col: 11}),
+								name: "ALPHA",
+								actualParams: []ASTNode{
+									NewBinOpNode(
+										NewStaticToken(Plus, 9, 14),
+										NewIntegerNumNode(NewDynamicToken(IntegerConst, "3", 9, 12)),
+										NewIntegerNumNode(NewDynamicToken(IntegerConst, "5", 9, 16))),
+									NewIntegerNumNode(NewDynamicToken(IntegerConst, "7", 9, 19)),
+								},
+							},
+							noop,
+						},
+					},
+				},
+			},
+		},
+		"if statement": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					var ok : boolean;
+				begin
+					if not ok and (x < 10) or false then
+						x := 1
+					else if x >= 10 then
+						ok := true
+				end.
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "X", 3, 10)),
+							NewTypNode(NewStaticToken(Integer, 3, 14))),
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "OK", 4, 10)),
+							NewTypNode(NewStaticToken(Boolean, 4, 15))),
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							NewIfNode(
+								NewStaticToken(If, 6, 6),
+								// (NOT ok AND (x < 10)) OR FALSE
+								NewBinOpNode(
+									NewStaticToken(Or, 6, 29),
+									NewBinOpNode(
+										NewStaticToken(And, 6, 16),
+										&UnaryOpNode{
+											token:   NewStaticToken(Not, 6, 9),
+											operand: NewVarNode(NewDynamicToken(ID, "OK", 6, 13)),
+											op:      Not,
+										},
+										NewBinOpNode(
+											NewStaticToken(Less, 6, 23),
+											NewVarNode(NewDynamicToken(ID, "X", 6, 21)),
+											NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 6, 25)))),
+									NewBoolNode(NewStaticToken(False, 6, 32))),
+								&AssignNode{
+									token: NewStaticToken(Assign, 7, 9),
+									left:  NewVarNode(NewDynamicToken(ID, "X", 7, 7)),
+									right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 7, 12)),
+								},
+								NewIfNode(
+									NewStaticToken(If, 8, 11),
+									NewBinOpNode(
+										NewStaticToken(GreaterEqual, 8, 16),
+										NewVarNode(NewDynamicToken(ID, "X", 8, 14)),
+										NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 8, 19))),
+									&AssignNode{
+										token: NewStaticToken(Assign, 9, 10),
+										left:  NewVarNode(NewDynamicToken(ID, "OK", 9, 7)),
+										right: NewBoolNode(NewStaticToken(True, 9, 13)),
+									},
+									nil),
+							),
+						},
+					},
+				},
+			},
+		},
+		"loop statements": {
+			givenSource: `
+				program Main;
+					var i, x : integer;
+				begin
+					while x < 10 do x := x + 1;
+					repeat x := x - 1; i := i + 1 until x = 0;
+					for i := 10 downto 1 do
+						for x := 1 to i do ;
+				end.
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "I", 3, 10)),
+							NewTypNode(NewStaticToken(Integer, 3, 17))),
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "X", 3, 13)),
+							NewTypNode(NewStaticToken(Integer, 3, 17))),
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							NewWhileNode(
+								NewStaticToken(While, 5, 6),
+								NewBinOpNode(
+									NewStaticToken(Less, 5, 14),
+									NewVarNode(NewDynamicToken(ID, "X", 5, 12)),
+									NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 5, 16))),
+								&AssignNode{
+									token: NewStaticToken(Assign, 5, 24),
+									left:  NewVarNode(NewDynamicToken(ID, "X", 5, 22)),
+									right: NewBinOpNode(
+										NewStaticToken(Plus, 5, 29),
+										NewVarNode(NewDynamicToken(ID, "X", 5, 27)),
+										NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 5, 31))),
+								}),
+							NewRepeatNode(
+								NewStaticToken(Repeat, 6, 6),
+								[]ASTNode{
+									&AssignNode{
+										token: NewStaticToken(Assign, 6, 15),
+										left:  NewVarNode(NewDynamicToken(ID, "X", 6, 13)),
+										right: NewBinOpNode(
+											NewStaticToken(Minus, 6, 20),
+											NewVarNode(NewDynamicToken(ID, "X", 6, 18)),
+											NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 6, 22))),
+									},
+									&AssignNode{
+										token: NewStaticToken(Assign, 6, 27),
+										left:  NewVarNode(NewDynamicToken(ID, "I", 6, 25)),
+										right: NewBinOpNode(
+											NewStaticToken(Plus, 6, 32),
+											NewVarNode(NewDynamicToken(ID, "I", 6, 30)),
+											NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 6, 34))),
+									},
+								},
+								NewBinOpNode(
+									NewStaticToken(Equal, 6, 44),
+									NewVarNode(NewDynamicToken(ID, "X", 6, 42)),
+									NewIntegerNumNode(NewDynamicToken(IntegerConst, "0", 6, 46)))),
+							NewForNode(
+								NewStaticToken(For, 7, 6),
+								NewVarNode(NewDynamicToken(ID, "I", 7, 10)),
+								NewIntegerNumNode(NewDynamicToken(IntegerConst, "10", 7, 15)),
+								NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 7, 25)),
+								true,
+								NewForNode(
+									NewStaticToken(For, 8, 7),
+									NewVarNode(NewDynamicToken(ID, "X", 8, 11)),
+									NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 8, 16)),
+									NewVarNode(NewDynamicToken(ID, "I", 8, 21)),
+									false,
+									noop)),
+							noop,
+						},
+					},
+				},
+			},
+		},
+		"function declaration and call": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Double(a : integer): integer;
+					begin
+						Double := a * 2
+					end;
+				begin
+					x := Double(Double(1)) + 1
+				end.
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "X", 3, 10)),
+							NewTypNode(NewStaticToken(Integer, 3, 14))),
+						NewFunctionDeclNode(
+							NewDynamicToken(ID, "DOUBLE", 4, 15),
+							[]*ParamNode{
+								NewParamNode(
+									NewVarNode(NewDynamicToken(ID, "A", 4, 22)),
+									NewTypNode(NewStaticToken(Integer, 4, 26))),
+							},
+							NewTypNode(NewStaticToken(Integer, 4, 36)),
+							&BlockNode{
+								compoundStmt: &CompoundNode{
+									children: []ASTNode{
+										&AssignNode{
+											token: NewStaticToken(Assign, 6, 14),
+											left:  NewVarNode(NewDynamicToken(ID, "DOUBLE", 6, 7)),
+											right: NewBinOpNode(
+												NewStaticToken(Mul, 6, 19),
+												NewVarNode(NewDynamicToken(ID, "A", 6, 17)),
+												NewIntegerNumNode(NewDynamicToken(IntegerConst, "2", 6, 21))),
+										},
+									},
+								},
+							}),
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							&AssignNode{
+								token: NewStaticToken(Assign, 9, 8),
+								left:  NewVarNode(NewDynamicToken(ID, "X", 9, 6)),
+								right: NewBinOpNode(
+									NewStaticToken(Plus, 9, 29),
+									NewFunctionCallNode(
+										NewDynamicToken(ID, "DOUBLE", 9, 11),
+										[]ASTNode{
+											NewFunctionCallNode(
+												NewDynamicToken(ID, "DOUBLE", 9, 18),
+												[]ASTNode{NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 9, 25))}),
+										}),
+									NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 9, 31))),
+							},
+						},
+					},
+				},
+			},
+		},
+		"types, indexes and fields": {
+			givenSource: `
+				program Main;
+					type Row = array[-1..2] of record x : real end;
+					var r : Row;
+				begin
+					r[0].x := 1
+				end.
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						NewTypeDeclNode(
+							NewDynamicToken(ID, "ROW", 3, 11),
+							&ArrayTypeNode{
+								token: NewStaticToken(Array, 3, 17),
+								low: &UnaryOpNode{
+									token:   NewStaticToken(Minus, 3, 23),
+									operand: NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 3, 24)),
+									op:      Minus,
+								},
+								high: NewIntegerNumNode(NewDynamicToken(IntegerConst, "2", 3, 27)),
+								elemType: &RecordTypeNode{
+									token: NewStaticToken(Record, 3, 33),
+									fields: []*VarDeclNode{
+										NewVarDeclNode(
+											NewVarNode(NewDynamicToken(ID, "X", 3, 40)),
+											NewTypNode(NewStaticToken(Real, 3, 44))),
+									},
+								},
+							}),
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "R", 4, 10)),
+							NewTypNode(NewDynamicToken(ID, "ROW", 4, 14))),
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{
+							&AssignNode{
+								token: NewStaticToken(Assign, 6, 13),
+								left: &FieldNode{
+									token: NewDynamicToken(ID, "X", 6, 11),
+									base: &IndexNode{
+										token: NewStaticToken(LBracket, 6, 7),
+										base:  NewVarNode(NewDynamicToken(ID, "R", 6, 6)),
+										index: NewIntegerNumNode(NewDynamicToken(IntegerConst, "0", 6, 8)),
+									},
+									field: "X",
+								},
+								right: NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 6, 16)),
+							},
+						},
+					},
+				},
+			},
+		},
+		"constants and enumerated types": {
+			givenSource: `
+				program Main;
+				const Max = 2;
+				type Color = (Red, Green);
+				var a : array[1..Max] of Color;
+				begin
+				end.
+			`,
+			wantNode: &ProgramNode{
+				name: "MAIN",
+				block: &BlockNode{
+					declarations: []ASTNode{
+						&ConstDeclNode{
+							token: NewDynamicToken(ID, "MAX", 3, 11),
+							name:  "MAX",
+							value: NewIntegerNumNode(NewDynamicToken(IntegerConst, "2", 3, 17)),
+						},
+						NewTypeDeclNode(
+							NewDynamicToken(ID, "COLOR", 4, 10),
+							&EnumTypeNode{
+								token: NewStaticToken(LParen, 4, 18),
+								values: []*Token{
+									NewDynamicToken(ID, "RED", 4, 19),
+									NewDynamicToken(ID, "GREEN", 4, 24),
+								},
+							}),
+						NewVarDeclNode(
+							NewVarNode(NewDynamicToken(ID, "A", 5, 9)),
+							&ArrayTypeNode{
+								token:    NewStaticToken(Array, 5, 13),
+								low:      NewIntegerNumNode(NewDynamicToken(IntegerConst, "1", 5, 19)),
+								high:     NewVarNode(NewDynamicToken(ID, "MAX", 5, 22)),
+								elemType: NewTypNode(NewDynamicToken(ID, "COLOR", 5, 30)),
+							}),
+					},
+					compoundStmt: &CompoundNode{
+						children: []ASTNode{noop},
+					},
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			lexer := NewLexer(tc.givenSource)
+			parser, err := NewParser(lexer)
+			assert.NoError(t, err)
+			node, err := parser.Parse()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantNode, node)
+		})
+	}
+}
+
+func TestParser_Parse_recovery(t *testing.T) {
+	tests := map[string]struct {
+		givenSource string
+		wantErrors  []string
+	}{
+		"errors in several statements": {
+			givenSource: `
+				program Main;
+				begin
+					x := 1 +;
+					y := (2;
+					z := 3
+				end.
+			`,
+			wantErrors: []string{
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=;,value=;,pos=(4,14))">`,
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=;,value=;,pos=(5,13))">`,
+			},
+		},
+		"missing semicolon between statements": {
+			givenSource: `
+				program Main;
+				begin
+					x := 1
+					y := 2
+				end.
+			`,
+			wantErrors: []string{
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=ID,value=Y,pos=(5,6))">`,
+			},
+		},
+		"errors in declarations and statements": {
+			givenSource: `
+				program Main;
+					var a : 1;
+					    b : integer;
+					procedure P(x : integer;
+					begin end;
+				begin
+					while do a := 1;
+					b := 2 ? 3
+				end.
+			`,
+			wantErrors: []string{
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=INTEGER_CONST,value=1,pos=(3,14))">`,
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=BEGIN,value=BEGIN,pos=(6,6))">`,
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=DO,value=DO,pos=(8,12))">`,
+				`<Error: module=Lexer,code=UnknownRune,message="lexeme=?,pos=(9,13)">`,
+				`<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=INTEGER_CONST,value=3,pos=(9,15))">`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			parser, err := NewParser(NewLexer(tc.givenSource))
+			assert.NoError(t, err)
+			node, err := parser.Parse()
+			assert.Nil(t, node)
+			errs, ok := err.(ErrorList)
+			assert.True(t, ok)
+			var gotErrors []string
+			for _, e := range errs {
+				gotErrors = append(gotErrors, e.Error())
+			}
+			assert.Equal(t, tc.wantErrors, gotErrors)
+		})
+	}
+}
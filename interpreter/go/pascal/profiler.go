@@ -0,0 +1,407 @@
+package pascal
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewProfiler creates a Profiler of program, it's passed to its runs in
+// RunOptions. The counts and times of several runs add up, so that one
+// report covers a program run on several inputs.
+func NewProfiler(program *CompiledProgram) *Profiler {
+	p := &Profiler{
+		program:  program,
+		counts:   make(map[ASTNode]int),
+		routines: make(map[*BlockNode]*routineProfile),
+		samples:  make(map[string]*profileSample),
+		now:      time.Now,
+	}
+	p.routines[program.root.block] = &routineProfile{name: TokenNames[Program] + " " + program.root.name}
+	// NOTE: the error can only come from the visitor, which never fails.
+	_ = Walk(&statementCollector{profiler: p}, program.root)
+	for _, unit := range program.root.units {
+		_ = Walk(&statementCollector{profiler: p, unit: unit}, unit)
+	}
+	return p
+}
+
+// Profiler counts the statements a program runs and times the calls of its
+// routines. It's hooked into the Interpreter, which calls it before each
+// node and as calls start and return.
+type Profiler struct {
+	program *CompiledProgram
+	// statements are the statements of the program in source order, counts
+	// how many times each ran.
+	statements []ASTNode
+	counts     map[ASTNode]int
+	routines   map[*BlockNode]*routineProfile
+	// stack are the routines being called, the program's at the bottom.
+	stack []*routineProfile
+	// samples are the calls and the time spent with a stack of routines,
+	// keyed by the ids of the routines.
+	samples map[string]*profileSample
+	// called are the routines called so far, a routine's id is its
+	// position in it plus one.
+	called []*routineProfile
+	// last is when the time was last charged to the top of the stack.
+	last     time.Time
+	duration time.Duration
+	now      func() time.Time
+}
+
+// routineProfile is the profile of the calls of a routine, active is the
+// number of its calls on the stack so that recursive calls are timed once.
+// The program's own row is 0, its ProgramNode has no token, as are the
+// rows of the routines of units.
+type routineProfile struct {
+	id     int
+	name   string
+	row    int
+	calls  int
+	active int
+	start  time.Time
+	time   time.Duration
+}
+
+type profileSample struct {
+	stack []*routineProfile
+	calls int
+	time  time.Duration
+}
+
+// statementCollector finds the statements and the routines of a program,
+// including the ones never run. The statements of a unit, in a source of
+// its own, aren't covered and its routines are named after it.
+type statementCollector struct {
+	profiler *Profiler
+	unit     *UnitNode
+}
+
+func (c *statementCollector) Before(node ASTNode) (bool, error) {
+	p := c.profiler
+	if statementToken(node) != nil && c.unit == nil {
+		p.statements = append(p.statements, node)
+	}
+	switch n := node.(type) {
+	case *ProcedureDeclNode:
+		p.routines[n.block] = c.routine(Procedure, n.name, n.token)
+	case *FunctionDeclNode:
+		p.routines[n.block] = c.routine(Function, n.name, n.token)
+	case *ProcedureCallNode:
+		// NOTE: the callee's block is walked with its declaration.
+		return false, nil
+	}
+	return true, nil
+}
+
+func (c *statementCollector) After(node ASTNode) error {
+	return nil
+}
+
+// routine returns the profile of a routine, one of a unit has no row.
+func (c *statementCollector) routine(kind TokenKind, name string, token *Token) *routineProfile {
+	if c.unit != nil {
+		return &routineProfile{name: fmt.Sprintf("%s %s.%s", TokenNames[kind], c.unit.name, name)}
+	}
+	return &routineProfile{name: TokenNames[kind] + " " + name, row: token.Row}
+}
+
+// attach hooks the profiler into the Interpreter running the program.
+func (p *Profiler) attach(it *Interpreter) {
+	it.hook = p.count
+	it.enter = p.call
+	it.leave = p.ret
+	p.last = p.now()
+	p.enter(p.routines[p.program.root.block])
+}
+
+// detach ends the run, the program's own call returns.
+func (p *Profiler) detach() {
+	for len(p.stack) > 0 {
+		p.exit()
+	}
+}
+
+func (p *Profiler) count(node ASTNode) error {
+	if statementToken(node) != nil {
+		p.counts[node]++
+	}
+	return nil
+}
+
+func (p *Profiler) call(node ASTNode) {
+	switch n := node.(type) {
+	case *ProcedureCallNode:
+		p.enter(p.routines[n.procSymbol.blockNode])
+	case *FunctionCallNode:
+		p.enter(p.routines[n.funcSymbol.blockNode])
+	}
+}
+
+func (p *Profiler) ret(node ASTNode) {
+	p.exit()
+}
+
+func (p *Profiler) enter(routine *routineProfile) {
+	now := p.charge()
+	if routine.id == 0 {
+		p.called = append(p.called, routine)
+		routine.id = len(p.called)
+	}
+	p.stack = append(p.stack, routine)
+	p.sample().calls++
+	routine.calls++
+	if routine.active++; routine.active == 1 {
+		routine.start = now
+	}
+}
+
+func (p *Profiler) exit() {
+	now := p.charge()
+	routine := p.stack[len(p.stack)-1]
+	if routine.active--; routine.active == 0 {
+		routine.time += now.Sub(routine.start)
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+// charge charges the time since the last call or return to the stack, it
+// returns the time now.
+func (p *Profiler) charge() time.Time {
+	now := p.now()
+	if len(p.stack) > 0 {
+		elapsed := now.Sub(p.last)
+		p.sample().time += elapsed
+		p.duration += elapsed
+	}
+	p.last = now
+	return now
+}
+
+// sample returns the sample of the current stack.
+func (p *Profiler) sample() *profileSample {
+	ids := make([]string, len(p.stack))
+	for i, routine := range p.stack {
+		ids[i] = strconv.Itoa(routine.id)
+	}
+	key := strings.Join(ids, ",")
+	if sample, ok := p.samples[key]; ok {
+		return sample
+	}
+	sample := &profileSample{stack: append([]*routineProfile(nil), p.stack...)}
+	p.samples[key] = sample
+	return sample
+}
+
+// Covered returns the number of statements run at least once and the number
+// of statements of the program.
+func (p *Profiler) Covered() (covered, total int) {
+	for _, statement := range p.statements {
+		if p.counts[statement] > 0 {
+			covered++
+		}
+	}
+	return covered, len(p.statements)
+}
+
+// WriteListing writes the source of the program annotated as gcov does: a
+// line is prefixed with the number of times its statements ran, ##### if
+// none did or - if it has none.
+func (p *Profiler) WriteListing(writer io.Writer) (err error) {
+	lines := make(map[int]int)
+	for _, statement := range p.statements {
+		row := statementToken(statement).Row
+		if count, ok := lines[row]; !ok || p.counts[statement] > count {
+			lines[row] = p.counts[statement]
+		}
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(p.program.source, "\n"), "\n") {
+		count, ok := lines[i+1]
+		prefix := "-"
+		if ok && count == 0 {
+			prefix = "#####"
+		} else if ok {
+			prefix = strconv.Itoa(count)
+		}
+		if _, err = fmt.Fprintf(writer, "%9s:%5d:%s\n", prefix, i+1, line); err != nil {
+			return
+		}
+	}
+	return
+}
+
+type profileReport struct {
+	Covered    int               `json:"covered"`
+	Total      int               `json:"total"`
+	Statements []statementReport `json:"statements"`
+	Routines   []routineReport   `json:"routines"`
+}
+
+type statementReport struct {
+	Row   int    `json:"row"`
+	Col   int    `json:"col"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// routineReport is the calls of a routine in the order of their first call,
+// Nanoseconds is the time spent in them including the routines they called.
+type routineReport struct {
+	Name        string `json:"name"`
+	Row         int    `json:"row,omitempty"`
+	Calls       int    `json:"calls"`
+	Nanoseconds int64  `json:"nanoseconds"`
+}
+
+// WriteJSON writes the counts of the statements and the calls of the
+// routines as indented JSON.
+func (p *Profiler) WriteJSON(writer io.Writer) error {
+	report := profileReport{Statements: []statementReport{}, Routines: []routineReport{}}
+	report.Covered, report.Total = p.Covered()
+	for _, statement := range p.statements {
+		token := statementToken(statement)
+		report.Statements = append(report.Statements, statementReport{
+			Row: token.Row, Col: token.Col, Kind: statementKind(statement), Count: p.counts[statement]})
+	}
+	for _, routine := range p.called {
+		report.Routines = append(report.Routines, routineReport{
+			Name: routine.name, Row: routine.row, Calls: routine.calls, Nanoseconds: int64(routine.time)})
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// statementKind names the kind of a statement in the JSON report.
+func statementKind(node ASTNode) string {
+	switch node.(type) {
+	case *AssignNode:
+		return "ASSIGN"
+	case *ProcedureCallNode:
+		return "CALL"
+	case *IfNode:
+		return TokenNames[If]
+	case *WhileNode:
+		return TokenNames[While]
+	case *RepeatNode:
+		return TokenNames[Repeat]
+	case *ForNode:
+		return TokenNames[For]
+	}
+	return ""
+}
+
+// WritePprof writes the times of the calls as a gzipped pprof profile, the
+// source of the program is named name. Each sample is a stack of routines,
+// valued the calls made and the time spent with it on top.
+func (p *Profiler) WritePprof(writer io.Writer, name string) (err error) {
+	strs := map[string]int{"": 0}
+	table := []string{""}
+	str := func(s string) uint64 {
+		if i, ok := strs[s]; ok {
+			return uint64(i)
+		}
+		strs[s] = len(table)
+		table = append(table, s)
+		return uint64(strs[s])
+	}
+
+	var profile protoBuffer
+	for _, valueType := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}} {
+		var message protoBuffer
+		message.uint64(1, str(valueType[0]))
+		message.uint64(2, str(valueType[1]))
+		profile.message(1, message)
+	}
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sample := p.samples[key]
+		var message protoBuffer
+		// NOTE: the leaf comes first in pprof.
+		locations := make([]uint64, len(sample.stack))
+		for i, routine := range sample.stack {
+			locations[len(locations)-1-i] = uint64(routine.id)
+		}
+		message.packed(1, locations)
+		message.packed(2, []uint64{uint64(sample.calls), uint64(sample.time)})
+		profile.message(2, message)
+	}
+	// a routine is both a location and a function, of the same id.
+	for _, routine := range p.called {
+		var line, location protoBuffer
+		line.uint64(1, uint64(routine.id))
+		line.uint64(2, uint64(routine.row))
+		location.uint64(1, uint64(routine.id))
+		location.message(4, line)
+		profile.message(4, location)
+	}
+	for _, routine := range p.called {
+		var function protoBuffer
+		function.uint64(1, uint64(routine.id))
+		function.uint64(2, str(routine.name))
+		function.uint64(3, str(routine.name))
+		function.uint64(4, str(name))
+		function.uint64(5, uint64(routine.row))
+		profile.message(5, function)
+	}
+	var period protoBuffer
+	period.uint64(1, str("time"))
+	period.uint64(2, str("nanoseconds"))
+	for _, s := range table {
+		profile.bytes(6, []byte(s))
+	}
+	profile.uint64(10, uint64(p.duration))
+	profile.message(11, period)
+	profile.uint64(12, 1)
+
+	gz := gzip.NewWriter(writer)
+	if _, err = gz.Write(profile); err != nil {
+		return
+	}
+	return gz.Close()
+}
+
+// protoBuffer encodes a protocol buffers message, only the wire types pprof
+// needs.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *protoBuffer) uint64(field int, v uint64) {
+	b.varint(uint64(field) << 3)
+	b.varint(v)
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.varint(uint64(field)<<3 | 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+func (b *protoBuffer) message(field int, message protoBuffer) {
+	b.bytes(field, message)
+}
+
+func (b *protoBuffer) packed(field int, values []uint64) {
+	var data protoBuffer
+	for _, v := range values {
+		data.varint(v)
+	}
+	b.bytes(field, data)
+}
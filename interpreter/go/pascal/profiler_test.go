@@ -0,0 +1,129 @@
+package pascal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const profilerTestSource = `program Main;
+var i : integer;
+procedure Tick(n : integer);
+begin
+   if n > 1 then Tick(n - 1)
+end;
+function Twice(n : integer): integer;
+begin
+   Twice := n * 2
+end;
+begin
+   for i := 1 to 2 do Tick(Twice(i));
+   if i < 0 then
+      writeln('never')
+end.`
+
+// newTestProfiler creates a Profiler whose clock advances a millisecond
+// each time it's read.
+func newTestProfiler(t *testing.T, runs int) *Profiler {
+	program, err := Compile(profilerTestSource)
+	assert.NoError(t, err)
+	profiler := NewProfiler(program)
+	clock := time.Unix(0, 0)
+	profiler.now = func() time.Time {
+		clock = clock.Add(time.Millisecond)
+		return clock
+	}
+	for i := 0; i < runs; i++ {
+		assert.NoError(t, program.Run(context.Background(), RunOptions{Profiler: profiler}))
+	}
+	return profiler
+}
+
+func TestProfiler_WriteListing(t *testing.T) {
+	output := bytes.NewBuffer(nil)
+	assert.NoError(t, newTestProfiler(t, 2).WriteListing(output))
+	assert.Equal(t, ""+
+		"        -:    1:program Main;\n"+
+		"        -:    2:var i : integer;\n"+
+		"        -:    3:procedure Tick(n : integer);\n"+
+		"        -:    4:begin\n"+
+		"       12:    5:   if n > 1 then Tick(n - 1)\n"+
+		"        -:    6:end;\n"+
+		"        -:    7:function Twice(n : integer): integer;\n"+
+		"        -:    8:begin\n"+
+		"        4:    9:   Twice := n * 2\n"+
+		"        -:   10:end;\n"+
+		"        -:   11:begin\n"+
+		"        4:   12:   for i := 1 to 2 do Tick(Twice(i));\n"+
+		"        2:   13:   if i < 0 then\n"+
+		"    #####:   14:      writeln('never')\n"+
+		"        -:   15:end.\n", output.String())
+}
+
+func TestProfiler_WriteJSON(t *testing.T) {
+	output := bytes.NewBuffer(nil)
+	assert.NoError(t, newTestProfiler(t, 1).WriteJSON(output))
+	assert.JSONEq(t, `{
+		"covered": 6,
+		"total": 7,
+		"statements": [
+			{"row": 5, "col": 4, "kind": "IF", "count": 6},
+			{"row": 5, "col": 18, "kind": "CALL", "count": 4},
+			{"row": 9, "col": 10, "kind": "ASSIGN", "count": 2},
+			{"row": 12, "col": 4, "kind": "FOR", "count": 1},
+			{"row": 12, "col": 23, "kind": "CALL", "count": 2},
+			{"row": 13, "col": 4, "kind": "IF", "count": 1},
+			{"row": 14, "col": 7, "kind": "CALL", "count": 0}
+		],
+		"routines": [
+			{"name": "PROGRAM MAIN", "calls": 1, "nanoseconds": 17000000},
+			{"name": "FUNCTION TWICE", "row": 7, "calls": 2, "nanoseconds": 2000000},
+			{"name": "PROCEDURE TICK", "row": 3, "calls": 6, "nanoseconds": 10000000}
+		]
+	}`, output.String())
+}
+
+func TestProfiler_WritePprof(t *testing.T) {
+	output := bytes.NewBuffer(nil)
+	assert.NoError(t, newTestProfiler(t, 1).WritePprof(output, "main.pas"))
+	reader, err := gzip.NewReader(output)
+	assert.NoError(t, err)
+	profile, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	for _, s := range []string{"calls", "count", "time", "nanoseconds", "PROGRAM MAIN", "PROCEDURE TICK", "FUNCTION TWICE", "main.pas"} {
+		assert.True(t, bytes.Contains(profile, []byte(s)), s)
+	}
+	// the first sample type is calls/count, of the strings 1 and 2.
+	assert.Equal(t, []byte{0x0a, 0x04, 0x08, 0x01, 0x10, 0x02}, profile[:6])
+}
+
+func TestProfiler_Run_errors(t *testing.T) {
+	program, err := Compile(profilerTestSource)
+	assert.NoError(t, err)
+	other, err := Compile(strings.Replace(profilerTestSource, "Main", "Other", 1))
+	assert.NoError(t, err)
+	profiler := NewProfiler(program)
+
+	tests := map[string]struct {
+		givenProgram *CompiledProgram
+		givenOpts    RunOptions
+		wantError    error
+	}{
+		"vm":              {givenProgram: program, givenOpts: RunOptions{Backend: BackendVM, Profiler: profiler}, wantError: errProfileBackend},
+		"debugger":        {givenProgram: program, givenOpts: RunOptions{Debug: true, Profiler: profiler}, wantError: errProfileDebug},
+		"optimizer":       {givenProgram: program, givenOpts: RunOptions{Optimizer: NewOptimizer(ioutil.Discard), Profiler: profiler}, wantError: errProfileOptimal},
+		"another program": {givenProgram: other, givenOpts: RunOptions{Profiler: profiler}, wantError: errProfileProgram},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.wantError, tc.givenProgram.Run(context.Background(), tc.givenOpts))
+		})
+	}
+}
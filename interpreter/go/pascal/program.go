@@ -0,0 +1,178 @@
+package pascal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+// Compile parses and checks source, the errors are an Error or an ErrorList.
+// The CompiledProgram it returns can run any number of times. The units it
+// uses are read from the current directory.
+func Compile(source string) (program *CompiledProgram, err error) {
+	return CompileWith(source, CompileOptions{})
+}
+
+// CompileOptions configure CompileWith, the zero value is Compile's.
+type CompileOptions struct {
+	// SearchPath are the directories searched in order for the units the
+	// program uses, a unit's file is its name in lowercase with the
+	// extension .pas. The current directory if empty.
+	SearchPath []string
+}
+
+// CompileWith compiles source as Compile does, with opts.
+func CompileWith(source string, opts CompileOptions) (program *CompiledProgram, err error) {
+	root, err := parse(source)
+	if err != nil {
+		return
+	}
+	analyzer := NewSemanticAnalyzer()
+	analyzer.loader = searchPath(opts.SearchPath)
+	if err = Walk(analyzer, root); err != nil {
+		return
+	}
+	return &CompiledProgram{source: source, opts: opts, root: root.(*ProgramNode), warnings: analyzer.Warnings()}, nil
+}
+
+// CompiledProgram is a checked Pascal program.
+type CompiledProgram struct {
+	source   string
+	opts     CompileOptions
+	root     *ProgramNode
+	warnings []Error
+	// optimizing guards optimized, the program compiled again and optimized
+	// by the first run with an Optimizer. The root of a program is never
+	// rewritten so that it can run any number of times, at once too.
+	optimizing sync.Mutex
+	optimized  *CompiledProgram
+}
+
+// Source returns the text the program was compiled from.
+func (p *CompiledProgram) Source() string {
+	return p.source
+}
+
+// Root returns the checked tree of the program, Walk visits it.
+func (p *CompiledProgram) Root() *ProgramNode {
+	return p.root
+}
+
+// Warnings returns the warnings about the program, they don't stop it from
+// running.
+func (p *CompiledProgram) Warnings() []Error {
+	return p.warnings
+}
+
+// Backend runs a CompiledProgram.
+type Backend string
+
+const (
+	// BackendAST walks the tree of the program.
+	BackendAST Backend = "ast"
+	// BackendVM compiles the program to bytecode run by a VM.
+	BackendVM Backend = "vm"
+)
+
+// RunOptions configure a run of a CompiledProgram, the zero value runs it
+// on the BackendAST with no input and discards its output.
+type RunOptions struct {
+	// Stdin is read by READLN, Stdout is written by WRITE and WRITELN.
+	Stdin   io.Reader
+	Stdout  io.Writer
+	Backend Backend
+	// Optimizer rewrites the program before it runs, if set.
+	Optimizer *Optimizer
+	// Debug runs the program in a Debugger reading its commands from
+	// Stdin, on the BackendAST only.
+	Debug bool
+	// Limits bound the run, an untrusted program's especially.
+	Limits Limits
+	// Profiler counts the statements run and times the calls, on the
+	// BackendAST only, neither debugged nor optimized.
+	Profiler *Profiler
+}
+
+var (
+	errDebugBackend   = errors.New("the debugger runs on the ast backend only")
+	errProfileBackend = errors.New("the profiler runs on the ast backend only")
+	errProfileDebug   = errors.New("the profiler can't run with the debugger")
+	errProfileOptimal = errors.New("the profiler can't run with the optimizer, it covers the source as written")
+	errProfileProgram = errors.New("the profiler is of another program")
+)
+
+// Run runs the program until it ends or fails with a runtime Error, of
+// ErrorCodeCanceled once ctx is done.
+func (p *CompiledProgram) Run(ctx context.Context, opts RunOptions) (err error) {
+	stdin, stdout := opts.Stdin, opts.Stdout
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	if stdout == nil {
+		stdout = ioutil.Discard
+	}
+	if opts.Debug && opts.Backend != "" && opts.Backend != BackendAST {
+		return errDebugBackend
+	}
+	if opts.Profiler != nil {
+		switch {
+		case opts.Backend != "" && opts.Backend != BackendAST:
+			return errProfileBackend
+		case opts.Debug:
+			return errProfileDebug
+		case opts.Optimizer != nil:
+			return errProfileOptimal
+		case opts.Profiler.program != p:
+			return errProfileProgram
+		}
+	}
+	if p, err = p.optimize(opts.Optimizer); err != nil {
+		return
+	}
+	limits := opts.Limits.withDefaults()
+	switch opts.Backend {
+	case "", BackendAST:
+		if opts.Debug {
+			debugger := NewDebugger(stdin, stdout)
+			debugger.interpreter.limits = limits
+			return debugger.run(ctx, p)
+		}
+		interpreter := NewInterpreter(stdin, stdout)
+		interpreter.limits = limits
+		if opts.Profiler != nil {
+			opts.Profiler.attach(interpreter)
+			defer opts.Profiler.detach()
+		}
+		return interpreter.run(ctx, p.root)
+	case BackendVM:
+		vm := NewVM(stdin, stdout)
+		vm.limits = limits
+		return vm.run(ctx, p.root)
+	}
+	return fmt.Errorf("unknown backend %q", opts.Backend)
+}
+
+// optimize returns the program rewritten with optimizer, or p if it's not
+// set. It's rewritten once, the changes are written by the first optimizer.
+func (p *CompiledProgram) optimize(optimizer *Optimizer) (program *CompiledProgram, err error) {
+	if optimizer == nil {
+		return p, nil
+	}
+	p.optimizing.Lock()
+	defer p.optimizing.Unlock()
+	if p.optimized != nil {
+		return p.optimized, nil
+	}
+	if program, err = CompileWith(p.source, p.opts); err != nil {
+		return
+	}
+	if _, err = optimizer.Optimize(program.root); err != nil {
+		return
+	}
+	p.optimized = program
+	return
+}
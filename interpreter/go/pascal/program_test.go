@@ -0,0 +1,288 @@
+package pascal_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"go-playground/pascal"
+)
+
+const programTestSource = `
+program Main;
+var i, total : integer; unused : real;
+procedure Add(n : integer);
+begin
+	total := total + n
+end;
+begin
+	readln(i);
+	total := 0;
+	while i > 0 do
+	begin
+		Add(i);
+		i := i - 1
+	end;
+	writeln(total)
+end.
+`
+
+func TestCompile(t *testing.T) {
+	program, err := pascal.Compile(programTestSource)
+	assert.NoError(t, err)
+	assert.Equal(t, programTestSource, program.Source())
+	assert.Equal(t, "MAIN", program.Root().Name())
+	if assert.Len(t, program.Warnings(), 1) {
+		assert.Equal(t, pascal.ErrorCodeUnusedVariable, program.Warnings()[0].Code)
+	}
+
+	_, err = pascal.Compile("program Main; begin x := 1 end.")
+	assert.EqualError(t, err, `<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=X,pos=(1,21))">`)
+
+	_, err = pascal.Compile("")
+	if assert.IsType(t, pascal.ErrorList{}, err) {
+		assert.Equal(t, pascal.ErrorCodeUnexpectedToken, err.(pascal.ErrorList)[0].(pascal.Error).Code)
+	}
+}
+
+func TestCompiledProgram_Run(t *testing.T) {
+	tests := map[string]struct {
+		givenOpts  pascal.RunOptions
+		wantOutput string
+		wantError  string
+	}{
+		"ast":       {givenOpts: pascal.RunOptions{}, wantOutput: "10\n"},
+		"vm":        {givenOpts: pascal.RunOptions{Backend: pascal.BackendVM}, wantOutput: "10\n"},
+		"optimized": {givenOpts: pascal.RunOptions{Optimizer: pascal.NewOptimizer(&bytes.Buffer{})}, wantOutput: "10\n"},
+		"debugger": {
+			givenOpts:  pascal.RunOptions{Stdin: strings.NewReader("continue\n4\n"), Debug: true},
+			wantOutput: "line 9: readln(i);\n(debug) 10\n",
+		},
+		"debugger on the vm": {
+			givenOpts: pascal.RunOptions{Backend: pascal.BackendVM, Debug: true},
+			wantError: "the debugger runs on the ast backend only",
+		},
+		"unknown backend": {
+			givenOpts: pascal.RunOptions{Backend: "jit"},
+			wantError: `unknown backend "jit"`,
+		},
+	}
+
+	program, err := pascal.Compile(programTestSource)
+	assert.NoError(t, err)
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			tc.givenOpts.Stdout = output
+			if tc.givenOpts.Stdin == nil {
+				tc.givenOpts.Stdin = strings.NewReader("4")
+			}
+			err := program.Run(context.Background(), tc.givenOpts)
+			if tc.wantError != "" {
+				assert.EqualError(t, err, tc.wantError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantOutput, output.String())
+		})
+	}
+}
+
+func TestCompiledProgram_Run_optimized(t *testing.T) {
+	program, err := pascal.Compile("program Main; var x : integer; begin x := 2 * 3; writeln(x) end.")
+	assert.NoError(t, err)
+	changes := make([]*bytes.Buffer, 4)
+	var wg sync.WaitGroup
+	for i := range changes {
+		changes[i] = bytes.NewBuffer(nil)
+		wg.Add(1)
+		go func(changes *bytes.Buffer) {
+			defer wg.Done()
+			output := bytes.NewBuffer(nil)
+			assert.NoError(t, program.Run(context.Background(), pascal.RunOptions{Stdout: output, Optimizer: pascal.NewOptimizer(changes)}))
+			assert.Equal(t, "6\n", output.String())
+		}(changes[i])
+	}
+	wg.Wait()
+
+	// the program is optimized once, its own tree is left as it's written.
+	written := 0
+	for _, buffer := range changes {
+		if buffer.Len() > 0 {
+			written++
+			assert.Equal(t, "1:45: folded a constant expression into 6\n", buffer.String())
+		}
+	}
+	assert.Equal(t, 1, written)
+	assign := program.Root().Block().CompoundStmt().Children()[0].(*pascal.AssignNode)
+	assert.IsType(t, &pascal.BinOpNode{}, assign.Right())
+}
+
+func TestCompiledProgram_Run_limits(t *testing.T) {
+	const forever = `
+		program Main;
+		var i : integer;
+		begin
+			i := 0;
+			while true do i := i + 1
+		end.
+	`
+	const recursion = `
+		program Main;
+		procedure Down(n : integer);
+		var a, b : integer;
+		begin
+			a := n;
+			b := n;
+			Down(n - 1)
+		end;
+		begin
+			Down(100)
+		end.
+	`
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tests := map[string]struct {
+		givenSource  string
+		givenContext func() (context.Context, context.CancelFunc)
+		givenLimits  pascal.Limits
+		wantCode     pascal.ErrorCode
+	}{
+		"canceled": {
+			givenSource:  forever,
+			givenContext: func() (context.Context, context.CancelFunc) { return canceled, func() {} },
+			wantCode:     pascal.ErrorCodeCanceled,
+		},
+		"deadline": {
+			givenSource: forever,
+			givenContext: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantCode: pascal.ErrorCodeCanceled,
+		},
+		"steps": {
+			givenSource: forever,
+			givenLimits: pascal.Limits{Steps: 1000},
+			wantCode:    pascal.ErrorCodeStepLimitExceeded,
+		},
+		"steps of an empty while": {
+			givenSource: "program Main; begin while true do ; end.",
+			givenLimits: pascal.Limits{Steps: 1000},
+			wantCode:    pascal.ErrorCodeStepLimitExceeded,
+		},
+		"deadline of an empty while": {
+			givenSource: "program Main; begin while true do begin end end.",
+			givenContext: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantCode: pascal.ErrorCodeCanceled,
+		},
+		"steps of an empty repeat": {
+			givenSource: "program Main; begin repeat until false end.",
+			givenLimits: pascal.Limits{Steps: 1000},
+			wantCode:    pascal.ErrorCodeStepLimitExceeded,
+		},
+		"deadline of an empty repeat": {
+			givenSource: "program Main; begin repeat until false end.",
+			givenContext: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantCode: pascal.ErrorCodeCanceled,
+		},
+		"steps of an empty for": {
+			givenSource: "program Main; var i : integer; begin for i := 1 to 1000000000 do ; end.",
+			givenLimits: pascal.Limits{Steps: 1000},
+			wantCode:    pascal.ErrorCodeStepLimitExceeded,
+		},
+		"call depth": {
+			givenSource: recursion,
+			givenLimits: pascal.Limits{CallDepth: 10},
+			wantCode:    pascal.ErrorCodeStackOverflow,
+		},
+		"memory": {
+			givenSource: recursion,
+			givenLimits: pascal.Limits{Memory: 20},
+			wantCode:    pascal.ErrorCodeMemoryLimitExceeded,
+		},
+		"memory of an array": {
+			givenSource: "program Main; var a : array[1..3000000000] of integer; begin a[1] := 1 end.",
+			givenLimits: pascal.Limits{Memory: 5},
+			wantCode:    pascal.ErrorCodeMemoryLimitExceeded,
+		},
+		"memory of records in an array": {
+			givenSource: "program Main; var a : array[1..3] of record x, y : integer end; begin a[1].x := 1 end.",
+			givenLimits: pascal.Limits{Memory: 5},
+			wantCode:    pascal.ErrorCodeMemoryLimitExceeded,
+		},
+		"array larger than an int counts": {
+			givenSource: `program Main;
+				var a : array[1..4000000000] of array[1..4000000000] of array[1..4000000000] of integer;
+				begin a[1][1][1] := 1 end.`,
+			wantCode: pascal.ErrorCodeMemoryLimitExceeded,
+		},
+		"default call depth": {
+			givenSource: recursion,
+			givenLimits: pascal.Limits{Steps: 1000000, Memory: 1000000},
+			wantCode:    pascal.ErrorCodeStackOverflow,
+		},
+	}
+
+	for name, tc := range tests {
+		program, err := pascal.Compile(tc.givenSource)
+		assert.NoError(t, err)
+		for _, backend := range []pascal.Backend{pascal.BackendAST, pascal.BackendVM} {
+			t.Run(name+" on "+string(backend), func(t *testing.T) {
+				ctx, cancel := context.Background(), func() {}
+				if tc.givenContext != nil {
+					ctx, cancel = tc.givenContext()
+				}
+				defer cancel()
+				err := program.Run(ctx, pascal.RunOptions{Backend: backend, Limits: tc.givenLimits})
+				if assert.IsType(t, pascal.Error{}, err) {
+					assert.Equal(t, tc.wantCode, err.(pascal.Error).Code, err.Error())
+				}
+			})
+		}
+	}
+}
+
+// callCounter counts the calls of each routine declared by the program, as
+// a tool outside the package walks the tree.
+type callCounter map[string]int
+
+func (c callCounter) Before(node pascal.ASTNode) (bool, error) {
+	if n, ok := node.(*pascal.ProcedureCallNode); ok && !n.Builtin() {
+		c[n.Name()]++
+	}
+	return true, nil
+}
+
+func (c callCounter) After(node pascal.ASTNode) error {
+	return nil
+}
+
+func TestWalk_accessors(t *testing.T) {
+	program, err := pascal.Compile(programTestSource)
+	assert.NoError(t, err)
+	counter := callCounter{}
+	assert.NoError(t, pascal.Walk(counter, program.Root()))
+	assert.Equal(t, callCounter{"ADD": 1}, counter)
+
+	decls := program.Root().Block().Declarations()
+	if assert.Len(t, decls, 4) {
+		procedure := decls[3].(*pascal.ProcedureDeclNode)
+		assert.Equal(t, "ADD", procedure.Name())
+		assert.Equal(t, "N", procedure.Params()[0].VarNode().Name())
+		assert.Equal(t, "INTEGER", procedure.Params()[0].VarNode().Type().GetName())
+	}
+	loop := program.Root().Block().CompoundStmt().Children()[2].(*pascal.WhileNode)
+	condition := loop.Condition().(*pascal.BinOpNode)
+	assert.Equal(t, pascal.Greater, condition.Op())
+	assert.Equal(t, int64(0), condition.Right().(*pascal.NumNode).Value())
+	assert.Equal(t, "BOOLEAN", condition.Type().GetName())
+}
@@ -0,0 +1,254 @@
+package pascal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	replPrompt       = "> "
+	replContinuation = ". "
+	replSourceName   = "repl"
+)
+
+// NewREPL creates a REPL, READLN reads from reader, which also provides the
+// lines of Run, and WRITE/WRITELN, values and errors are written to writer.
+func NewREPL(reader io.Reader, writer io.Writer) *REPL {
+	r := &REPL{
+		reader:   bufio.NewReader(reader),
+		writer:   writer,
+		analyzer: NewSemanticAnalyzer(),
+	}
+	r.interpreter = NewInterpreter(r.reader, writer)
+	// NOTE: the REPL behaves as the body of a program that never ends, its
+	// scope and activation record persist across inputs.
+	r.analyzer.Before(&ProgramNode{name: "REPL"})
+	r.interpreter.callStack.Push(NewActivationRecord("REPL", ARKindProgram, 1))
+	return r
+}
+
+// REPL reads declarations, statements and expressions line by line, an
+// input spanning several lines is run once complete. It supports the
+// commands :stack, dumping the call stack, and :scope, dumping the symbols.
+type REPL struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	analyzer    *SemanticAnalyzer
+	interpreter *Interpreter
+	// pending is the incomplete input read so far.
+	pending strings.Builder
+}
+
+// Run reads lines until the end of input.
+func (r *REPL) Run() (err error) {
+	for {
+		prompt := replPrompt
+		if r.pending.Len() > 0 {
+			prompt = replContinuation
+		}
+		if _, err = io.WriteString(r.writer, prompt); err != nil {
+			return
+		}
+		line, readErr := r.reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		if line != "" {
+			source := r.pending.String() + line
+			result, _, evalErr := r.Eval(strings.TrimSuffix(line, "\n"))
+			if evalErr != nil {
+				result = r.render(evalErr, source)
+			}
+			if _, err = io.WriteString(r.writer, result); err != nil {
+				return
+			}
+		}
+		if readErr == io.EOF {
+			_, err = io.WriteString(r.writer, "\n")
+			return
+		}
+	}
+}
+
+// Eval evaluates a line, more reports that the input is incomplete and
+// the next line continues it. The result is the value of an expression,
+// or the dump asked by a command.
+func (r *REPL) Eval(line string) (result string, more bool, err error) {
+	if r.pending.Len() == 0 {
+		switch strings.TrimSpace(line) {
+		case "":
+			return
+		case ":stack":
+			return r.interpreter.callStack.String(), false, nil
+		case ":scope":
+			return r.analyzer.currentScope.String(), false, nil
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), ":") {
+			return "", false, fmt.Errorf("unknown command %s, want :stack or :scope", strings.TrimSpace(line))
+		}
+	}
+
+	r.pending.WriteString(line)
+	r.pending.WriteRune('\n')
+	source := r.pending.String()
+	decls, stmts, expr, err := r.parse(source)
+	if err != nil {
+		if incomplete(source, err) {
+			return "", true, nil
+		}
+		r.pending.Reset()
+		return
+	}
+	r.pending.Reset()
+
+	for _, node := range append(decls, append(stmts, expr)...) {
+		if node != nil {
+			Walk(r.analyzer, node)
+		}
+	}
+	if err = r.analyzer.takeErrors(); err != nil {
+		return
+	}
+	return r.run(decls, stmts, expr)
+}
+
+// parse parses declarations followed by either statements or an expression.
+//
+// input : declarations (expr | statement_list)
+func (r *REPL) parse(source string) (decls []ASTNode, stmts []ASTNode, expr ASTNode, err error) {
+	parser, err := NewParser(NewLexer(source))
+	if err != nil {
+		return
+	}
+	decls, _ = parser.declarations()
+
+	if parser.currToken.Kind != EOF {
+		// try an expression first, then backtrack to statements.
+		savedParser, savedLexer := *parser, *parser.lexer
+		node, exprErr := parser.expr()
+		if exprErr == nil && len(parser.errors) == len(savedParser.errors) &&
+			parser.currToken.Kind == EOF && !r.isProcedureCall(node) {
+			expr = node
+		} else {
+			*parser, *parser.lexer = savedParser, savedLexer
+			stmts, _ = parser.stmtList()
+			if parser.currToken.Kind != EOF {
+				parser.errors = append(parser.errors, parser.error(ErrorCodeUnexpectedToken, expected(EOF)))
+			}
+		}
+	}
+	err = parser.errors.Err()
+	return
+}
+
+// isProcedureCall reports whether node, parsed as an expression, is rather
+// a call of a procedure, e.g. WRITELN or WRITELN(1).
+func (r *REPL) isProcedureCall(node ASTNode) bool {
+	var name string
+	switch n := node.(type) {
+	case *VarNode:
+		name = n.value
+	case *FunctionCallNode:
+		name = n.name
+	default:
+		return false
+	}
+	symbol, _ := r.analyzer.currentScope.Lookup(name, false)
+	switch symbol.(type) {
+	case *ProcedureSymbol, *BuiltinProcedureSymbol:
+		return true
+	}
+	return false
+}
+
+// run allocates the variables declared and executes the statements, then
+// evaluates the expression.
+func (r *REPL) run(decls []ASTNode, stmts []ASTNode, expr ASTNode) (result string, more bool, err error) {
+	defer func() {
+		// a runtime error leaves the records of the calls it aborted.
+		for r.interpreter.callStack.Len() > 1 {
+			r.interpreter.callStack.Pop()
+		}
+	}()
+	for _, node := range append(decls, stmts...) {
+		if err = Walk(r.interpreter, node); err != nil {
+			return
+		}
+	}
+	if expr == nil {
+		return
+	}
+	value, err := r.interpreter.expr(expr)
+	if err != nil {
+		return
+	}
+	return formatValue(value) + "\n", false, nil
+}
+
+func (r *REPL) render(err error, source string) string {
+	switch e := err.(type) {
+	case Error:
+		return e.Render(replSourceName, source)
+	case ErrorList:
+		return e.Render(replSourceName, source)
+	}
+	return err.Error() + "\n"
+}
+
+// incomplete reports whether parsing failed only because source ended too early.
+func incomplete(source string, err error) bool {
+	errs, ok := err.(ErrorList)
+	if !ok {
+		return false
+	}
+	lexer := NewLexer(source)
+	var eof *Token
+	for eof == nil || eof.Kind != EOF {
+		eof, _ = lexer.GetNextToken()
+	}
+	for _, err := range errs {
+		if e, ok := err.(Error); !ok || e.Module != ModuleParser || e.Span != eof.Span() {
+			return false
+		}
+	}
+	return true
+}
+
+// Complete, Reset, Prompt, Start and Stop implement, along with Eval, the
+// handler of github.com/boynton/repl which provides line editing in a terminal.
+
+func (r *REPL) Complete(expr string) (string, []string) {
+	return "", nil
+}
+
+func (r *REPL) Reset() {
+	r.pending.Reset()
+}
+
+func (r *REPL) Prompt() string {
+	return replPrompt
+}
+
+func (r *REPL) Start() []string {
+	return nil
+}
+
+func (r *REPL) Stop(history []string) {
+}
+
+// TerminalREPL adapts a REPL to github.com/boynton/repl, it renders the
+// errors of REPL.Eval since the terminal prints them as is.
+type TerminalREPL struct {
+	*REPL
+}
+
+func (t TerminalREPL) Eval(line string) (result string, more bool, err error) {
+	source := t.pending.String() + line + "\n"
+	if result, more, err = t.REPL.Eval(line); err != nil {
+		err = errors.New(strings.TrimSuffix(t.render(err, source), "\n"))
+	}
+	return strings.TrimSuffix(result, "\n"), more, err
+}
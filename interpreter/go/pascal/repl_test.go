@@ -0,0 +1,90 @@
+package pascal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestREPL_Run(t *testing.T) {
+	tests := map[string]struct {
+		givenInput string
+		wantOutput string
+	}{
+		"expressions": {
+			givenInput: "1 + 2 * 3\n7 / 2\nnot (1 < 2)\n",
+			wantOutput: "> 7\n> 3.5\n> FALSE\n> \n",
+		},
+		"declarations and statements persist": {
+			givenInput: "var x, y : integer;\nx := 2; y := x * 3\nx + y\nwriteln(x, y)\n",
+			wantOutput: "> > > 8\n> 26\n> \n",
+		},
+		"input spanning several lines": {
+			givenInput: "function Sq(n : integer): integer;\nbegin\n  Sq := n * n\nend;\nSq(4)\n",
+			wantOutput: "> . . . > 16\n> \n",
+		},
+		"errors don't end the session": {
+			givenInput: "z\n1 div 0\n2\n",
+			wantOutput: "> repl:1:1: SemanticAnalyzer error IdNotFound: token:(kind=ID,value=Z,pos=(1,1))\n" +
+				"   1 | z\n" +
+				"     | ^\n" +
+				"hint: declare Z before using it\n" +
+				"> repl:1:3: Interpreter error DivisionByZero: division by zero,token:(kind=DIV,value=DIV,pos=(1,3))\n" +
+				"   1 | 1 div 0\n" +
+				"     |   ^^^\n" +
+				"hint: check the divisor before dividing\n" +
+				"\tat 1: PROGRAM REPL\n" +
+				"> 2\n> \n",
+		},
+		"stack command": {
+			givenInput: "var x : integer;\nx := 1\n:stack\n",
+			wantOutput: "> > > CALL STACK\n1: PROGRAM REPL\n\tX                   : 1\n> \n",
+		},
+		"scope command": {
+			givenInput: "var b : boolean;\n:scope\n",
+			wantOutput: "> > SCOPE (SCOPED SYMBOL TABLE)\n" +
+				"===========================\n" +
+				"Scope name     : REPL\n" +
+				"Scope level    : 1\n" +
+				"Enclosing scope: global\n" +
+				"\n" +
+				"Scope (Scoped symbol table) contents\n" +
+				"------------------------------------\n" +
+				"      B: <B:BOOLEAN>\n" +
+				"> \n",
+		},
+		"unknown command": {
+			givenInput: ":quit\n",
+			wantOutput: "> unknown command :quit, want :stack or :scope\n> \n",
+		},
+		"readln reads the next line": {
+			givenInput: "var a : integer;\nreadln(a)\n41\na + 1\n",
+			wantOutput: "> > > 42\n> \n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			assert.NoError(t, NewREPL(strings.NewReader(tc.givenInput), output).Run())
+			assert.Equal(t, tc.wantOutput, output.String())
+		})
+	}
+}
+
+func TestREPL_Eval(t *testing.T) {
+	r := NewREPL(nil, nil)
+
+	_, more, err := r.Eval("begin")
+	assert.NoError(t, err)
+	assert.True(t, more)
+	_, more, err = r.Eval("end")
+	assert.NoError(t, err)
+	assert.False(t, more)
+
+	_, more, err = r.Eval("x := )")
+	assert.False(t, more)
+	assert.Equal(t, `<Error: module=Parser,code=UnexpectedToken,message="code=UnexpectedToken,token=(kind=),value=),pos=(1,6))">`, err.Error())
+}
@@ -0,0 +1,268 @@
+package pascal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func NewScopeMarker(writer io.Writer) *ScopeMarker {
+	return &ScopeMarker{
+		writer:           writer,
+		semanticAnalyzer: NewSemanticAnalyzer(),
+		blockStmts:       make(map[*CompoundNode]bool),
+	}
+}
+
+var _ Visitor = (*ScopeMarker)(nil)
+
+type ScopeMarker struct {
+	writer           io.Writer
+	semanticAnalyzer *SemanticAnalyzer
+	extraIndent      int
+	// blockStmts marks compound statements owned by a block, their END is
+	// written together with the enclosing PROGRAM or PROCEDURE.
+	blockStmts map[*CompoundNode]bool
+}
+
+func (sm *ScopeMarker) Mark(source string) (err error) {
+	lexer := NewLexer(source)
+	parser, err := NewParser(lexer)
+	if err != nil {
+		return
+	}
+	root, err := parser.Parse()
+	if err != nil {
+		return
+	}
+	return Walk(sm, root)
+}
+
+func (sm *ScopeMarker) Before(node ASTNode) (shouldStepIn bool, err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		sm.writeLine(fmt.Sprintf("%s %s;", TokenNames[Program], sm.withScope(n.name, 0)))
+	case *BlockNode:
+		sm.blockStmts[n.compoundStmt] = true
+	case *VarDeclNode:
+		sm.extraIndent += 1
+		sm.writeLine(fmt.Sprintf("%s %s %s %s%s",
+			TokenNames[Var],
+			sm.withScope(n.varNode.value, 0),
+			TokenNames[Colon],
+			sm.typ(n.typNode),
+			TokenNames[Semi]))
+		sm.extraIndent -= 1
+	case *ConstDeclNode:
+		sm.extraIndent += 1
+		sm.writeLine(fmt.Sprintf("%s %s %s %s%s",
+			TokenNames[Const],
+			sm.withScope(n.name, 0),
+			TokenNames[Equal],
+			sm.expr(n.value),
+			TokenNames[Semi]))
+		sm.extraIndent -= 1
+	case *TypeDeclNode:
+		sm.extraIndent += 1
+		sm.writeLine(fmt.Sprintf("%s %s %s %s%s",
+			TokenNames[Type],
+			sm.withScope(n.name, 0),
+			TokenNames[Equal],
+			sm.typ(n.typNode),
+			TokenNames[Semi]))
+		sm.extraIndent -= 1
+	case *ProcedureDeclNode:
+		sm.extraIndent += 1
+		sm.writeLine(fmt.Sprintf("%s %s%s%s",
+			TokenNames[Procedure], sm.withScope(n.name, 0), sm.params(n.params), TokenNames[Semi]))
+		sm.extraIndent -= 1
+	case *FunctionDeclNode:
+		sm.extraIndent += 1
+		sm.writeLine(fmt.Sprintf("%s %s%s %s %s%s",
+			TokenNames[Function], sm.withScope(n.name, 0), sm.params(n.params),
+			TokenNames[Colon], n.returnType.value, TokenNames[Semi]))
+		sm.extraIndent -= 1
+	case *CompoundNode:
+		sm.writeLine(TokenNames[Begin])
+	case *AssignNode:
+		sm.writeLine(fmt.Sprintf("%s %s %s%s",
+			sm.expr(n.left), n.token.Value, sm.expr(n.right), TokenNames[Semi]))
+	case *ProcedureCallNode:
+		sm.writeLine(fmt.Sprintf("%s%s%s", sm.withLookupScope(n.name), sm.args(n.actualParams), TokenNames[Semi]))
+	case *IfNode:
+		sm.writeLine(fmt.Sprintf("%s %s %s", TokenNames[If], sm.expr(n.condition), TokenNames[Then]))
+		if err = sm.nested(n.thenStmt); err != nil {
+			return
+		}
+		if n.elseStmt != nil {
+			sm.writeLine(TokenNames[Else])
+			if err = sm.nested(n.elseStmt); err != nil {
+				return
+			}
+		}
+		return false, nil
+	case *WhileNode:
+		sm.writeLine(fmt.Sprintf("%s %s %s", TokenNames[While], sm.expr(n.condition), TokenNames[Do]))
+		return false, sm.nested(n.body)
+	case *RepeatNode:
+		sm.writeLine(TokenNames[Repeat])
+		for _, child := range n.children {
+			if err = sm.nested(child); err != nil {
+				return
+			}
+		}
+		sm.writeLine(fmt.Sprintf("%s %s%s", TokenNames[Until], sm.expr(n.condition), TokenNames[Semi]))
+		return false, nil
+	case *ForNode:
+		direction := TokenNames[To]
+		if n.downto {
+			direction = TokenNames[Downto]
+		}
+		sm.writeLine(fmt.Sprintf("%s %s %s %s %s %s %s",
+			TokenNames[For], sm.withLookupScope(n.varNode.value), TokenNames[Assign],
+			sm.expr(n.start), direction, sm.expr(n.end), TokenNames[Do]))
+		return false, sm.nested(n.body)
+	}
+
+	return sm.semanticAnalyzer.Before(node)
+}
+
+func (sm *ScopeMarker) After(node ASTNode) (err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		builder := strings.Builder{}
+		builder.WriteString(TokenNames[End])
+		builder.WriteString(TokenNames[Dot])
+		builder.WriteString(fmt.Sprintf(" {END OF %s}", n.name))
+		sm.writeLine(builder.String())
+	case *ProcedureDeclNode, *FunctionDeclNode:
+		builder := strings.Builder{}
+		builder.WriteString(TokenNames[End])
+		builder.WriteString(TokenNames[Semi])
+		sm.writeLine(builder.String())
+	case *BlockNode:
+	case *CompoundNode:
+		if !sm.blockStmts[n] {
+			sm.writeLine(TokenNames[End] + TokenNames[Semi])
+		}
+	}
+	return sm.semanticAnalyzer.After(node)
+}
+
+// nested writes a statement owned by a structured statement one level deeper.
+func (sm *ScopeMarker) nested(node ASTNode) error {
+	sm.extraIndent += 1
+	defer func() { sm.extraIndent -= 1 }()
+	return Walk(sm, node)
+}
+
+func (sm *ScopeMarker) expr(node ASTNode) string {
+	switch n := node.(type) {
+	case *NumNode:
+		return n.token.Value
+	case *BoolNode:
+		return n.token.Value
+	case *StrNode:
+		return n.token.Value
+	case *UnaryOpNode:
+		if n.op == Not {
+			return n.token.Value + " " + sm.expr(n.operand)
+		}
+		return n.token.Value + sm.expr(n.operand)
+	case *BinOpNode:
+		return strings.Join([]string{
+			sm.expr(n.left),
+			n.token.Value,
+			sm.expr(n.right),
+		}, " ")
+	case *VarNode:
+		return sm.withLookupScope(n.value)
+	case *FunctionCallNode:
+		return sm.withLookupScope(n.name) + sm.args(n.actualParams)
+	case *IndexNode:
+		return sm.expr(n.base) + TokenNames[LBracket] + sm.expr(n.index) + TokenNames[RBracket]
+	case *FieldNode:
+		return sm.expr(n.base) + TokenNames[Dot] + n.field
+	}
+	panic("unreachable")
+}
+
+// typ writes a type, the name of a declared one is marked with its scope.
+func (sm *ScopeMarker) typ(node ASTNode) string {
+	switch n := node.(type) {
+	case *TypNode:
+		if n.token.Kind == ID {
+			return sm.withLookupScope(n.value)
+		}
+		return n.value
+	case *ArrayTypeNode:
+		return fmt.Sprintf("%s%s%s%s%s%s %s %s",
+			TokenNames[Array], TokenNames[LBracket], sm.expr(n.low), TokenNames[DotDot], sm.expr(n.high),
+			TokenNames[RBracket], TokenNames[Of], sm.typ(n.elemType))
+	case *RecordTypeNode:
+		fields := make([]string, len(n.fields))
+		for i, field := range n.fields {
+			fields[i] = fmt.Sprintf("%s %s %s%s", field.varNode.value, TokenNames[Colon], sm.typ(field.typNode), TokenNames[Semi])
+		}
+		return fmt.Sprintf("%s %s %s", TokenNames[Record], strings.Join(fields, " "), TokenNames[End])
+	case *EnumTypeNode:
+		values := make([]string, len(n.values))
+		for i, value := range n.values {
+			values[i] = sm.withScope(value.Value, 0)
+		}
+		return TokenNames[LParen] + strings.Join(values, TokenNames[Comma]+" ") + TokenNames[RParen]
+	}
+	panic("unreachable")
+}
+
+func (sm *ScopeMarker) params(params []*ParamNode) string {
+	builder := strings.Builder{}
+	builder.WriteString(TokenNames[LParen])
+	for i, param := range params {
+		if param.byReference {
+			builder.WriteString(TokenNames[Var] + " ")
+		}
+		builder.WriteString(fmt.Sprintf("%s %s %s",
+			sm.withScope(param.varNode.value, -1), TokenNames[Colon], param.typNode.value))
+		if i+1 != len(params) {
+			builder.WriteRune(';')
+		}
+	}
+	builder.WriteString(TokenNames[RParen])
+	return builder.String()
+}
+
+func (sm *ScopeMarker) args(actualParams []ASTNode) string {
+	args := make([]string, len(actualParams))
+	for i, actualParam := range actualParams {
+		args[i] = sm.expr(actualParam)
+	}
+	return TokenNames[LParen] + strings.Join(args, TokenNames[Comma]+" ") + TokenNames[RParen]
+}
+
+func (sm *ScopeMarker) writeLine(s string) {
+	indent := strings.Builder{}
+	for i := 1; i < sm.semanticAnalyzer.currentScope.level+sm.extraIndent; i++ {
+		indent.WriteRune('\t')
+	}
+	sm.writer.Write([]byte(indent.String() + s + "\n"))
+}
+
+func (sm *ScopeMarker) withScope(s string, levelup int) string {
+	return fmt.Sprintf("%s%d", s, sm.semanticAnalyzer.currentScope.level-levelup)
+}
+
+func (sm *ScopeMarker) withLookupScope(s string) string {
+	var levelup int
+	currScope := sm.semanticAnalyzer.currentScope
+	_, ok := currScope.Lookup(s, true)
+	for !ok {
+		levelup += 1
+		currScope = currScope.enclosingScope
+		if currScope == nil {
+			panic(fmt.Sprintf("symbol %s not found in enclosing scope", s))
+		}
+		_, ok = currScope.Lookup(s, true)
+	}
+	return sm.withScope(s, levelup)
+}
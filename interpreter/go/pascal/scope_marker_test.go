@@ -1,4 +1,4 @@
-package main
+package pascal
 
 import (
 	"bytes"
@@ -96,6 +96,101 @@ func TestScopeMarker_Mark(t *testing.T) {
 				END. {END OF MAIN}
 			`,
 		},
+		"structured statements": {
+			givenSource: `
+				program Main;
+					var i, x : integer;
+				begin
+					for i := 1 to 3 do
+						if i > x then
+							x := i
+						else
+							begin
+								x := 0;
+							end;
+					while x > 0 do x := x - 1;
+					repeat x := x + 1 until x >= 3;
+				end.
+			`,
+			wantSource: `
+				PROGRAM MAIN0;
+					VAR I1 : INTEGER;
+					VAR X1 : INTEGER;
+				BEGIN
+					FOR I1 := 1 TO 3 DO
+						IF I1 > X1 THEN
+							X1 := I1;
+						ELSE
+							BEGIN
+								X1 := 0;
+							END;
+					WHILE X1 > 0 DO
+						X1 := X1 - 1;
+					REPEAT
+						X1 := X1 + 1;
+					UNTIL X1 >= 3;
+				END. {END OF MAIN}
+			`,
+		},
+		"functions and procedure calls": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Fib(n : integer): integer;
+					begin
+						if n < 2 then Fib := n else Fib := Fib(n - 1) + Fib(n - 2)
+					end;
+					procedure Show(a : integer; var b : integer);
+					begin
+					end;
+				begin
+					x := Fib(10);
+					Show(x, x)
+				end.
+			`,
+			wantSource: `
+				PROGRAM MAIN0;
+					VAR X1 : INTEGER;
+					FUNCTION FIB1(N2 : INTEGER) : INTEGER;
+					BEGIN
+						IF N2 < 2 THEN
+							FIB1 := N2;
+						ELSE
+							FIB1 := FIB1(N2 - 1) + FIB1(N2 - 2);
+					END;
+					PROCEDURE SHOW1(A2 : INTEGER;VAR B2 : INTEGER);
+					BEGIN
+					END;
+				BEGIN
+					X1 := FIB1(10);
+					SHOW1(X1, X1);
+				END. {END OF MAIN}
+			`,
+		},
+		"types": {
+			givenSource: `
+				program Main;
+					type
+						Vec = array[1..3] of integer;
+						Point = record x, y : real end;
+					var v : Vec; p : Point;
+				begin
+					v[1] := 1;
+					p.x := v[1]
+				end.
+			`,
+			wantSource: `
+				PROGRAM MAIN0;
+					TYPE VEC1 = ARRAY[1..3] OF INTEGER;
+					TYPE POINT1 = RECORD X : REAL; Y : REAL; END;
+					VAR V1 : VEC1;
+					VAR P1 : POINT1;
+				BEGIN
+					V1[1] := 1;
+					P1.X := V1[1];
+				END. {END OF MAIN}
+			`,
+		},
 	}
 
 	for name, tc := range tests {
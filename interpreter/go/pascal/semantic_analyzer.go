@@ -0,0 +1,907 @@
+package pascal
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+func NewSemanticAnalyzer() *SemanticAnalyzer {
+	globalScope := NewScopedSymbolTable("global", 0, nil)
+	integerType := NewBuiltinTypeSymbol("INTEGER")
+	stringType := NewBuiltinTypeSymbol("STRING")
+	charType := NewBuiltinTypeSymbol("CHAR")
+	globalScope.Define(integerType)
+	globalScope.Define(NewBuiltinTypeSymbol("REAL"))
+	globalScope.Define(NewBuiltinTypeSymbol("BOOLEAN"))
+	globalScope.Define(stringType)
+	globalScope.Define(charType)
+	globalScope.Define(NewBuiltinProcedureSymbol("WRITE", false))
+	globalScope.Define(NewBuiltinProcedureSymbol("WRITELN", false))
+	globalScope.Define(NewBuiltinProcedureSymbol("READLN", true))
+	globalScope.Define(NewBuiltinFunctionSymbol("LENGTH",
+		[]*VarSymbol{NewVarSymbol("S", stringType)}, integerType))
+	globalScope.Define(NewBuiltinFunctionSymbol("COPY",
+		[]*VarSymbol{NewVarSymbol("S", stringType), NewVarSymbol("INDEX", integerType), NewVarSymbol("COUNT", integerType)}, stringType))
+	globalScope.Define(NewBuiltinFunctionSymbol("POS",
+		[]*VarSymbol{NewVarSymbol("SUBSTR", stringType), NewVarSymbol("S", stringType)}, integerType))
+	globalScope.Define(NewBuiltinFunctionSymbol("CHR",
+		[]*VarSymbol{NewVarSymbol("I", integerType)}, charType))
+	// NOTE: ORD, SUCC and PRED take a value of any ordinal type, which can't
+	// be named in a program.
+	ordinalType := NewBuiltinTypeSymbol("ORDINAL")
+	globalScope.Define(NewBuiltinFunctionSymbol("ORD",
+		[]*VarSymbol{NewVarSymbol("X", ordinalType)}, integerType))
+	globalScope.Define(NewBuiltinFunctionSymbol("SUCC",
+		[]*VarSymbol{NewVarSymbol("X", ordinalType)}, ordinalType))
+	globalScope.Define(NewBuiltinFunctionSymbol("PRED",
+		[]*VarSymbol{NewVarSymbol("X", ordinalType)}, ordinalType))
+
+	return &SemanticAnalyzer{
+		currentScope: globalScope,
+		ordinalType:  ordinalType,
+		used:         make(map[Symbol]bool),
+		types:        make(map[ASTNode]Symbol),
+		loader:       searchPath(nil),
+		units:        make(map[string]*UnitNode),
+		parsed:       make(map[string]*UnitNode),
+		loading:      make(map[string]bool),
+	}
+}
+
+var _ Visitor = (*SemanticAnalyzer)(nil)
+
+// SemanticAnalyzer carries on after an error, all of them are returned
+// together once the ProgramNode is left. A symbol of unknown type gets a
+// nil type, which is accepted anywhere so as not to report errors twice.
+type SemanticAnalyzer struct {
+	currentScope *ScopedSymbolTable
+	// ordinalType is the type of the parameter of ORD, SUCC and PRED, it
+	// stands for INTEGER, CHAR, BOOLEAN and the enumerated types.
+	ordinalType Symbol
+	errors      ErrorList
+	// declarations are the variables and routines declared, in order, those
+	// never used are reported as warnings once the ProgramNode is left.
+	declarations []declaration
+	used         map[Symbol]bool
+	warnings     []Error
+	// types are the symbols of the array and record types, the variables
+	// declared together share them.
+	types map[ASTNode]Symbol
+	// resolved, if set, is called with the token of each name in the program
+	// and the symbol it stands for, declarations included.
+	resolved func(token *Token, symbol Symbol)
+	// loader reads the units the program uses. units are those analyzed by
+	// name, nil if they can't be used, in the order they're initialized,
+	// parsed those read already and loading those being analyzed, a unit
+	// used while it's analyzed depends on itself.
+	loader  unitLoader
+	units   map[string]*UnitNode
+	order   []*UnitNode
+	parsed  map[string]*UnitNode
+	loading map[string]bool
+}
+
+// declaration is the node declaring a variable or a routine, token is its name.
+type declaration struct {
+	node   ASTNode
+	symbol Symbol
+	token  *Token
+}
+
+func (s *SemanticAnalyzer) Before(node ASTNode) (shouldStepIn bool, err error) {
+	if shouldStepIn, err = s.before(node); err != nil {
+		s.errors = append(s.errors, err)
+		return false, nil
+	}
+	return
+}
+
+func (s *SemanticAnalyzer) After(node ASTNode) (err error) {
+	if err = s.after(node); err != nil {
+		s.errors = append(s.errors, err)
+	}
+	if _, ok := node.(*ProgramNode); ok {
+		s.warnUnused()
+		return s.errors.Err()
+	}
+	return nil
+}
+
+// Warnings returns the warnings about the program, once it's analyzed.
+func (s *SemanticAnalyzer) Warnings() []Error {
+	return s.warnings
+}
+
+// warnUnused reports the declarations of variables and routines that are
+// never used, parameters aren't reported.
+func (s *SemanticAnalyzer) warnUnused() {
+	for _, decl := range s.declarations {
+		if s.used[decl.symbol] {
+			continue
+		}
+		code := ErrorCodeUnusedRoutine
+		if _, ok := decl.symbol.(*VarSymbol); ok {
+			code = ErrorCodeUnusedVariable
+		}
+		warning := s.error(code, decl.token).(Error)
+		warning.Warning = true
+		s.warnings = append(s.warnings, warning)
+	}
+}
+
+// unused returns the nodes declaring what's never used.
+func (s *SemanticAnalyzer) unused() map[ASTNode]bool {
+	unused := make(map[ASTNode]bool)
+	for _, decl := range s.declarations {
+		if !s.used[decl.symbol] {
+			unused[decl.node] = true
+		}
+	}
+	return unused
+}
+
+// takeErrors returns the errors reported so far and forgets them, for
+// callers analyzing nodes outside of a ProgramNode.
+func (s *SemanticAnalyzer) takeErrors() error {
+	err := s.errors.Err()
+	s.errors = nil
+	return err
+}
+
+func (s *SemanticAnalyzer) before(node ASTNode) (shouldStepIn bool, err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		s.currentScope.Define(NewProcedureSymbol(n.name, nil))
+		s.useUnits(n)
+		enclosingScope := s.currentScope
+		if len(n.uses) > 0 {
+			enclosingScope = s.imports(n.uses)
+		}
+		s.currentScope = NewScopedSymbolTable(n.name, len(n.units)+1, enclosingScope)
+	case *UnitNode:
+		// NOTE: a unit analyzed on its own, rather than used by a program,
+		// uses its units first.
+		if n.level == 0 {
+			for _, token := range n.uses {
+				s.use(token)
+			}
+			n.level = len(s.order) + 1
+		}
+		s.currentScope = NewScopedSymbolTable(n.name, n.level, s.imports(n.uses))
+	case *ProcedureDeclNode:
+		if _, ok := s.currentScope.Lookup(n.name, true); ok {
+			err = s.error(ErrorCodeDuplicateId, n.token)
+			return
+		}
+		procedureScope := NewScopedSymbolTable(n.name, s.currentScope.level+1, s.currentScope)
+		procedureParamsSymbols := s.formalParams(procedureScope, n.params)
+		// NOTE: inject block sub-AST into procedure symbol for interpretation usage.
+		procedureSymbol := NewProcedureSymbol(n.name, procedureParamsSymbols)
+		procedureSymbol.SetBlockNode(n.block)
+		s.currentScope.Define(procedureSymbol)
+		s.declare(n, procedureSymbol, n.token)
+		s.resolve(n.token, procedureSymbol)
+		s.currentScope = procedureScope
+	case *FunctionDeclNode:
+		if _, ok := s.currentScope.Lookup(n.name, true); ok {
+			err = s.error(ErrorCodeDuplicateId, n.token)
+			return
+		}
+		returnType := s.resolveType(n.returnType, "")
+		functionScope := NewScopedSymbolTable(n.name, s.currentScope.level+1, s.currentScope)
+		functionParamsSymbols := s.formalParams(functionScope, n.params)
+		// NOTE: the function symbol is defined before its block is visited, so
+		// that the block can call the function recursively.
+		functionSymbol := NewFunctionSymbol(n.name, functionParamsSymbols, returnType)
+		functionSymbol.SetBlockNode(n.block)
+		s.currentScope.Define(functionSymbol)
+		s.declare(n, functionSymbol, n.token)
+		s.resolve(n.token, functionSymbol)
+		s.currentScope = functionScope
+	case *ConstDeclNode:
+		if _, ok := s.currentScope.Lookup(n.name, true); ok {
+			err = s.error(ErrorCodeDuplicateId, n.token)
+			return
+		}
+		if err = Walk(s, n.value); err != nil {
+			return
+		}
+		// NOTE: a constant whose value is unknown is still defined, so that
+		// its uses aren't reported too.
+		value, ok := constantValue(n.value)
+		constSymbol := NewConstSymbol(n.name, typeOf(n.value), value)
+		s.currentScope.Define(constSymbol)
+		s.resolve(n.token, constSymbol)
+		if !ok && typeOf(n.value) != nil {
+			err = s.error(ErrorCodeConstantExpected, n.token)
+		}
+		return false, err
+	case *TypeDeclNode:
+		if _, ok := s.currentScope.Lookup(n.name, true); ok {
+			err = s.error(ErrorCodeDuplicateId, n.token)
+			return
+		}
+		typSymbol := s.resolveType(n.typNode, n.name)
+		if typSymbol == nil {
+			return false, nil
+		}
+		n.typ = typSymbol
+		if _, ok := n.typNode.(*TypNode); ok {
+			typSymbol = NewTypeAliasSymbol(n.name, typSymbol)
+		}
+		s.currentScope.Define(typSymbol)
+		s.resolve(n.token, typSymbol)
+		return false, nil
+	case *ArrayTypeNode, *RecordTypeNode, *EnumTypeNode:
+		// resolved along with their declaration.
+		return false, nil
+	case *VarDeclNode:
+		typSymbol := s.resolveType(n.typNode, "")
+		// check duplicate definitions
+		if _, ok := s.currentScope.Lookup(n.varNode.value, true); ok {
+			err = s.error(ErrorCodeDuplicateId, n.varNode.token)
+			return
+		}
+		varSymbol := NewVarSymbol(n.varNode.value, typSymbol)
+		s.currentScope.Define(varSymbol)
+		s.declare(n, varSymbol, n.varNode.token)
+		s.resolve(n.varNode.token, varSymbol)
+		// NOTE: the declared variable is annotated here, walking it would
+		// count as a use.
+		n.varNode.typ = typSymbol
+		n.varNode.level = varSymbol.scopeLevel
+		return false, nil
+	case *VarNode:
+		symbol, ok := s.currentScope.Lookup(n.value, false)
+		if !ok {
+			err = s.error(ErrorCodeIdNotFound, n.token)
+			return
+		}
+		if constSymbol, ok := symbol.(*ConstSymbol); ok {
+			n.typ = constSymbol.typ
+			n.constant = constSymbol
+			s.resolve(n.token, constSymbol)
+			return
+		}
+		varSymbol, ok := symbol.(*VarSymbol)
+		if !ok {
+			// NOTE: a bare name is never a call, a function without
+			// parameters is called with empty parentheses.
+			e := s.error(ErrorCodeVariableExpected, n.token).(Error)
+			if _, ok := symbol.(*FunctionSymbol); ok {
+				e.Suggestion = fmt.Sprintf("%s is a function, call it as %s()", n.value, n.value)
+			}
+			err = e
+			return
+		}
+		n.typ = varSymbol.typ
+		n.level = varSymbol.scopeLevel
+		s.used[varSymbol] = true
+		s.resolve(n.token, varSymbol)
+	case *NumNode:
+		if n.token.Kind == IntegerConst {
+			n.typ = s.builtinType(Integer)
+		} else {
+			n.typ = s.builtinType(Real)
+		}
+	case *BoolNode:
+		n.typ = s.builtinType(Boolean)
+	case *StrNode:
+		// a literal of a single character is a CHAR, which widens into a STRING.
+		if utf8.RuneCountInString(n.value) == 1 {
+			n.typ = s.builtinType(Char)
+		} else {
+			n.typ = s.builtinType(String)
+		}
+	case *AssignNode:
+		if err = s.assignTarget(n.left); err != nil {
+			return
+		}
+		if err = Walk(s, n.right); err != nil {
+			return
+		}
+		if !s.assignable(typeOf(n.left), typeOf(n.right)) {
+			err = s.error(ErrorCodeTypeMismatch, n.token)
+			return
+		}
+		return false, nil
+	case *FunctionCallNode:
+		symbol, ok := s.currentScope.Lookup(n.name, false)
+		if !ok {
+			err = s.error(ErrorCodeIdNotFound, n.token)
+			return
+		}
+		s.resolve(n.token, symbol)
+		// NOTE: inject symbol info (signature) to AST.
+		switch sym := symbol.(type) {
+		case *FunctionSymbol:
+			n.funcSymbol = sym
+			s.used[sym] = true
+		case *BuiltinFunctionSymbol:
+			n.builtin = sym
+		default:
+			err = s.error(ErrorCodeNotCallable, n.token)
+			return
+		}
+		if formalParams, _ := signature(n); len(formalParams) != len(n.actualParams) {
+			err = s.error(ErrorCodeArgumentsMismatch, n.token)
+			return
+		}
+	case *ProcedureCallNode:
+		symbol, ok := s.currentScope.Lookup(n.name, false)
+		if !ok {
+			err = s.error(ErrorCodeIdNotFound, n.token)
+			return
+		}
+		s.resolve(n.token, symbol)
+		if builtinSymbol, ok := symbol.(*BuiltinProcedureSymbol); ok {
+			for _, actualParam := range n.actualParams {
+				if err = Walk(s, actualParam); err != nil {
+					return
+				}
+				if builtinSymbol.byReference {
+					if err = s.reference(actualParam, n.token); err != nil {
+						return
+					}
+				}
+				typ := typeOf(actualParam)
+				if builtinSymbol.byReference && typ != nil && !s.isNumeric(typ) && !s.isText(typ) {
+					err = s.error(ErrorCodeTypeMismatch, n.token)
+					return
+				}
+				// an ARRAY or a RECORD is neither written nor read as a whole.
+				if typ != nil && !s.isScalar(typ) {
+					err = s.error(ErrorCodeTypeMismatch, n.token)
+					return
+				}
+			}
+			n.builtin = builtinSymbol
+			return false, nil
+		}
+		procedureSymbol, ok := symbol.(*ProcedureSymbol)
+		if !ok {
+			err = s.error(ErrorCodeNotCallable, n.token)
+			return
+		}
+		if len(procedureSymbol.formalParams) != len(n.actualParams) {
+			err = s.error(ErrorCodeArgumentsMismatch, n.token)
+			return
+		}
+		for i, actualParam := range n.actualParams {
+			if err = Walk(s, actualParam); err != nil {
+				return
+			}
+			if err = s.argument(procedureSymbol.formalParams[i], actualParam, n.token); err != nil {
+				return
+			}
+		}
+		// NOTE: inject symbol info (signature) to AST.
+		n.procSymbol = procedureSymbol
+		s.used[procedureSymbol] = true
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *SemanticAnalyzer) after(node ASTNode) (err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		s.currentScope = s.currentScope.enclosingScope
+		if len(n.uses) > 0 {
+			s.currentScope = s.currentScope.enclosingScope
+		}
+	case *UnitNode:
+		s.implement(n)
+		n.exports = s.exports(n)
+		s.currentScope = s.currentScope.enclosingScope.enclosingScope
+	case *ProcedureDeclNode:
+		s.currentScope = s.currentScope.enclosingScope
+	case *FunctionDeclNode:
+		s.currentScope = s.currentScope.enclosingScope
+	case *UnaryOpNode:
+		operandType := typeOf(n.operand)
+		switch {
+		case operandType == nil:
+		case n.op == Not && operandType == s.builtinType(Boolean):
+		case n.op != Not && s.isNumeric(operandType):
+		default:
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+		n.typ = operandType
+	case *BinOpNode:
+		left, right := typeOf(n.left), typeOf(n.right)
+		if left == nil || right == nil {
+			return
+		}
+		if n.typ = s.binOpType(n.op, left, right); n.typ == nil {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+	case *IndexNode:
+		baseType := typeOf(n.base)
+		if baseType == nil {
+			return
+		}
+		arrayType, ok := baseType.(*ArrayTypeSymbol)
+		if !ok || !s.is(typeOf(n.index), Integer) {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+		n.typ = arrayType.elemType
+	case *FieldNode:
+		baseType := typeOf(n.base)
+		if baseType == nil {
+			return
+		}
+		recordType, ok := baseType.(*RecordTypeSymbol)
+		if !ok {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+		if n.index = recordType.Field(n.field); n.index < 0 {
+			return s.error(ErrorCodeUnknownField, n.token)
+		}
+		n.typ = recordType.fields[n.index].typ
+		s.resolve(n.token, recordType.fields[n.index])
+	case *FunctionCallNode:
+		formalParams, returnType := signature(n)
+		for i, actualParam := range n.actualParams {
+			if err = s.argument(formalParams[i], actualParam, n.token); err != nil {
+				return
+			}
+		}
+		n.typ = returnType
+		// SUCC and PRED return a value of the type of their argument.
+		if returnType == s.ordinalType {
+			n.typ = typeOf(n.actualParams[0])
+		}
+	case *IfNode:
+		if !s.is(typeOf(n.condition), Boolean) {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+	case *WhileNode:
+		if !s.is(typeOf(n.condition), Boolean) {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+	case *RepeatNode:
+		if !s.is(typeOf(n.condition), Boolean) {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+	case *ForNode:
+		if n.varNode.constant != nil {
+			return s.error(ErrorCodeConstantAssignment, n.varNode.token)
+		}
+		if !s.is(typeOf(n.varNode), Integer) || !s.is(typeOf(n.start), Integer) || !s.is(typeOf(n.end), Integer) {
+			return s.error(ErrorCodeTypeMismatch, n.token)
+		}
+	default:
+	}
+	return
+}
+
+// binOpType returns the type of a binary operation on the given operand
+// types, or nil if the operator doesn't apply to them.
+func (s *SemanticAnalyzer) binOpType(op TokenKind, left, right Symbol) Symbol {
+	integerType, realType, booleanType := s.builtinType(Integer), s.builtinType(Real), s.builtinType(Boolean)
+	switch op {
+	case Plus:
+		// + also concatenates strings and chars.
+		if s.isText(left) && s.isText(right) {
+			return s.builtinType(String)
+		}
+		fallthrough
+	case Minus, Mul:
+		if left == integerType && right == integerType {
+			return integerType
+		}
+		if s.isNumeric(left) && s.isNumeric(right) {
+			return realType
+		}
+	case FloatDiv:
+		if s.isNumeric(left) && s.isNumeric(right) {
+			return realType
+		}
+	case IntegerDiv:
+		if left == integerType && right == integerType {
+			return integerType
+		}
+	case And, Or:
+		if left == booleanType && right == booleanType {
+			return booleanType
+		}
+	case Equal, NotEqual, Less, LessEqual, Greater, GreaterEqual:
+		if (s.isNumeric(left) && s.isNumeric(right)) || (s.isText(left) && s.isText(right)) ||
+			(left == right && s.isScalar(left)) {
+			return booleanType
+		}
+	}
+	return nil
+}
+
+// assignable reports whether a value of type from can be stored into a
+// variable of type to, an INTEGER is widened into a REAL and a CHAR into
+// a STRING.
+func (s *SemanticAnalyzer) assignable(to, from Symbol) bool {
+	if to == nil || from == nil {
+		return true
+	}
+	return to == from ||
+		(to == s.builtinType(Real) && from == s.builtinType(Integer)) ||
+		(to == s.builtinType(String) && from == s.builtinType(Char))
+}
+
+// argument checks an actual parameter of the call at token against its
+// formal parameter, a VAR parameter takes a variable, an element or a field
+// of the very same type.
+func (s *SemanticAnalyzer) argument(formalParam *VarSymbol, actualParam ASTNode, token *Token) error {
+	if formalParam.typ == s.ordinalType {
+		if typ := typeOf(actualParam); typ != nil && !s.isOrdinal(typ) {
+			return s.error(ErrorCodeTypeMismatch, token)
+		}
+		return nil
+	}
+	if !formalParam.byReference {
+		if !s.assignable(formalParam.typ, typeOf(actualParam)) {
+			return s.error(ErrorCodeTypeMismatch, token)
+		}
+		return nil
+	}
+	if err := s.reference(actualParam, token); err != nil {
+		return err
+	}
+	if typ := typeOf(actualParam); formalParam.typ != nil && typ != nil && typ != formalParam.typ {
+		return s.error(ErrorCodeTypeMismatch, token)
+	}
+	return nil
+}
+
+// is reports whether typ is the builtin type of kind, or unknown.
+func (s *SemanticAnalyzer) is(typ Symbol, kind TokenKind) bool {
+	return typ == nil || typ == s.builtinType(kind)
+}
+
+// isScalar reports whether typ is a builtin or an enumerated type, as
+// opposed to an ARRAY or a RECORD.
+func (s *SemanticAnalyzer) isScalar(typ Symbol) bool {
+	switch typ.(type) {
+	case *BuiltinTypeSymbol, *EnumTypeSymbol:
+		return true
+	}
+	return false
+}
+
+// isOrdinal reports whether the values of typ are counted: INTEGER, CHAR,
+// BOOLEAN and the enumerated types.
+func (s *SemanticAnalyzer) isOrdinal(typ Symbol) bool {
+	if _, ok := typ.(*EnumTypeSymbol); ok {
+		return true
+	}
+	return typ == s.builtinType(Integer) || typ == s.builtinType(Char) || typ == s.builtinType(Boolean)
+}
+
+func (s *SemanticAnalyzer) isNumeric(typ Symbol) bool {
+	return typ != nil && (typ == s.builtinType(Integer) || typ == s.builtinType(Real))
+}
+
+func (s *SemanticAnalyzer) isText(typ Symbol) bool {
+	return typ != nil && (typ == s.builtinType(String) || typ == s.builtinType(Char))
+}
+
+func (s *SemanticAnalyzer) builtinType(kind TokenKind) Symbol {
+	typ, _ := s.currentScope.Lookup(TokenNames[kind], false)
+	return typ
+}
+
+// typeOf returns the type the SemanticAnalyzer annotated an expression node with.
+func typeOf(node ASTNode) Symbol {
+	switch n := node.(type) {
+	case *NumNode:
+		return n.typ
+	case *BoolNode:
+		return n.typ
+	case *StrNode:
+		return n.typ
+	case *VarNode:
+		return n.typ
+	case *UnaryOpNode:
+		return n.typ
+	case *BinOpNode:
+		return n.typ
+	case *FunctionCallNode:
+		return n.typ
+	case *IndexNode:
+		return n.typ
+	case *FieldNode:
+		return n.typ
+	}
+	return nil
+}
+
+// signature returns the formal parameters and the return type of the
+// function called, a builtin one or not.
+func signature(n *FunctionCallNode) (formalParams []*VarSymbol, returnType Symbol) {
+	if n.builtin != nil {
+		return n.builtin.formalParams, n.builtin.returnType
+	}
+	return n.funcSymbol.formalParams, n.funcSymbol.returnType
+}
+
+// reference checks an actual parameter passed by reference: a variable, an
+// element or a field of one, but not a constant.
+func (s *SemanticAnalyzer) reference(actualParam ASTNode, token *Token) error {
+	if !isVariable(actualParam) {
+		return s.error(ErrorCodeInvalidAssignment, token)
+	}
+	if varNode, ok := actualParam.(*VarNode); ok && varNode.constant != nil {
+		return s.error(ErrorCodeConstantAssignment, varNode.token)
+	}
+	return nil
+}
+
+// isVariable reports whether node denotes a variable, an element of an
+// ARRAY variable or a field of a RECORD variable.
+func isVariable(node ASTNode) bool {
+	switch n := node.(type) {
+	case *VarNode:
+		return true
+	case *IndexNode:
+		return isVariable(n.base)
+	case *FieldNode:
+		return isVariable(n.base)
+	}
+	return false
+}
+
+// assignTarget annotates the left side of an assignment: a variable, an
+// element or a field of one, or the name of the function being defined.
+func (s *SemanticAnalyzer) assignTarget(node ASTNode) (err error) {
+	varNode, ok := node.(*VarNode)
+	if !ok {
+		// errors in an element or a field are recorded as the node is walked.
+		return Walk(s, node)
+	}
+	symbol, ok := s.currentScope.Lookup(varNode.value, false)
+	if !ok {
+		return s.error(ErrorCodeIdNotFound, varNode.token)
+	}
+	s.resolve(varNode.token, symbol)
+	switch sym := symbol.(type) {
+	case *ConstSymbol:
+		return s.error(ErrorCodeConstantAssignment, varNode.token)
+	case *VarSymbol:
+		varNode.typ = sym.typ
+		varNode.level = sym.scopeLevel
+		s.used[sym] = true
+	case *FunctionSymbol:
+		// the result of a function is set by assigning to its name inside its block.
+		if !s.insideOf(sym) {
+			return s.error(ErrorCodeInvalidAssignment, varNode.token)
+		}
+		varNode.typ = sym.returnType
+		// the result lives in the record of the function's call.
+		varNode.level = sym.scopeLevel + 1
+	default:
+		return s.error(ErrorCodeInvalidAssignment, varNode.token)
+	}
+	return
+}
+
+// resolveType returns the type symbol a type node denotes, an ARRAY or a
+// RECORD gets the name of its TYPE declaration. Errors are recorded and
+// an unknown type is nil.
+func (s *SemanticAnalyzer) resolveType(node ASTNode, name string) Symbol {
+	switch n := node.(type) {
+	case *TypNode:
+		symbol, _ := s.currentScope.Lookup(n.value, false)
+		if symbol != nil {
+			s.resolve(n.token, symbol)
+		}
+		switch sym := symbol.(type) {
+		case *TypeAliasSymbol:
+			return sym.typ
+		case *BuiltinTypeSymbol, *ArrayTypeSymbol, *RecordTypeSymbol, *EnumTypeSymbol:
+			return sym
+		}
+		s.errors = append(s.errors, s.error(ErrorCodeUnknownDataType, n.token))
+	case *ArrayTypeNode:
+		if typ, ok := s.types[n]; ok {
+			return typ
+		}
+		elemType := s.resolveType(n.elemType, "")
+		low, high := s.constant(n.low), s.constant(n.high)
+		if high < low {
+			s.errors = append(s.errors, s.error(ErrorCodeIndexOutOfRange, n.token))
+			return nil
+		}
+		if elemType == nil {
+			return nil
+		}
+		s.types[n] = NewArrayTypeSymbol(name, low, high, elemType)
+		return s.types[n]
+	case *RecordTypeNode:
+		if typ, ok := s.types[n]; ok {
+			return typ
+		}
+		fields := make([]*VarSymbol, len(n.fields))
+		known := true
+		for i, field := range n.fields {
+			for _, previous := range fields[:i] {
+				if previous.name == field.varNode.value {
+					s.errors = append(s.errors, s.error(ErrorCodeDuplicateId, field.varNode.token))
+				}
+			}
+			fieldType := s.resolveType(field.typNode, "")
+			known = known && fieldType != nil
+			fields[i] = NewVarSymbol(field.varNode.value, fieldType)
+			s.resolve(field.varNode.token, fields[i])
+		}
+		if !known {
+			return nil
+		}
+		s.types[n] = NewRecordTypeSymbol(name, fields)
+		return s.types[n]
+	case *EnumTypeNode:
+		if typ, ok := s.types[n]; ok {
+			return typ
+		}
+		values := make([]string, len(n.values))
+		for i, token := range n.values {
+			values[i] = token.Value
+		}
+		enumType := NewEnumTypeSymbol(name, values)
+		// the values are constants of the scope the type is declared in.
+		for i, token := range n.values {
+			if _, ok := s.currentScope.Lookup(token.Value, true); ok {
+				s.errors = append(s.errors, s.error(ErrorCodeDuplicateId, token))
+				continue
+			}
+			constSymbol := NewConstSymbol(token.Value, enumType, EnumValue{typ: enumType, ordinal: int64(i)})
+			s.currentScope.Define(constSymbol)
+			s.resolve(token, constSymbol)
+		}
+		s.types[n] = enumType
+		return enumType
+	}
+	return nil
+}
+
+// constant evaluates the bound of an ARRAY, a signed INTEGER_CONST or the
+// name of an INTEGER constant. Errors are recorded and the bound is then 0.
+func (s *SemanticAnalyzer) constant(node ASTNode) int64 {
+	if err := Walk(s, node); err != nil {
+		s.errors = append(s.errors, err)
+		return 0
+	}
+	value, ok := constantValue(node)
+	if bound, isInt := value.(int64); ok && isInt {
+		return bound
+	}
+	if varNode, isVar := node.(*VarNode); isVar && typeOf(node) != nil {
+		s.errors = append(s.errors, s.error(ErrorCodeConstantExpected, varNode.token))
+	}
+	return 0
+}
+
+// constantValue evaluates an expression of literals and constants, ok is
+// false if it isn't one or it can't be evaluated, e.g. a division by zero.
+func constantValue(node ASTNode) (value interface{}, ok bool) {
+	switch n := node.(type) {
+	case *NumNode, *BoolNode, *StrNode:
+		return literal(n)
+	case *VarNode:
+		if n.constant == nil || n.constant.value == nil {
+			return nil, false
+		}
+		return n.constant.value, true
+	case *UnaryOpNode:
+		operand, ok := constantValue(n.operand)
+		if !ok || n.typ == nil {
+			return nil, false
+		}
+		switch n.op {
+		case Minus:
+			return negate(operand), true
+		case Not:
+			return !operand.(bool), true
+		}
+		return operand, true
+	case *BinOpNode:
+		lhs, ok := constantValue(n.left)
+		if !ok {
+			return nil, false
+		}
+		rhs, ok := constantValue(n.right)
+		if !ok || n.typ == nil || ((n.op == IntegerDiv || n.op == FloatDiv) && isZero(rhs)) {
+			return nil, false
+		}
+		switch n.op {
+		case And:
+			return lhs.(bool) && rhs.(bool), true
+		case Or:
+			return lhs.(bool) || rhs.(bool), true
+		}
+		return binaryOp(n.op, lhs, rhs), true
+	}
+	return nil, false
+}
+
+// formalParams defines the formal parameters in the scope of their procedure or function.
+func (s *SemanticAnalyzer) formalParams(scope *ScopedSymbolTable, params []*ParamNode) (symbols []*VarSymbol) {
+	for _, param := range params {
+		typSymbol := s.resolveType(param.typNode, "")
+		varSymbol := NewVarSymbol(param.varNode.value, typSymbol)
+		varSymbol.byReference = param.byReference
+		// NOTE: a duplicate still counts as a parameter, so that the calls
+		// aren't reported too.
+		symbols = append(symbols, varSymbol)
+		if _, ok := scope.Lookup(varSymbol.name, true); ok {
+			s.errors = append(s.errors, s.error(ErrorCodeDuplicateId, param.varNode.token))
+			continue
+		}
+		scope.Define(varSymbol)
+		s.resolve(param.varNode.token, varSymbol)
+	}
+	return
+}
+
+// declare records the declaration of a variable or a routine in a block.
+func (s *SemanticAnalyzer) declare(node ASTNode, symbol Symbol, token *Token) {
+	s.declarations = append(s.declarations, declaration{node: node, symbol: symbol, token: token})
+}
+
+func (s *SemanticAnalyzer) resolve(token *Token, symbol Symbol) {
+	if s.resolved != nil {
+		s.resolved(token, symbol)
+	}
+}
+
+// insideOf reports whether the current scope is the one of the function or nested in it.
+func (s *SemanticAnalyzer) insideOf(fs *FunctionSymbol) bool {
+	for scope := s.currentScope; scope != nil; scope = scope.enclosingScope {
+		if scope.level == fs.scopeLevel+1 && scope.name == fs.name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SemanticAnalyzer) error(code ErrorCode, token *Token) error {
+	return Error{
+		Code:       code,
+		Module:     ModuleSemanticAnalyzer,
+		Message:    fmt.Sprintf("token:%s", token),
+		Span:       token.Span(),
+		Suggestion: s.suggestion(code, token),
+	}
+}
+
+func (s *SemanticAnalyzer) suggestion(code ErrorCode, token *Token) string {
+	switch code {
+	case ErrorCodeIdNotFound:
+		return fmt.Sprintf("declare %s before using it", token.Value)
+	case ErrorCodeDuplicateId:
+		return fmt.Sprintf("%s is already declared in this scope, rename it", token.Value)
+	case ErrorCodeUnknownDataType:
+		return fmt.Sprintf("declare %s in a TYPE section, or use one of INTEGER, REAL, BOOLEAN, STRING, CHAR", token.Value)
+	case ErrorCodeArgumentsMismatch:
+		return fmt.Sprintf("pass as many arguments as %s declares parameters", token.Value)
+	case ErrorCodeNotCallable:
+		return fmt.Sprintf("%s is not a procedure or a function", token.Value)
+	case ErrorCodeInvalidAssignment:
+		return "only variables, or a function's name inside its own block, can be assigned or passed to a VAR parameter"
+	case ErrorCodeVariableExpected:
+		return fmt.Sprintf("%s is not a variable", token.Value)
+	case ErrorCodeTypeMismatch:
+		return "check the operand types, a REAL is never converted implicitly into an INTEGER, nor a STRING into a CHAR"
+	case ErrorCodeUnknownField:
+		return fmt.Sprintf("the record has no field %s", token.Value)
+	case ErrorCodeIndexOutOfRange:
+		return "the low bound of an array must not exceed its high bound"
+	case ErrorCodeUnusedVariable, ErrorCodeUnusedRoutine:
+		return fmt.Sprintf("%s is declared but never used, remove it", token.Value)
+	case ErrorCodeConstantAssignment:
+		return fmt.Sprintf("%s is a constant, declare it in a VAR section to change it", token.Value)
+	case ErrorCodeConstantExpected:
+		return "use literals and constants only, the value must be known before the program runs"
+	case ErrorCodeCircularDependency:
+		return fmt.Sprintf("%s is used by a unit it uses, move what they share into a unit of its own", token.Value)
+	}
+	return ""
+}
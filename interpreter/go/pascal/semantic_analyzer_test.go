@@ -0,0 +1,737 @@
+package pascal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemanticAnalyzer_Visit(t *testing.T) {
+	tests := map[string]struct {
+		givenSource      string
+		wantError        bool
+		wantErrorMessage string
+		wantSuggestion   string
+	}{
+		"part13: var declarations only": {
+			givenSource: `
+				program SymTab2;
+					var x, y : INTEGER;
+				begin
+				end.
+			`,
+		},
+		"part13: declarations + references": {
+			givenSource: `
+				program SymTab4;
+					var x, y : integer;
+
+				begin
+					x := x + y;
+				end.
+			`,
+		},
+		"part13: reference without declaration": {
+			givenSource: `
+				program SymTab5;
+				var x : integer;
+				begin
+					x := y;
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=Y,pos=(5,11))">`,
+		},
+		"part13: duplication declarations": {
+			givenSource: `
+				program SymTab6;
+				var
+					x, y : integer;
+					y : real;
+				begin
+					x := x + y;
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=DuplicateId,message="token:(kind=ID,value=Y,pos=(5,6))">`,
+		},
+		"part14: nested scopes": {
+			givenSource: `
+				program Main;
+					var x, y: real;
+					procedure Alpha(a : integer);
+						var y : integer;
+					begin
+						x := a + x + y;
+					end;
+				begin { Main }
+				end.  { Main }
+			`,
+		},
+		"part16: actualParams mismatch case 1": {
+			givenSource: `
+				program Main;
+					 procedure Alpha(a : integer; b : integer);
+						 var x : integer;
+					 begin
+						 x := (a + b) * 2;
+					 end;
+				begin { Main }
+					Alpha(1, 3 + 5, 7);  { procedure call }
+				end.  { Main }`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ArgumentsMismatch,message="token:(kind=ID,value=ALPHA,pos=(9,6))">`,
+		},
+		"part16: actualParams mismatch case 2": {
+			givenSource: `
+				program Main;
+					 procedure Alpha(a : integer; b : integer);
+						 var x : integer;
+					 begin
+						 x := (a + b) * 2;
+					 end;
+				begin { Main }
+					Alpha(1);  { procedure call }
+				end.  { Main }`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ArgumentsMismatch,message="token:(kind=ID,value=ALPHA,pos=(9,6))">`,
+		},
+		"if statement with boolean variables": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					var done : boolean;
+				begin
+					if (x > 0) and not done then
+						done := true
+					else
+						x := x + 1;
+				end.
+			`,
+		},
+		"if statement referencing undeclared variable": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					if x = y then
+						x := 1;
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=Y,pos=(5,13))">`,
+		},
+		"loops": {
+			givenSource: `
+				program Main;
+					var i, sum : integer;
+				begin
+					for i := 1 to 10 do
+						sum := sum + i;
+					while sum > 0 do
+						sum := sum - 1;
+					repeat
+						i := i - 1
+					until i = 0
+				end.
+			`,
+		},
+		"for loop with undeclared variable": {
+			givenSource: `
+				program Main;
+					var sum : integer;
+				begin
+					for i := 1 to 10 do
+						sum := sum + 1;
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=I,pos=(5,10))">`,
+		},
+		"recursive function": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Fact(n : integer): integer;
+					begin
+						if n <= 1 then Fact := 1 else Fact := n * Fact(n - 1)
+					end;
+				begin
+					x := Fact(5)
+				end.
+			`,
+		},
+		"function arguments mismatch": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Id(n : integer): integer;
+					begin
+						Id := n
+					end;
+				begin
+					x := Id(1, 2)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ArgumentsMismatch,message="token:(kind=ID,value=ID,pos=(9,11))">`,
+		},
+		"procedure called as function": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					procedure Alpha(a : integer);
+					begin
+					end;
+				begin
+					x := Alpha(1)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=NotCallable,message="token:(kind=ID,value=ALPHA,pos=(8,11))">`,
+		},
+		"variable called as procedure": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					x(1)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=NotCallable,message="token:(kind=ID,value=X,pos=(5,6))">`,
+		},
+		"assign to function name outside of its block": {
+			givenSource: `
+				program Main;
+					function Id(n : integer): integer;
+					begin
+						Id := n
+					end;
+				begin
+					Id := 1
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=InvalidAssignment,message="token:(kind=ID,value=ID,pos=(8,6))">`,
+		},
+		"var parameters of elements and fields": {
+			givenSource: `
+				program Main;
+					var a : array[1..3] of integer;
+					    r : record x : integer; y : real end;
+					procedure Clear(var n : integer);
+					begin
+						n := 0
+					end;
+				begin
+					Clear(a[1]);
+					Clear(r.x);
+					Clear(r.y);
+					Clear(a[1] + 1)
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=ID,value=CLEAR,pos=(12,6))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=InvalidAssignment,message="token:(kind=ID,value=CLEAR,pos=(13,6))">`,
+		},
+		"builtin procedures": {
+			givenSource: `
+				program Main;
+					var x, y : integer;
+				begin
+					readln(x, y);
+					write(x, y + 1);
+					writeln;
+				end.
+			`,
+		},
+		"readln into an expression": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					readln(x + 1);
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=InvalidAssignment,message="token:(kind=ID,value=READLN,pos=(5,6))">`,
+		},
+		"writeln with undeclared variable": {
+			givenSource: `
+				program Main;
+				begin
+					writeln(y);
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=Y,pos=(4,14))">`,
+		},
+		"integer widened into real": {
+			givenSource: `
+				program Main;
+					var i : integer; r : real;
+				begin
+					i := 7 div 2;
+					r := i * 2 + 0.5;
+					r := i
+				end.
+			`,
+		},
+		"assign real to integer": {
+			givenSource: `
+				program Main;
+					var i : integer;
+				begin
+					i := 7 / 2
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=:=,value=:=,pos=(5,8))">`,
+		},
+		"div on reals": {
+			givenSource: `
+				program Main;
+					var r : real;
+				begin
+					r := r div 2
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=DIV,value=DIV,pos=(5,13))">`,
+		},
+		"non boolean condition": {
+			givenSource: `
+				program Main;
+				begin
+					if 1 then writeln(1)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=IF,value=IF,pos=(4,6))">`,
+		},
+		"real argument for integer parameter": {
+			givenSource: `
+				program Main;
+					procedure Alpha(a : integer);
+					begin
+					end;
+				begin
+					Alpha(1.5)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=ID,value=ALPHA,pos=(7,6))">`,
+		},
+		"expression for var parameter": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					procedure Inc(var n : integer);
+					begin
+						n := n + 1
+					end;
+				begin
+					Inc(x + 1)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=InvalidAssignment,message="token:(kind=ID,value=INC,pos=(9,6))">`,
+		},
+		"integer variable for real var parameter": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Half(var r : real): real;
+					begin
+						Half := r / 2
+					end;
+				begin
+					writeln(Half(x))
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=ID,value=HALF,pos=(9,14))">`,
+		},
+		"arrays and records": {
+			givenSource: `
+				program Main;
+					type
+						Count = integer;
+						Vec = array[1..3] of Count;
+						Pair = record first, second : Vec end;
+					var p, q : Pair; n : Count; a, b : array[0..1] of real;
+				begin
+					p.first[1] := 1;
+					n := p.first[1] + 1;
+					q := p;
+					a := b;
+					a[n] := n
+				end.
+			`,
+		},
+		"strings and chars": {
+			givenSource: `
+				program Main;
+					var s : string; c : char; i : integer; ok : boolean;
+				begin
+					c := 'a';
+					s := c;
+					s := s + c + 'bc';
+					ok := (s < c) and (c = 'a');
+					i := length(c) + pos('b', s) + ord(c);
+					c := chr(i);
+					s := copy(s, 1, i)
+				end.
+			`,
+		},
+		"string assigned to a char": {
+			givenSource: `
+				program Main;
+					var c : char;
+				begin
+					c := 'ab'
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=:=,value=:=,pos=(5,8))">`,
+		},
+		"string plus integer": {
+			givenSource: `
+				program Main;
+					var s : string;
+				begin
+					s := 'a' + 1
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=+,value=+,pos=(5,15))">`,
+		},
+		"builtin function arguments": {
+			givenSource: `
+				program Main;
+					var i : integer;
+				begin
+					i := length('a', 'b');
+					i := ord(1.5)
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ArgumentsMismatch,message="token:(kind=ID,value=LENGTH,pos=(5,11))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=ID,value=ORD,pos=(6,11))">`,
+		},
+		"unknown field": {
+			givenSource: `
+				program Main;
+					var p : record x, y : integer end;
+				begin
+					p.z := 1
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=UnknownField,message="token:(kind=ID,value=Z,pos=(5,8))">`,
+		},
+		"index of a non array": {
+			givenSource: `
+				program Main;
+					var x : integer;
+				begin
+					x := x[1]
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=[,value=[,pos=(5,12))">`,
+		},
+		"real index": {
+			givenSource: `
+				program Main;
+					var a : array[1..2] of integer;
+				begin
+					a[1.5] := 1
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=[,value=[,pos=(5,7))">`,
+		},
+		"empty array bounds": {
+			givenSource: `
+				program Main;
+					type Empty = array[1..0] of integer;
+				begin
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=IndexOutOfRange,message="token:(kind=ARRAY,value=ARRAY,pos=(3,19))">`,
+		},
+		"distinct array types": {
+			givenSource: `
+				program Main;
+					var a : array[1..2] of integer;
+					    b : array[1..2] of integer;
+				begin
+					a := b
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=:=,value=:=,pos=(6,8))">`,
+		},
+		"writeln of a record": {
+			givenSource: `
+				program Main;
+					var p : record x : integer end;
+				begin
+					writeln(p)
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=ID,value=WRITELN,pos=(5,6))">`,
+		},
+		"variable used as type": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					    y : x;
+				begin
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=UnknownDataType,message="token:(kind=ID,value=X,pos=(4,14))">`,
+		},
+		"procedure used as variable": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					procedure Alpha;
+					begin
+					end;
+				begin
+					x := Alpha + 1
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=VariableExpected,message="token:(kind=ID,value=ALPHA,pos=(8,11))">`,
+		},
+		"every error is reported": {
+			givenSource: `
+				program Main;
+					var a, b : integer;
+				begin
+					a := d + 1;
+					b := c;
+					b := 1.5;
+					if b then b := 1
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=D,pos=(5,11))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=IdNotFound,message="token:(kind=ID,value=C,pos=(6,11))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=:=,value=:=,pos=(7,8))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=IF,value=IF,pos=(8,6))">`,
+		},
+		"constants and enumerated types": {
+			givenSource: `
+				program Main;
+					const Size = 3; Name = 'x';
+					type Color = (Red, Green, Blue);
+					var a : array[1..Size] of Color; c : Color; i : integer;
+				begin
+					for i := 1 to Size do a[i] := Red;
+					c := succ(a[Size]);
+					i := ord(c) + length(Name);
+					if (c > Green) and (pred(c) = Red) then writeln(c, Name)
+				end.
+			`,
+		},
+		"assignment to a constant": {
+			givenSource: `
+				program Main;
+					const Size = 3;
+				begin
+					Size := 4
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ConstantAssignment,message="token:(kind=ID,value=SIZE,pos=(5,6))">`,
+		},
+		"constant as a variable argument": {
+			givenSource: `
+				program Main;
+					type Color = (Red, Green);
+					var i : integer;
+					procedure Reset(var c : Color);
+					begin
+						c := Red
+					end;
+				begin
+					for Green := Red to Green do;
+					readln(i, Green);
+					Reset(Green)
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ConstantAssignment,message="token:(kind=ID,value=GREEN,pos=(10,10))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=ConstantAssignment,message="token:(kind=ID,value=GREEN,pos=(11,16))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=ConstantAssignment,message="token:(kind=ID,value=GREEN,pos=(12,12))">`,
+		},
+		"constant from a variable": {
+			givenSource: `
+				program Main;
+					var i : integer;
+					const Size = i + 1;
+				begin
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=ConstantExpected,message="token:(kind=ID,value=SIZE,pos=(4,12))">`,
+		},
+		"duplicate enumerated value": {
+			givenSource: `
+				program Main;
+					type Color = (Red, Green);
+					     Light = (Green, Amber);
+				begin
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=DuplicateId,message="token:(kind=ID,value=GREEN,pos=(4,20))">`,
+		},
+		"duplicate parameters": {
+			givenSource: `
+				program Main;
+					procedure P(a, a : integer);
+					begin
+					end;
+					function F(b : integer; b : real) : integer;
+					begin
+						F := 1
+					end;
+				begin
+					P(1, 2);
+					P(F(1, 2.0), 3)
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=DuplicateId,message="token:(kind=ID,value=A,pos=(3,21))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=DuplicateId,message="token:(kind=ID,value=B,pos=(6,30))">`,
+		},
+		"routine named like a variable": {
+			givenSource: `
+				program Main;
+					var P, F : integer;
+					procedure P;
+					begin
+					end;
+					function F : integer;
+					begin
+						F := 1
+					end;
+				begin
+					P := F
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=DuplicateId,message="token:(kind=ID,value=P,pos=(4,16))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=DuplicateId,message="token:(kind=ID,value=F,pos=(7,15))">`,
+		},
+		"parameterless function called with parentheses": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Zero : integer;
+					begin
+						Zero := 0
+					end;
+				begin
+					x := Zero() + 1
+				end.
+			`,
+		},
+		"parameterless function named without parentheses": {
+			givenSource: `
+				program Main;
+					var x : integer;
+					function Zero : integer;
+					begin
+						Zero := 0
+					end;
+				begin
+					x := Zero
+				end.
+			`,
+			wantError:        true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=VariableExpected,message="token:(kind=ID,value=ZERO,pos=(9,11))">`,
+			wantSuggestion:   "ZERO is a function, call it as ZERO()",
+		},
+		"ordinal functions of a non ordinal": {
+			givenSource: `
+				program Main;
+					type Color = (Red, Green);
+					var c : Color; r : real;
+				begin
+					r := succ(1.5);
+					c := ord(Red);
+					c := Red + Green
+				end.
+			`,
+			wantError: true,
+			wantErrorMessage: `<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=ID,value=SUCC,pos=(6,11))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=:=,value=:=,pos=(7,8))">` + "\n" +
+				`<Error: module=SemanticAnalyzer,code=TypeMismatch,message="token:(kind=+,value=+,pos=(8,15))">`,
+		},
+		// TODO: when we support type definition syntax
+		//"declare a symbol with unknown type": {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			lexer := NewLexer(tc.givenSource)
+			parser, err := NewParser(lexer)
+			assert.NoError(t, err)
+			root, err := parser.Parse()
+			assert.NoError(t, err)
+			analyzer := NewSemanticAnalyzer()
+			err = Walk(analyzer, root)
+			if tc.wantError {
+				assert.Error(t, err)
+				assert.Equal(t, tc.wantErrorMessage, err.Error())
+				if tc.wantSuggestion != "" {
+					if errs, ok := err.(ErrorList); assert.True(t, ok) {
+						assert.Equal(t, tc.wantSuggestion, errs[0].(Error).Suggestion)
+					}
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSemanticAnalyzer_Warnings(t *testing.T) {
+	root, err := parse(`
+		program Main;
+			var x, y : integer;
+			procedure Unused;
+			begin
+			end;
+			function Twice(a : integer) : integer;
+			begin
+				Twice := a * 2
+			end;
+		begin
+			x := Twice(1)
+		end.
+	`)
+	assert.NoError(t, err)
+	analyzer := NewSemanticAnalyzer()
+	assert.NoError(t, Walk(analyzer, root))
+
+	var warnings []string
+	for _, warning := range analyzer.Warnings() {
+		assert.True(t, warning.Warning)
+		warnings = append(warnings, warning.Error())
+	}
+	assert.Equal(t, []string{
+		`<Error: module=SemanticAnalyzer,code=UnusedVariable,message="token:(kind=ID,value=Y,pos=(3,11))">`,
+		`<Error: module=SemanticAnalyzer,code=UnusedRoutine,message="token:(kind=ID,value=UNUSED,pos=(4,14))">`,
+	}, warnings)
+}
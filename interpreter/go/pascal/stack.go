@@ -0,0 +1,245 @@
+package pascal
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ARKind int
+
+const (
+	ARKindProgram   ARKind = 1
+	ARKindProcedure ARKind = 2
+	ARKindFunction  ARKind = 3
+	ARKindUnit      ARKind = 4
+)
+
+func (k ARKind) String() string {
+	switch k {
+	case ARKindProgram:
+		return "PROGRAM"
+	case ARKindProcedure:
+		return "PROCEDURE"
+	case ARKindFunction:
+		return "FUNCTION"
+	case ARKindUnit:
+		return "UNIT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+type CallStack struct {
+	records []*ActivationRecord
+}
+
+func (cs *CallStack) Push(ar *ActivationRecord) {
+	cs.records = append(cs.records, ar)
+}
+
+func (cs *CallStack) Pop() (ar *ActivationRecord) {
+	ar = cs.records[len(cs.records)-1]
+	cs.records = cs.records[:len(cs.records)-1]
+	return
+}
+
+func (cs *CallStack) Peek() *ActivationRecord {
+	return cs.records[len(cs.records)-1]
+}
+
+func (cs *CallStack) Len() int {
+	return len(cs.records)
+}
+
+// Members returns the number of scalars the members of the records hold,
+// an ARRAY or a RECORD counting its elements or fields, a measure of the
+// memory the program uses.
+func (cs *CallStack) Members() (members int) {
+	for _, record := range cs.records {
+		for _, value := range record.Members {
+			members += sizeOfValue(value)
+		}
+	}
+	return
+}
+
+func (cs *CallStack) String() string {
+	var records = make([]string, len(cs.records))
+	for i, record := range cs.records {
+		records[i] = record.String()
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	sb := strings.Builder{}
+	sb.WriteString("CALL STACK\n")
+	sb.WriteString(strings.Join(records, "\n"))
+	sb.WriteRune('\n')
+	return sb.String()
+}
+
+// NewActivationRecord creates the record of a call, its StaticLink is set
+// by the caller.
+func NewActivationRecord(name string, kind ARKind, nestingLevel int) *ActivationRecord {
+	return &ActivationRecord{
+		Name:         name,
+		Kind:         kind,
+		NestingLevel: nestingLevel,
+		Members:      make(map[string]interface{}),
+	}
+}
+
+type ActivationRecord struct {
+	Name         string
+	Kind         ARKind
+	NestingLevel int
+	Members      map[string]interface{}
+	// StaticLink is the record of the routine this one is nested in, the
+	// variables of enclosing routines are reached through it.
+	StaticLink *ActivationRecord
+}
+
+// Enclosing follows the static links up to the record of the given nesting
+// level, or the outermost one.
+func (cs *ActivationRecord) Enclosing(level int) *ActivationRecord {
+	ar := cs
+	for ar.NestingLevel > level && ar.StaticLink != nil {
+		ar = ar.StaticLink
+	}
+	return ar
+}
+
+// Get returns the value of a member, following it if it's a Reference.
+func (cs *ActivationRecord) Get(key string) (value interface{}, ok bool) {
+	value, ok = cs.Members[key]
+	if ref, isRef := value.(*Reference); isRef {
+		if ref.slot != nil {
+			return *ref.slot, *ref.slot != nil
+		}
+		return ref.record.Get(ref.name)
+	}
+	return
+}
+
+// Set sets the value of a member, or of the variable it refers to.
+func (cs *ActivationRecord) Set(key string, value interface{}) {
+	if ref, isRef := cs.Members[key].(*Reference); isRef {
+		if ref.slot != nil {
+			*ref.slot = value
+			return
+		}
+		ref.record.Set(ref.name, value)
+		return
+	}
+	cs.Members[key] = value
+}
+
+// Reference returns a Reference to a member, a member that's already a
+// Reference is returned as is so that references never chain.
+func (cs *ActivationRecord) Reference(key string) *Reference {
+	if ref, isRef := cs.Members[key].(*Reference); isRef {
+		return ref
+	}
+	return &Reference{record: cs, name: key}
+}
+
+// Bind makes a member stand for the variable ref refers to.
+func (cs *ActivationRecord) Bind(key string, ref *Reference) {
+	cs.Members[key] = ref
+}
+
+func (cs *ActivationRecord) String() string {
+	lines := []string{
+		fmt.Sprintf("%d: %s %s",
+			cs.NestingLevel, cs.Kind.String(), cs.Name),
+	}
+
+	for key, value := range cs.Members {
+		lines = append(lines, fmt.Sprintf("\t%-20s: %v", key, value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Reference is the member of a VAR parameter, reads and writes go to the
+// variable passed by the caller, or to the slot of the element or the
+// field passed.
+type Reference struct {
+	record *ActivationRecord
+	name   string
+	slot   *interface{}
+}
+
+func (r *Reference) String() string {
+	if r.slot != nil {
+		return "-> " + formatMember(*r.slot)
+	}
+	return fmt.Sprintf("-> %s.%s", r.record.Name, r.name)
+}
+
+// ArrayValue is the storage of an ARRAY, nil elements are uninitialized.
+type ArrayValue struct {
+	low   int64
+	elems []interface{}
+}
+
+// Get returns the element at index, inRange is false if index is out of bounds.
+func (av *ArrayValue) Get(index int64) (value interface{}, inRange bool) {
+	if index < av.low || index >= av.low+int64(len(av.elems)) {
+		return nil, false
+	}
+	return av.elems[index-av.low], true
+}
+
+// Set sets the element at index, inRange is false if index is out of bounds.
+func (av *ArrayValue) Set(index int64, value interface{}) (inRange bool) {
+	if index < av.low || index >= av.low+int64(len(av.elems)) {
+		return false
+	}
+	av.elems[index-av.low] = value
+	return true
+}
+
+// Slot returns the storage of the element at index, nil if index is out of
+// bounds.
+func (av *ArrayValue) Slot(index int64) *interface{} {
+	if index < av.low || index >= av.low+int64(len(av.elems)) {
+		return nil
+	}
+	return &av.elems[index-av.low]
+}
+
+// Bounds describes the valid indices, e.g. 1..10.
+func (av *ArrayValue) Bounds() string {
+	return fmt.Sprintf("%d..%d", av.low, av.low+int64(len(av.elems))-1)
+}
+
+func (av *ArrayValue) String() string {
+	elems := make([]string, len(av.elems))
+	for i, elem := range av.elems {
+		elems[i] = formatMember(elem)
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// RecordValue is the storage of a RECORD, its fields are in the order of
+// declaration, nil fields are uninitialized.
+type RecordValue struct {
+	typ    *RecordTypeSymbol
+	fields []interface{}
+}
+
+func (rv *RecordValue) String() string {
+	fields := make([]string, len(rv.fields))
+	for i, field := range rv.fields {
+		fields[i] = fmt.Sprintf("%s: %s", rv.typ.fields[i].name, formatMember(field))
+	}
+	return "(" + strings.Join(fields, "; ") + ")"
+}
+
+// formatMember renders an element or a field, ? if it's uninitialized.
+func formatMember(value interface{}) string {
+	if value == nil {
+		return "?"
+	}
+	return formatValue(value)
+}
@@ -1,4 +1,4 @@
-package main
+package pascal
 
 import (
 	"strings"
@@ -58,3 +58,38 @@ func TestCallStack_String(t *testing.T) {
 	`
 	assert.Equal(t, strings.Fields(wantString), strings.Fields(callStack.String()))
 }
+
+func TestActivationRecord_Reference(t *testing.T) {
+	caller := NewActivationRecord("Main", ARKindProgram, 1)
+	caller.Set("x", int64(1))
+	callee := NewActivationRecord("Inc", ARKindProcedure, 2)
+	callee.Bind("n", caller.Reference("x"))
+	nested := NewActivationRecord("Again", ARKindProcedure, 2)
+	nested.Bind("m", callee.Reference("n"))
+
+	value, ok := nested.Get("m")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), value)
+
+	nested.Set("m", int64(2))
+	value, _ = caller.Get("x")
+	assert.Equal(t, int64(2), value)
+	assert.Equal(t, "-> Main.x", nested.Members["m"].(*Reference).String())
+}
+
+func TestActivationRecord_Enclosing(t *testing.T) {
+	main := NewActivationRecord("Main", ARKindProgram, 1)
+	alpha := NewActivationRecord("Alpha", ARKindProcedure, 2)
+	alpha.StaticLink = main
+	beta := NewActivationRecord("Beta", ARKindProcedure, 3)
+	beta.StaticLink = alpha
+	// a recursive call of Alpha made from Beta links back to Main.
+	recursive := NewActivationRecord("Alpha", ARKindProcedure, 2)
+	recursive.StaticLink = beta.Enclosing(1)
+
+	assert.Same(t, beta, beta.Enclosing(3))
+	assert.Same(t, alpha, beta.Enclosing(2))
+	assert.Same(t, main, beta.Enclosing(1))
+	assert.Same(t, main, recursive.Enclosing(1))
+	assert.Same(t, main, beta.Enclosing(0))
+}
@@ -0,0 +1,434 @@
+package pascal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Symbol interface {
+	fmt.Stringer
+	GetName() string
+	SetScopeLevel(int)
+}
+
+type baseSymbol struct {
+	scopeLevel int
+}
+
+func (sb *baseSymbol) SetScopeLevel(scopeLevel int) {
+	sb.scopeLevel = scopeLevel
+}
+
+var _ Symbol = (*BuiltinTypeSymbol)(nil)
+var _ Symbol = (*VarSymbol)(nil)
+var _ Symbol = (*ProcedureSymbol)(nil)
+var _ Symbol = (*FunctionSymbol)(nil)
+var _ Symbol = (*BuiltinProcedureSymbol)(nil)
+var _ Symbol = (*BuiltinFunctionSymbol)(nil)
+var _ Symbol = (*ArrayTypeSymbol)(nil)
+var _ Symbol = (*RecordTypeSymbol)(nil)
+var _ Symbol = (*TypeAliasSymbol)(nil)
+var _ Symbol = (*EnumTypeSymbol)(nil)
+var _ Symbol = (*ConstSymbol)(nil)
+
+func NewBuiltinTypeSymbol(name string) *BuiltinTypeSymbol {
+	return &BuiltinTypeSymbol{
+		name: name,
+	}
+}
+
+type BuiltinTypeSymbol struct {
+	baseSymbol
+	name string
+}
+
+func (bs *BuiltinTypeSymbol) GetName() string {
+	return bs.name
+}
+
+func (bs *BuiltinTypeSymbol) String() string {
+	return bs.name
+}
+
+func NewArrayTypeSymbol(name string, low, high int64, elemType Symbol) *ArrayTypeSymbol {
+	return &ArrayTypeSymbol{
+		name:     name,
+		low:      low,
+		high:     high,
+		elemType: elemType,
+	}
+}
+
+// ArrayTypeSymbol is ARRAY[low..high] OF elemType, an array declared along
+// with a variable has no name.
+type ArrayTypeSymbol struct {
+	baseSymbol
+	name     string
+	low      int64
+	high     int64
+	elemType Symbol
+}
+
+func (as *ArrayTypeSymbol) GetName() string {
+	if as.name == "" {
+		return as.describe()
+	}
+	return as.name
+}
+
+func (as *ArrayTypeSymbol) String() string {
+	if as.name == "" {
+		return as.describe()
+	}
+	return fmt.Sprintf("<%s:%s>", as.name, as.describe())
+}
+
+func (as *ArrayTypeSymbol) describe() string {
+	return fmt.Sprintf("ARRAY[%d..%d] OF %s", as.low, as.high, as.elemType.GetName())
+}
+
+func NewRecordTypeSymbol(name string, fields []*VarSymbol) *RecordTypeSymbol {
+	return &RecordTypeSymbol{
+		name:   name,
+		fields: fields,
+	}
+}
+
+// RecordTypeSymbol is RECORD fields END, a record declared along with a
+// variable has no name.
+type RecordTypeSymbol struct {
+	baseSymbol
+	name   string
+	fields []*VarSymbol
+}
+
+func (rs *RecordTypeSymbol) GetName() string {
+	if rs.name == "" {
+		return rs.describe()
+	}
+	return rs.name
+}
+
+func (rs *RecordTypeSymbol) String() string {
+	if rs.name == "" {
+		return rs.describe()
+	}
+	return fmt.Sprintf("<%s:%s>", rs.name, rs.describe())
+}
+
+func (rs *RecordTypeSymbol) describe() string {
+	fields := make([]string, len(rs.fields))
+	for i, field := range rs.fields {
+		fields[i] = fmt.Sprintf("%s:%s", field.name, field.typ.GetName())
+	}
+	return fmt.Sprintf("RECORD %s END", strings.Join(fields, ";"))
+}
+
+// Field returns the index of a field, or -1 if there's no such field.
+func (rs *RecordTypeSymbol) Field(name string) int {
+	for i, field := range rs.fields {
+		if field.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func NewEnumTypeSymbol(name string, values []string) *EnumTypeSymbol {
+	return &EnumTypeSymbol{
+		name:   name,
+		values: values,
+	}
+}
+
+// EnumTypeSymbol is (values), an enumerated type whose values are ordered
+// as declared. An enumeration declared along with a variable has no name.
+type EnumTypeSymbol struct {
+	baseSymbol
+	name   string
+	values []string
+}
+
+func (es *EnumTypeSymbol) GetName() string {
+	if es.name == "" {
+		return es.describe()
+	}
+	return es.name
+}
+
+func (es *EnumTypeSymbol) String() string {
+	if es.name == "" {
+		return es.describe()
+	}
+	return fmt.Sprintf("<%s:%s>", es.name, es.describe())
+}
+
+func (es *EnumTypeSymbol) describe() string {
+	return fmt.Sprintf("(%s)", strings.Join(es.values, ", "))
+}
+
+func NewTypeAliasSymbol(name string, typ Symbol) *TypeAliasSymbol {
+	return &TypeAliasSymbol{
+		name: name,
+		typ:  typ,
+	}
+}
+
+// TypeAliasSymbol is another name of a type, e.g. TYPE Count = INTEGER.
+type TypeAliasSymbol struct {
+	baseSymbol
+	name string
+	typ  Symbol
+}
+
+func (ts *TypeAliasSymbol) GetName() string {
+	return ts.name
+}
+
+func (ts *TypeAliasSymbol) String() string {
+	return fmt.Sprintf("<%s=%s>", ts.name, ts.typ.GetName())
+}
+
+func NewVarSymbol(name string, typ Symbol) *VarSymbol {
+	return &VarSymbol{
+		name: name,
+		typ:  typ,
+	}
+}
+
+type VarSymbol struct {
+	baseSymbol
+	name string
+	typ  Symbol
+	// byReference marks a VAR parameter, the argument is a variable of the caller.
+	byReference bool
+}
+
+func (vs *VarSymbol) GetName() string {
+	return vs.name
+}
+
+func (vs *VarSymbol) String() string {
+	if vs.byReference {
+		return fmt.Sprintf("<VAR %s:%s>", vs.name, vs.typ)
+	}
+	return fmt.Sprintf("<%s:%s>", vs.name, vs.typ)
+}
+
+func NewConstSymbol(name string, typ Symbol, value interface{}) *ConstSymbol {
+	return &ConstSymbol{
+		name:  name,
+		typ:   typ,
+		value: value,
+	}
+}
+
+// ConstSymbol is a constant, declared in a CONST section or as a value of
+// an enumerated type. Its value is known before the program runs.
+type ConstSymbol struct {
+	baseSymbol
+	name  string
+	typ   Symbol
+	value interface{}
+}
+
+func (cs *ConstSymbol) GetName() string {
+	return cs.name
+}
+
+func (cs *ConstSymbol) String() string {
+	return fmt.Sprintf("<%s:%s=%s>", cs.name, cs.typ, formatLiteral(cs.value))
+}
+
+func NewProcedureSymbol(name string, formalParams []*VarSymbol) *ProcedureSymbol {
+	return &ProcedureSymbol{
+		name:         name,
+		formalParams: formalParams,
+	}
+}
+
+type ProcedureSymbol struct {
+	baseSymbol
+	name         string
+	formalParams []*VarSymbol
+	blockNode    *BlockNode
+}
+
+func (ps *ProcedureSymbol) String() string {
+	var params []string
+	for _, p := range ps.formalParams {
+		params = append(params, p.String())
+	}
+	return fmt.Sprintf("<%s:%s>", ps.name, strings.Join(params, ";"))
+}
+
+func (ps *ProcedureSymbol) GetName() string {
+	return ps.name
+}
+
+func (ps *ProcedureSymbol) SetBlockNode(node *BlockNode) {
+	ps.blockNode = node
+}
+
+func NewFunctionSymbol(name string, formalParams []*VarSymbol, returnType Symbol) *FunctionSymbol {
+	return &FunctionSymbol{
+		name:         name,
+		formalParams: formalParams,
+		returnType:   returnType,
+	}
+}
+
+type FunctionSymbol struct {
+	baseSymbol
+	name         string
+	formalParams []*VarSymbol
+	returnType   Symbol
+	blockNode    *BlockNode
+}
+
+func (fs *FunctionSymbol) String() string {
+	var params []string
+	for _, p := range fs.formalParams {
+		params = append(params, p.String())
+	}
+	return fmt.Sprintf("<%s:%s:%s>", fs.name, strings.Join(params, ";"), fs.returnType)
+}
+
+func (fs *FunctionSymbol) GetName() string {
+	return fs.name
+}
+
+func (fs *FunctionSymbol) SetBlockNode(node *BlockNode) {
+	fs.blockNode = node
+}
+
+func NewBuiltinProcedureSymbol(name string, byReference bool) *BuiltinProcedureSymbol {
+	return &BuiltinProcedureSymbol{
+		name:        name,
+		byReference: byReference,
+	}
+}
+
+// BuiltinProcedureSymbol is a procedure implemented by the interpreter itself,
+// it takes any number of actual parameters.
+type BuiltinProcedureSymbol struct {
+	baseSymbol
+	name string
+	// byReference requires every actual parameter to be a variable.
+	byReference bool
+}
+
+func (bs *BuiltinProcedureSymbol) GetName() string {
+	return bs.name
+}
+
+func (bs *BuiltinProcedureSymbol) String() string {
+	return fmt.Sprintf("<%s:...>", bs.name)
+}
+
+func NewBuiltinFunctionSymbol(name string, formalParams []*VarSymbol, returnType Symbol) *BuiltinFunctionSymbol {
+	return &BuiltinFunctionSymbol{
+		name:         name,
+		formalParams: formalParams,
+		returnType:   returnType,
+	}
+}
+
+// BuiltinFunctionSymbol is a function implemented by the interpreter itself,
+// its actual parameters are checked as those of a FunctionSymbol.
+type BuiltinFunctionSymbol struct {
+	baseSymbol
+	name         string
+	formalParams []*VarSymbol
+	returnType   Symbol
+}
+
+func (bs *BuiltinFunctionSymbol) GetName() string {
+	return bs.name
+}
+
+func (bs *BuiltinFunctionSymbol) String() string {
+	var params []string
+	for _, p := range bs.formalParams {
+		params = append(params, p.String())
+	}
+	return fmt.Sprintf("<%s:%s:%s>", bs.name, strings.Join(params, ";"), bs.returnType)
+}
+
+func NewScopedSymbolTable(
+	name string,
+	level int,
+	enclosingScope *ScopedSymbolTable,
+) *ScopedSymbolTable {
+	return &ScopedSymbolTable{
+		name:           name,
+		level:          level,
+		enclosingScope: enclosingScope,
+		symbols:        make(map[string]Symbol),
+	}
+}
+
+type ScopedSymbolTable struct {
+	name           string
+	level          int
+	enclosingScope *ScopedSymbolTable
+	symbols        map[string]Symbol
+}
+
+func (st *ScopedSymbolTable) String() string {
+	output := strings.Builder{}
+	h1 := "SCOPE (SCOPED SYMBOL TABLE)"
+	output.WriteString(h1)
+	output.WriteRune('\n')
+	for i := 0; i < len(h1); i++ {
+		output.WriteRune('=')
+	}
+	output.WriteRune('\n')
+	output.WriteString(fmt.Sprintf("%-15s: %s\n", "Scope name", st.name))
+	output.WriteString(fmt.Sprintf("%-15s: %d\n", "Scope level", st.level))
+	if st.enclosingScope != nil {
+		output.WriteString(fmt.Sprintf("%-15s: %s\n", "Enclosing scope", st.enclosingScope.name))
+	}
+	output.WriteRune('\n')
+	h2 := "Scope (Scoped symbol table) contents"
+	output.WriteString(h2)
+	output.WriteRune('\n')
+	for i := 0; i < len(h2); i++ {
+		output.WriteRune('-')
+	}
+	output.WriteRune('\n')
+	var symbolRows []string
+	for k, v := range st.symbols {
+		symbolRows = append(symbolRows, fmt.Sprintf("%7s: %s", k, v.String()))
+	}
+	sort.Strings(symbolRows)
+	output.WriteString(strings.Join(symbolRows, "\n"))
+	output.WriteRune('\n')
+	return output.String()
+}
+
+func (st *ScopedSymbolTable) Define(s Symbol) {
+	s.SetScopeLevel(st.level)
+	st.symbols[s.GetName()] = s
+	return
+}
+
+// Import makes a symbol declared in another scope visible in this one, its
+// scope level is kept.
+func (st *ScopedSymbolTable) Import(s Symbol) {
+	st.symbols[s.GetName()] = s
+}
+
+func (st *ScopedSymbolTable) Lookup(name string, currentOnly bool) (s Symbol, ok bool) {
+	if s, ok = st.symbols[name]; ok {
+		return
+	}
+
+	if currentOnly || st.enclosingScope == nil {
+		return
+	}
+
+	s, ok = st.enclosingScope.Lookup(name, false)
+	return
+}
@@ -0,0 +1,1115 @@
+package pascal
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func NewTranspiler(writer io.Writer) *Transpiler {
+	return &Transpiler{writer: writer}
+}
+
+var _ Visitor = (*Transpiler)(nil)
+
+// Transpiler translates a checked program into the source of a Go program
+// that behaves the same, built into a native binary by the go tool. The
+// declarations of the program and its units are declared in the Go
+// package, the ones of routines in Go closures. A VAR parameter is a
+// pointer, an enumerated type is an int64 of the ordinal.
+//
+// The runtime errors of the Interpreter are checked the same: an index out
+// of range, a division by zero, an invalid argument of a builtin function or
+// invalid input prints the error to stderr and exits with status 1. Reads
+// before assignment are not checked though, unlike in both interpreters a
+// variable, an element, a field or a function result that's never set
+// reads as the zero value of its type. A too deep recursion is Go's own
+// stack overflow, and the Limits of a run don't apply.
+type Transpiler struct {
+	writer io.Writer
+	lines  []string
+	scope  *transpilerScope
+	// helpers are the names of the helper functions the program calls,
+	// imports are the packages the program and the helpers use.
+	helpers map[string]bool
+	imports map[string]bool
+	// units are the names the units translated export, by unit, inits the
+	// statements initializing them, which main runs first.
+	units map[string]map[string]*transpiledName
+	inits []string
+	// types are the Go types of the ARRAY and RECORD types declared.
+	types map[Symbol]string
+}
+
+// transpilerScope is what the names declared in a block are in Go.
+type transpilerScope struct {
+	parent *transpilerScope
+	names  map[string]*transpiledName
+	// global marks the scope of the program or of a unit, declared in the
+	// package. The names of a unit's are prefixed so as not to clash with
+	// the program's.
+	global bool
+	prefix string
+	// locals are the variables and the routines of a routine's block, Go
+	// refuses to compile a local variable that's never read.
+	locals []*transpiledName
+}
+
+type transpiledName struct {
+	ident string
+	// reference marks a VAR parameter, a pointer to the caller's variable.
+	reference bool
+	// result is the variable holding the result of a function.
+	result string
+	// typ is the type of a type name.
+	typ  Symbol
+	used bool
+}
+
+func (t *Transpiler) Transpile(program *CompiledProgram) (err error) {
+	t.lines, t.scope, t.inits = nil, nil, nil
+	t.helpers = make(map[string]bool)
+	t.imports = make(map[string]bool)
+	t.units = make(map[string]map[string]*transpiledName)
+	t.types = make(map[Symbol]string)
+	if err = Walk(t, program.root); err != nil {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("// Code generated by go-playground from PROGRAM %s. DO NOT EDIT.\n\n", program.root.name))
+	sb.WriteString("package main\n\n")
+	for _, helper := range transpilerHelpers {
+		if t.helpers[helper.name] {
+			for _, pkg := range helper.imports {
+				t.imports[pkg] = true
+			}
+		}
+	}
+	if len(t.imports) > 0 {
+		imports := make([]string, 0, len(t.imports))
+		for pkg := range t.imports {
+			imports = append(imports, strconv.Quote(pkg))
+		}
+		sort.Strings(imports)
+		sb.WriteString(fmt.Sprintf("import (\n%s\n)\n\n", strings.Join(imports, "\n")))
+	}
+	sb.WriteString(strings.Join(t.lines, "\n"))
+	for _, helper := range transpilerHelpers {
+		if t.helpers[helper.name] {
+			sb.WriteString("\n\n" + helper.source)
+		}
+	}
+	source, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return
+	}
+	_, err = t.writer.Write(source)
+	return
+}
+
+func (t *Transpiler) Before(node ASTNode) (shouldStepIn bool, err error) {
+	switch n := node.(type) {
+	case *ProgramNode:
+		for _, unit := range n.units {
+			if err = t.unit(unit); err != nil {
+				return
+			}
+		}
+		t.scope = t.uses(n.uses)
+		if err = t.block(n.block, nil); err != nil {
+			return
+		}
+	case *ProcedureDeclNode:
+		err = t.routine(n.name, n.params, nil, n.block)
+	case *FunctionDeclNode:
+		err = t.routine(n.name, n.params, n.returnType, n.block)
+	case *CompoundNode:
+		for _, child := range n.children {
+			if err = Walk(t, child); err != nil {
+				return
+			}
+		}
+	case *AssignNode:
+		t.line(fmt.Sprintf("%s = %s", t.target(n.left), t.convert(n.right, typeOf(n.left))))
+	case *ProcedureCallNode:
+		t.call(n)
+	case *IfNode:
+		err = t.ifStmt(n)
+	case *WhileNode:
+		t.line(fmt.Sprintf("for %s {", t.text(n.condition)))
+		if err = Walk(t, n.body); err != nil {
+			return
+		}
+		t.line("}")
+	case *RepeatNode:
+		t.line("for {")
+		for _, child := range n.children {
+			if err = Walk(t, child); err != nil {
+				return
+			}
+		}
+		t.line(fmt.Sprintf("if %s {", t.text(n.condition)))
+		t.line("break")
+		t.line("}")
+		t.line("}")
+	case *ForNode:
+		// NOTE: as in the Interpreter, both bounds are evaluated once and the
+		// variable is set from a counter of its own before each iteration.
+		cmp, step := "<=", "++"
+		if n.downto {
+			cmp, step = ">=", "--"
+		}
+		t.line(fmt.Sprintf("for forCounter, forEnd := %s, %s; forCounter %s forEnd; forCounter%s {",
+			t.integer(n.start), t.integer(n.end), cmp, step))
+		t.line(fmt.Sprintf("%s = forCounter", t.target(n.varNode)))
+		if err = Walk(t, n.body); err != nil {
+			return
+		}
+		t.line("}")
+	}
+	return false, err
+}
+
+func (t *Transpiler) After(node ASTNode) error {
+	return nil
+}
+
+func (t *Transpiler) line(text string) {
+	t.lines = append(t.lines, text)
+}
+
+// block writes the declarations of a block and its statements, the ones
+// of the program go in the main function. routine is the routine whose
+// block it is, nil for the program.
+func (t *Transpiler) block(node *BlockNode, routine *transpiledName) (err error) {
+	t.scope = &transpilerScope{parent: t.scope, names: make(map[string]*transpiledName), global: routine == nil}
+	defer func() { t.scope = t.scope.parent }()
+	if err = t.declarations(node.declarations); err != nil {
+		return
+	}
+
+	if routine == nil {
+		t.line("")
+		t.line("func main() {")
+		t.lines = append(t.lines, t.inits...)
+	}
+	declarations := len(t.lines)
+	if err = Walk(t, node.compoundStmt); err != nil {
+		return
+	}
+	// NOTE: Go refuses to compile a local variable that's never read.
+	var unused []string
+	for _, name := range t.scope.locals {
+		if !name.used {
+			unused = append(unused, fmt.Sprintf("_ = %s", name.ident))
+		}
+	}
+	t.lines = append(t.lines[:declarations], append(unused, t.lines[declarations:]...)...)
+	if routine != nil && routine.result != "" {
+		t.line("return")
+	}
+	t.line("}")
+	return
+}
+
+// declarations writes the declarations of a block.
+func (t *Transpiler) declarations(declarations []ASTNode) (err error) {
+	for _, decl := range declarations {
+		switch n := decl.(type) {
+		case *ConstDeclNode:
+			value, _ := constantValue(n.value)
+			if text, ok := goConstant(value); ok {
+				t.line(fmt.Sprintf("const %s %s = %s", t.declare(n.name).ident, t.goType(typeOf(n.value)), text))
+				break
+			}
+			// NOTE: Go has no constant -0 nor infinity.
+			text := t.text(n.value)
+			name := t.declare(n.name)
+			t.local(name)
+			t.line(fmt.Sprintf("var %s %s = %s", name.ident, t.goType(typeOf(n.value)), text))
+		case *TypeDeclNode:
+			t.enums(n.typNode)
+			name := t.declare(n.name)
+			name.typ = n.typ
+			// NOTE: an alias is spelled as the type it names, an enumerated
+			// type as an int64.
+			switch n.typ.(type) {
+			case *ArrayTypeSymbol, *RecordTypeSymbol:
+				if n.typ.GetName() == n.name {
+					t.types[n.typ] = name.ident
+					t.line(fmt.Sprintf("type %s %s", name.ident, t.structure(n.typ)))
+				}
+			}
+		case *VarDeclNode:
+			t.enums(n.typNode)
+			name := t.declare(n.varNode.value)
+			t.line(fmt.Sprintf("var %s %s", name.ident, t.goType(n.varNode.typ)))
+			t.local(name)
+		default:
+			if err = Walk(t, n); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// unit writes the declarations of a unit, in the package with their names
+// prefixed by the unit's, and keeps its statements for main.
+func (t *Transpiler) unit(n *UnitNode) (err error) {
+	t.scope = &transpilerScope{
+		parent: t.uses(n.uses),
+		names:  make(map[string]*transpiledName),
+		global: true,
+		prefix: t.ident(n.name) + "Unit_",
+	}
+	defer func() { t.scope = nil }()
+	if err = t.declarations(n.block.declarations); err != nil {
+		return
+	}
+	lines := t.lines
+	t.lines = nil
+	err = Walk(t, n.block.compoundStmt)
+	t.inits = append(t.inits, t.lines...)
+	t.lines = lines
+	exports := make(map[string]*transpiledName)
+	for _, symbol := range n.exports {
+		exports[symbol.GetName()] = t.scope.names[symbol.GetName()]
+	}
+	t.units[n.name] = exports
+	return
+}
+
+// uses returns the scope of the names the units at uses export, nil if
+// there are none.
+func (t *Transpiler) uses(uses []*Token) *transpilerScope {
+	if len(uses) == 0 {
+		return nil
+	}
+	scope := &transpilerScope{names: make(map[string]*transpiledName)}
+	for _, token := range uses {
+		for name, exported := range t.units[token.Value] {
+			scope.names[name] = exported
+		}
+	}
+	return scope
+}
+
+// routine writes a procedure or a function, returnType is nil for a
+// procedure. A routine declared by the program is a Go function, a nested
+// one a closure.
+func (t *Transpiler) routine(name string, params []*ParamNode, returnType *TypNode, block *BlockNode) (err error) {
+	routine := t.declare(name)
+	formals := make([]string, len(params))
+	types := make([]string, len(params))
+	for i, param := range params {
+		types[i] = t.goType(t.lookupType(param.typNode))
+		if param.byReference {
+			types[i] = "*" + types[i]
+		}
+		formals[i] = t.ident(param.varNode.value) + " " + types[i]
+	}
+	results := ""
+	signature := fmt.Sprintf("func(%s)", strings.Join(types, ", "))
+	if returnType != nil {
+		routine.result = routine.ident + "Result"
+		results = fmt.Sprintf(" (%s %s)", routine.result, t.goType(t.lookupType(returnType)))
+		signature += " " + t.goType(t.lookupType(returnType))
+	}
+	if t.scope.global {
+		t.line("")
+		t.line(fmt.Sprintf("func %s(%s)%s {", routine.ident, strings.Join(formals, ", "), results))
+	} else {
+		t.line(fmt.Sprintf("var %s %s", routine.ident, signature))
+		t.line(fmt.Sprintf("%s = func(%s)%s {", routine.ident, strings.Join(formals, ", "), results))
+		t.local(routine)
+	}
+
+	t.scope = &transpilerScope{parent: t.scope, names: make(map[string]*transpiledName)}
+	for _, param := range params {
+		t.declare(param.varNode.value).reference = param.byReference
+	}
+	err = t.block(block, routine)
+	t.scope = t.scope.parent
+	return
+}
+
+// enums declares the values of the enumerated types in a type as int64
+// constants.
+func (t *Transpiler) enums(node ASTNode) {
+	switch n := node.(type) {
+	case *ArrayTypeNode:
+		t.enums(n.elemType)
+	case *RecordTypeNode:
+		for _, field := range n.fields {
+			t.enums(field.typNode)
+		}
+	case *EnumTypeNode:
+		t.line("const (")
+		for i, token := range n.values {
+			ident := t.declare(token.Value).ident
+			if i == 0 {
+				ident += " int64 = iota"
+			}
+			t.line(ident)
+		}
+		t.line(")")
+	}
+}
+
+// declare defines a name in the current scope.
+func (t *Transpiler) declare(name string) *transpiledName {
+	declared := &transpiledName{ident: t.scope.prefix + t.ident(name)}
+	t.scope.names[name] = declared
+	return declared
+}
+
+// local records a variable or a routine declared in a routine's block.
+func (t *Transpiler) local(name *transpiledName) {
+	if !t.scope.global {
+		t.scope.locals = append(t.scope.locals, name)
+	}
+}
+
+func (t *Transpiler) lookup(name string) *transpiledName {
+	for scope := t.scope; scope != nil; scope = scope.parent {
+		if found, ok := scope.names[name]; ok {
+			return found
+		}
+	}
+	return &transpiledName{ident: t.ident(name)}
+}
+
+// lookupType returns the type a type name denotes.
+func (t *Transpiler) lookupType(node *TypNode) Symbol {
+	if typ := t.lookup(node.value).typ; typ != nil {
+		return typ
+	}
+	return NewBuiltinTypeSymbol(node.value)
+}
+
+// goReserved are the names Go keeps for itself and the packages the
+// program imports.
+var goReserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true,
+	"defer": true, "else": true, "fallthrough": true, "for": true, "func": true, "go": true,
+	"goto": true, "if": true, "import": true, "interface": true, "map": true, "package": true,
+	"range": true, "return": true, "select": true, "struct": true, "switch": true, "type": true,
+	"var": true, "any": true, "append": true, "bool": true, "byte": true, "cap": true,
+	"clear": true, "close": true, "comparable": true, "complex": true, "complex64": true,
+	"complex128": true, "copy": true, "delete": true, "error": true, "false": true,
+	"float32": true, "float64": true, "imag": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "iota": true, "len": true, "make": true, "max": true,
+	"min": true, "new": true, "nil": true, "panic": true, "print": true, "println": true,
+	"real": true, "recover": true, "rune": true, "string": true, "true": true, "uint": true,
+	"uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"init": true, "main": true, "bufio": true, "fmt": true, "io": true, "os": true,
+	"strconv": true, "strings": true,
+}
+
+// ident returns the Go identifier of a name: in lowercase, so that it never
+// clashes with the names the Transpiler makes up in camel case.
+func (t *Transpiler) ident(name string) string {
+	ident := strings.ToLower(name)
+	if goReserved[ident] {
+		ident += "_"
+	}
+	return ident
+}
+
+// goType returns the Go type of a type, by its name if it's declared.
+func (t *Transpiler) goType(typ Symbol) string {
+	if ident, ok := t.types[typ]; ok {
+		return ident
+	}
+	return t.structure(typ)
+}
+
+// structure returns the Go type of a type spelled out.
+func (t *Transpiler) structure(typ Symbol) string {
+	switch typ := typ.(type) {
+	case *ArrayTypeSymbol:
+		return fmt.Sprintf("[%d]%s", typ.high-typ.low+1, t.goType(typ.elemType))
+	case *RecordTypeSymbol:
+		fields := make([]string, len(typ.fields))
+		for i, field := range typ.fields {
+			fields[i] = fmt.Sprintf("%s %s", t.ident(field.name), t.goType(field.typ))
+		}
+		return fmt.Sprintf("struct {\n%s\n}", strings.Join(fields, "\n"))
+	case *EnumTypeSymbol:
+		return "int64"
+	}
+	switch typ.GetName() {
+	case TokenNames[Integer]:
+		return "int64"
+	case TokenNames[Real]:
+		return "float64"
+	case TokenNames[Boolean]:
+		return "bool"
+	}
+	return "string"
+}
+
+// isType reports whether typ is the builtin type kind.
+func isType(typ Symbol, kind TokenKind) bool {
+	builtin, ok := typ.(*BuiltinTypeSymbol)
+	return ok && builtin.name == TokenNames[kind]
+}
+
+// target returns the variable an assignment sets, the name of a function
+// sets its result.
+func (t *Transpiler) target(node ASTNode) string {
+	varNode, ok := node.(*VarNode)
+	if !ok {
+		return t.text(node)
+	}
+	name := t.lookup(varNode.value)
+	switch {
+	case name.result != "":
+		return name.result
+	case name.reference:
+		return "*" + name.ident
+	}
+	return name.ident
+}
+
+// The precedences of Go's operators, an operand binds tightest.
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceRelation
+	precedenceAdd
+	precedenceMul
+	precedenceUnary
+	precedenceOperand
+)
+
+var goOperators = map[TokenKind]string{
+	Plus: "+", Minus: "-", Mul: "*", And: "&&", Or: "||",
+	Equal: "==", NotEqual: "!=", Less: "<", LessEqual: "<=", Greater: ">", GreaterEqual: ">=",
+}
+
+// text returns the Go expression of an expression.
+func (t *Transpiler) text(node ASTNode) string {
+	text, _ := t.expr(node)
+	return text
+}
+
+// expr returns the Go expression of an expression and the precedence of
+// its operator.
+func (t *Transpiler) expr(node ASTNode) (text string, precedence int) {
+	switch n := node.(type) {
+	case *NumNode:
+		return n.token.Value, precedenceOperand
+	case *BoolNode:
+		return strconv.FormatBool(n.value), precedenceOperand
+	case *StrNode:
+		return strconv.Quote(n.value), precedenceOperand
+	case *VarNode:
+		name := t.lookup(n.value)
+		name.used = true
+		if name.reference {
+			return "*" + name.ident, precedenceUnary
+		}
+		return name.ident, precedenceOperand
+	case *IndexNode:
+		arrayType := typeOf(n.base).(*ArrayTypeSymbol)
+		offset := t.helper("offsetOf", t.text(n.index), strconv.FormatInt(arrayType.low, 10), strconv.FormatInt(arrayType.high, 10))
+		return fmt.Sprintf("%s[%s]", t.base(n.base), offset), precedenceOperand
+	case *FieldNode:
+		return fmt.Sprintf("%s.%s", t.base(n.base), t.ident(n.field)), precedenceOperand
+	case *FunctionCallNode:
+		return t.functionCall(n)
+	case *UnaryOpNode:
+		operand := t.operand(n.operand, precedenceUnary)
+		switch n.op {
+		case Minus:
+			// NOTE: Go negates a constant 0.0 into 0, not -0.
+			if isType(n.typ, Real) && t.folded(n.operand) {
+				operand = t.helper("realValue", t.text(n.operand))
+			}
+			if strings.HasPrefix(operand, "-") {
+				operand = "(" + operand + ")"
+			}
+			return "-" + operand, precedenceUnary
+		case Not:
+			return "!" + operand, precedenceUnary
+		}
+		return t.expr(n.operand)
+	case *BinOpNode:
+		left, right := typeOf(n.left), typeOf(n.right)
+		switch n.op {
+		case And:
+			precedence = precedenceAnd
+		case Or:
+			precedence = precedenceOr
+		case Plus, Minus:
+			precedence = precedenceAdd
+		case Mul, IntegerDiv, FloatDiv:
+			precedence = precedenceMul
+		default:
+			precedence = precedenceRelation
+		}
+		lhs, rhs := t.operand(n.left, precedence), t.operand(n.right, precedence+1)
+		real := isType(left, Real) || isType(right, Real)
+		switch {
+		case n.op == FloatDiv:
+			return t.helper("divReal", t.real(n.left, precedenceOperand), t.real(n.right, precedenceOperand)), precedenceOperand
+		case n.op == IntegerDiv:
+			return t.helper("divInteger", t.text(n.left), t.text(n.right)), precedenceOperand
+		case real && (isType(left, Integer) || isType(right, Integer)):
+			lhs, rhs = t.real(n.left, precedence), t.real(n.right, precedence+1)
+		case isType(left, Boolean) && precedence == precedenceRelation && n.op != Equal && n.op != NotEqual:
+			lhs, rhs = t.helper("boolOrdinal", t.text(n.left)), t.helper("boolOrdinal", t.text(n.right))
+		}
+		// NOTE: Go evaluates an expression of constants exactly, where the
+		// interpreter rounds each operation and wraps around.
+		if (real || isType(left, Integer)) && t.folded(n.left) && t.folded(n.right) {
+			if real {
+				lhs = t.helper("realValue", lhs)
+			} else {
+				lhs = t.helper("integerValue", lhs)
+			}
+		}
+		return fmt.Sprintf("%s %s %s", lhs, goOperators[n.op], rhs), precedence
+	}
+	panic(fmt.Sprintf("unexpected node %T", node))
+}
+
+// operand returns an operand of an operator of precedence, in parentheses
+// if it binds looser.
+func (t *Transpiler) operand(node ASTNode, precedence int) string {
+	text, p := t.expr(node)
+	if p < precedence {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// base returns the array indexed or the record whose field is selected,
+// Go dereferences a pointer to either.
+func (t *Transpiler) base(node ASTNode) string {
+	if varNode, ok := node.(*VarNode); ok && t.lookup(varNode.value).reference {
+		t.lookup(varNode.value).used = true
+		return t.lookup(varNode.value).ident
+	}
+	return t.operand(node, precedenceOperand)
+}
+
+// goConstant returns the Go constant of the value of a CONST declaration.
+func goConstant(value interface{}) (text string, ok bool) {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) || math.Signbit(v) && v == 0 {
+			return "", false
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return strconv.Quote(v), true
+	}
+	return "", false
+}
+
+// folded reports whether Go evaluates a numeric expression at compile
+// time, a constant one that isn't an operation.
+func (t *Transpiler) folded(node ASTNode) bool {
+	switch n := node.(type) {
+	case *NumNode:
+		return true
+	case *VarNode:
+		return n.constant != nil
+	case *UnaryOpNode:
+		return !(n.op == Minus && isType(n.typ, Real)) && t.folded(n.operand)
+	}
+	return false
+}
+
+// literalOnly reports whether an expression is made of literals only, Go
+// gives it a type from where it's used.
+func literalOnly(node ASTNode) bool {
+	switch n := node.(type) {
+	case *NumNode:
+		return true
+	case *UnaryOpNode:
+		return literalOnly(n.operand)
+	case *BinOpNode:
+		return literalOnly(n.left) && literalOnly(n.right)
+	}
+	return false
+}
+
+// real returns an operand converted to float64 if it's an INTEGER.
+func (t *Transpiler) real(node ASTNode, precedence int) string {
+	if !isType(typeOf(node), Integer) {
+		return t.operand(node, precedence)
+	}
+	if n, ok := node.(*NumNode); ok {
+		return n.token.Value + ".0"
+	}
+	return fmt.Sprintf("float64(%s)", t.text(node))
+}
+
+// integer returns an INTEGER expression typed int64.
+func (t *Transpiler) integer(node ASTNode) string {
+	if literalOnly(node) {
+		return fmt.Sprintf("int64(%s)", t.text(node))
+	}
+	return t.text(node)
+}
+
+// convert returns an expression converted to typ, as INTEGER values widen
+// into REAL ones.
+func (t *Transpiler) convert(node ASTNode, typ Symbol) string {
+	if isType(typ, Real) && isType(typeOf(node), Integer) {
+		return t.real(node, precedenceOperand)
+	}
+	return t.text(node)
+}
+
+// helper returns a call of a helper function.
+func (t *Transpiler) helper(helper string, args ...string) string {
+	t.use(helper)
+	return fmt.Sprintf("%s(%s)", helper, strings.Join(args, ", "))
+}
+
+// use marks a helper function, and the ones it calls, as used.
+func (t *Transpiler) use(helper string) {
+	t.helpers[helper] = true
+	for _, h := range transpilerHelpers {
+		if h.name == helper {
+			for _, required := range h.requires {
+				t.use(required)
+			}
+		}
+	}
+}
+
+// args returns the actual parameters of a call of a routine, a VAR
+// parameter is passed the address of the variable.
+func (t *Transpiler) args(formalParams []*VarSymbol, actualParams []ASTNode) string {
+	args := make([]string, len(actualParams))
+	for i, actualParam := range actualParams {
+		if !formalParams[i].byReference {
+			args[i] = t.convert(actualParam, formalParams[i].typ)
+			continue
+		}
+		if varNode, ok := actualParam.(*VarNode); ok && t.lookup(varNode.value).reference {
+			t.lookup(varNode.value).used = true
+			args[i] = t.lookup(varNode.value).ident
+			continue
+		}
+		args[i] = "&" + t.operand(actualParam, precedenceOperand)
+	}
+	return strings.Join(args, ", ")
+}
+
+// functionCall returns the call of a function and its precedence, a
+// builtin one is an expression of its own.
+func (t *Transpiler) functionCall(n *FunctionCallNode) (string, int) {
+	if n.builtin == nil {
+		name := t.lookup(n.name)
+		name.used = true
+		return fmt.Sprintf("%s(%s)", name.ident, t.args(n.funcSymbol.formalParams, n.actualParams)), precedenceOperand
+	}
+	args := n.actualParams
+	switch n.name {
+	case "LENGTH":
+		t.imports["unicode/utf8"] = true
+		return fmt.Sprintf("int64(utf8.RuneCountInString(%s))", t.text(args[0])), precedenceOperand
+	case "COPY":
+		return t.helper("copyString", t.text(args[0]), t.text(args[1]), t.text(args[2])), precedenceOperand
+	case "POS":
+		return t.helper("posString", t.text(args[0]), t.text(args[1])), precedenceOperand
+	case "ORD":
+		if isType(typeOf(args[0]), Char) || isType(typeOf(args[0]), Boolean) {
+			return t.ordinal(args[0]), precedenceOperand
+		}
+		return t.expr(args[0])
+	case "CHR":
+		return t.helper("charOf", t.text(args[0])), precedenceOperand
+	}
+	delta := "1"
+	if n.name == "PRED" {
+		delta = "-1"
+	}
+	switch typ := typeOf(args[0]).(type) {
+	case *EnumTypeSymbol:
+		return t.helper("stepEnum", t.text(args[0]), delta, t.names(typ.values)), precedenceOperand
+	case *BuiltinTypeSymbol:
+		switch {
+		case isType(typ, Char):
+			return t.helper("charOf", fmt.Sprintf("%s + %s", t.ordinal(args[0]), delta)), precedenceOperand
+		case isType(typ, Boolean):
+			return t.helper("stepEnum", t.ordinal(args[0]), delta, `[]string{"FALSE", "TRUE"}`) + " == 1", precedenceRelation
+		}
+	}
+	return fmt.Sprintf("%s + %s", t.operand(args[0], precedenceAdd), delta), precedenceAdd
+}
+
+// ordinal returns the ordinal of a value, a CHAR's is its code.
+func (t *Transpiler) ordinal(node ASTNode) string {
+	switch {
+	case isType(typeOf(node), Char):
+		return fmt.Sprintf("int64([]rune(%s)[0])", t.text(node))
+	case isType(typeOf(node), Boolean):
+		return t.helper("boolOrdinal", t.text(node))
+	}
+	return t.text(node)
+}
+
+// names returns the names of the values of an enumerated type as a slice
+// literal, indexed by their ordinals.
+func (t *Transpiler) names(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return fmt.Sprintf("[]string{%s}", strings.Join(quoted, ", "))
+}
+
+func (t *Transpiler) ifStmt(n *IfNode) (err error) {
+	t.line(fmt.Sprintf("if %s {", t.text(n.condition)))
+	for {
+		if err = Walk(t, n.thenStmt); err != nil {
+			return
+		}
+		elseIf, ok := n.elseStmt.(*IfNode)
+		if !ok {
+			break
+		}
+		t.line(fmt.Sprintf("} else if %s {", t.text(elseIf.condition)))
+		n = elseIf
+	}
+	if n.elseStmt != nil {
+		t.line("} else {")
+		if err = Walk(t, n.elseStmt); err != nil {
+			return
+		}
+	}
+	t.line("}")
+	return
+}
+
+// call writes the call of a procedure, WRITE and WRITELN format their
+// values as formatValue does and READLN parses them as parseValue does.
+func (t *Transpiler) call(n *ProcedureCallNode) {
+	if n.builtin == nil {
+		name := t.lookup(n.name)
+		name.used = true
+		t.line(fmt.Sprintf("%s(%s)", name.ident, t.args(n.procSymbol.formalParams, n.actualParams)))
+		return
+	}
+	if n.name == "READLN" {
+		t.readln(n)
+		return
+	}
+	t.imports["fmt"] = true
+	var format strings.Builder
+	var args []string
+	for _, actualParam := range n.actualParams {
+		if str, ok := actualParam.(*StrNode); ok {
+			format.WriteString(strings.ReplaceAll(str.value, "%", "%%"))
+			continue
+		}
+		switch typ := typeOf(actualParam).(type) {
+		case *EnumTypeSymbol:
+			format.WriteString("%s")
+			args = append(args, fmt.Sprintf("%s[%s]", t.names(typ.values), t.text(actualParam)))
+		default:
+			switch {
+			case isType(typ, Integer):
+				format.WriteString("%d")
+				args = append(args, t.text(actualParam))
+			case isType(typ, Real):
+				format.WriteString("%s")
+				args = append(args, t.helper("formatReal", t.text(actualParam)))
+			case isType(typ, Boolean):
+				format.WriteString("%s")
+				args = append(args, t.helper("formatBool", t.text(actualParam)))
+			default:
+				format.WriteString("%s")
+				args = append(args, t.text(actualParam))
+			}
+		}
+	}
+	switch {
+	case len(args) > 0:
+		if n.name == "WRITELN" {
+			format.WriteString("\n")
+		}
+		t.line(fmt.Sprintf("fmt.Printf(%s, %s)", strconv.Quote(format.String()), strings.Join(args, ", ")))
+	case n.name == "WRITELN":
+		text := strings.ReplaceAll(format.String(), "%%", "%")
+		if text == "" {
+			t.line("fmt.Println()")
+		} else {
+			t.line(fmt.Sprintf("fmt.Println(%s)", strconv.Quote(text)))
+		}
+	case format.Len() > 0:
+		t.line(fmt.Sprintf("fmt.Print(%s)", strconv.Quote(strings.ReplaceAll(format.String(), "%%", "%"))))
+	}
+	return
+}
+
+// readln parses the values of a line into the variables, the
+// SemanticAnalyzer allows a variable of a type READLN reads only.
+func (t *Transpiler) readln(n *ProcedureCallNode) {
+	if len(n.actualParams) == 0 {
+		t.line(t.helper("readFields", "0", "0"))
+		return
+	}
+	t.line(fmt.Sprintf("inputFields = %s", t.helper("readFields", strconv.Itoa(len(n.actualParams)), strconv.Itoa(restField(n.actualParams)))))
+	for i, actualParam := range n.actualParams {
+		field := fmt.Sprintf("inputFields[%d]", i)
+		switch typ := typeOf(actualParam); {
+		case isType(typ, Integer):
+			field = t.helper("parseInteger", field)
+		case isType(typ, Real):
+			field = t.helper("parseReal", field)
+		case isType(typ, Char):
+			field = t.helper("parseChar", field)
+		}
+		t.line(fmt.Sprintf("%s = %s", t.target(actualParam), field))
+	}
+}
+
+// transpilerHelpers are the functions the Go programs call, a program
+// gets the ones it uses.
+var transpilerHelpers = []struct {
+	name     string
+	source   string
+	imports  []string
+	requires []string
+}{
+	{
+		name: "runtimeError",
+		source: `// runtimeError stops the program on an error.
+func runtimeError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "runtime error: "+format+"\n", args...)
+	os.Exit(1)
+}`,
+		imports: []string{"fmt", "os"},
+	},
+	{
+		name: "readFields",
+		source: `var inputReader = bufio.NewReader(os.Stdin)
+
+// inputFields are the values of the line read last.
+var inputFields []string
+
+// readFields reads a line of input, which must have count values, the
+// value rest being the rest of the line.
+func readFields(count, rest int) []string {
+	line, err := inputReader.ReadString('\n')
+	if err == io.EOF {
+		if line == "" && count > 0 {
+			runtimeError("unexpected end of input")
+		}
+	} else if err != nil {
+		runtimeError("%v", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	var fields []string
+	for len(fields) < count {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if len(fields) == rest {
+			fields = append(fields, line)
+			break
+		}
+		end := strings.IndexFunc(line, unicode.IsSpace)
+		if end < 0 {
+			end = len(line)
+		}
+		if end == 0 {
+			break
+		}
+		fields, line = append(fields, line[:end]), line[end:]
+	}
+	if len(fields) < count {
+		runtimeError("want %d values, got %d", count, len(fields))
+	}
+	return fields
+}`,
+		imports:  []string{"bufio", "io", "os", "strings", "unicode"},
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "parseInteger",
+		source: `func parseInteger(text string) int64 {
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		runtimeError("%v", err)
+	}
+	return value
+}`,
+		imports:  []string{"strconv"},
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "parseReal",
+		source: `func parseReal(text string) float64 {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		runtimeError("%v", err)
+	}
+	return value
+}`,
+		imports:  []string{"strconv"},
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "parseChar",
+		source: `func parseChar(text string) string {
+	if utf8.RuneCountInString(text) != 1 {
+		runtimeError("%q is not a single character", text)
+	}
+	return text
+}`,
+		imports:  []string{"unicode/utf8"},
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "formatReal",
+		source: `func formatReal(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}`,
+		imports: []string{"strconv"},
+	},
+	{
+		name: "formatBool",
+		source: `func formatBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}`,
+	},
+	{
+		name: "boolOrdinal",
+		source: `// boolOrdinal orders FALSE before TRUE.
+func boolOrdinal(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}`,
+	},
+	{
+		name: "copyString",
+		source: `// copyString returns count characters of text from index, both clamped to
+// the text.
+func copyString(text string, index, count int64) string {
+	s := []rune(text)
+	start := index - 1
+	if start < 0 {
+		start = 0
+	}
+	if start > int64(len(s)) {
+		start = int64(len(s))
+	}
+	end := start + count
+	if end < start {
+		end = start
+	}
+	if end > int64(len(s)) {
+		end = int64(len(s))
+	}
+	return string(s[start:end])
+}`,
+	},
+	{
+		name: "posString",
+		source: `// posString returns the position of the first sub in s, 0 if none.
+func posString(sub, s string) int64 {
+	index := strings.Index(s, sub)
+	if index < 0 {
+		return 0
+	}
+	return int64(utf8.RuneCountInString(s[:index]) + 1)
+}`,
+		imports: []string{"strings", "unicode/utf8"},
+	},
+	{
+		name: "charOf",
+		source: `func charOf(code int64) string {
+	if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+		runtimeError("no character has code %d", code)
+	}
+	return string(rune(code))
+}`,
+		imports:  []string{"unicode/utf8"},
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "stepEnum",
+		source: `// stepEnum returns the ordinal delta positions from ordinal among names.
+func stepEnum(ordinal, delta int64, names []string) int64 {
+	next := ordinal + delta
+	if next < 0 || next >= int64(len(names)) {
+		neighbor := "successor"
+		if delta < 0 {
+			neighbor = "predecessor"
+		}
+		runtimeError("%s has no %s", names[ordinal], neighbor)
+	}
+	return next
+}`,
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "offsetOf",
+		source: `// offsetOf returns the offset of index in an array of low..high.
+func offsetOf(index, low, high int64) int64 {
+	if index < low || index > high {
+		runtimeError("index %d out of range %d..%d", index, low, high)
+	}
+	return index - low
+}`,
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "divInteger",
+		source: `func divInteger(a, b int64) int64 {
+	if b == 0 {
+		runtimeError("division by zero")
+	}
+	return a / b
+}`,
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "divReal",
+		source: `func divReal(a, b float64) float64 {
+	if b == 0 {
+		runtimeError("division by zero")
+	}
+	return a / b
+}`,
+		requires: []string{"runtimeError"},
+	},
+	{
+		name: "integerValue",
+		source: `// integerValue keeps Go from evaluating an expression of constants
+// exactly at compile time.
+func integerValue(value int64) int64 {
+	return value
+}`,
+	},
+	{
+		name: "realValue",
+		source: `// realValue keeps Go from evaluating an expression of constants
+// exactly at compile time.
+func realValue(value float64) float64 {
+	return value
+}`,
+	},
+}
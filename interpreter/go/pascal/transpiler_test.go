@@ -0,0 +1,254 @@
+package pascal
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranspiler_Transpile(t *testing.T) {
+	program, err := Compile(`
+		program Main;
+		var i, total : integer; mean : real;
+		procedure Add(n : integer);
+			procedure Count(var sum : integer);
+			begin
+				sum := sum + n
+			end;
+		begin
+			Count(total)
+		end;
+		function Half(n : integer): integer;
+		begin
+			Half := n div 2
+		end;
+		begin
+			total := 0;
+			for i := 1 to 10 do Add(i);
+			mean := total / 10;
+			writeln('total ', total, ', half ', Half(total), ', mean ', mean)
+		end.
+	`)
+	assert.NoError(t, err)
+	output := bytes.NewBuffer(nil)
+	assert.NoError(t, NewTranspiler(output).Transpile(program))
+	assert.Equal(t, `// Code generated by go-playground from PROGRAM MAIN. DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+var i int64
+var total int64
+var mean float64
+
+func add(n int64) {
+	var count func(*int64)
+	count = func(sum *int64) {
+		*sum = *sum + n
+	}
+	count(&total)
+}
+
+func half(n int64) (halfResult int64) {
+	halfResult = divInteger(n, 2)
+	return
+}
+
+func main() {
+	total = 0
+	for forCounter, forEnd := int64(1), int64(10); forCounter <= forEnd; forCounter++ {
+		i = forCounter
+		add(i)
+	}
+	mean = divReal(float64(total), 10.0)
+	fmt.Printf("total %d, half %d, mean %s\n", total, half(total), formatReal(mean))
+}
+
+// runtimeError stops the program on an error.
+func runtimeError(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "runtime error: "+format+"\n", args...)
+	os.Exit(1)
+}
+
+func formatReal(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func divInteger(a, b int64) int64 {
+	if b == 0 {
+		runtimeError("division by zero")
+	}
+	return a / b
+}
+
+func divReal(a, b float64) float64 {
+	if b == 0 {
+		runtimeError("division by zero")
+	}
+	return a / b
+}
+`, output.String())
+}
+
+// transpileTests are the programs whose Go code must check at runtime what
+// the Interpreter does, wantError if it stops on a runtime error.
+var transpileTests = map[string]struct {
+	givenProgram string
+	wantOutput   string
+	wantError    bool
+}{
+	"constant index out of range": {
+		givenProgram: `
+			program p;
+			var a : array[1..3] of integer;
+			begin
+				a[3] := 7;
+				writeln(a[3]);
+				a[4] := 1
+			end.
+		`,
+		wantOutput: "7\n",
+		wantError:  true,
+	},
+	"write of an argument in error": {
+		givenProgram: `
+			program p;
+			var a : array[1..2] of integer; i : integer;
+			begin
+				a[1] := 1;
+				write('start ');
+				i := 3;
+				writeln(a[1], ' ', a[i])
+			end.
+		`,
+		wantOutput: "start ",
+		wantError:  true,
+	},
+	"integer division by a constant zero": {
+		givenProgram: `
+			program p;
+			var x : integer;
+			begin
+				x := 7;
+				if x < 0 then x := x div 0;
+				writeln(x);
+				x := 1 div 0
+			end.
+		`,
+		wantOutput: "7\n",
+		wantError:  true,
+	},
+	"real division by zero": {
+		givenProgram: `
+			program p;
+			var a : integer; r : real;
+			begin
+				a := 0;
+				writeln('start');
+				r := 1 / a;
+				writeln(r)
+			end.
+		`,
+		wantOutput: "start\n",
+		wantError:  true,
+	},
+	"real constants": {
+		givenProgram: `
+			program p;
+			const c = 0.1; z = -0.0;
+			procedure Show;
+			const unused = -0.0; local = -c;
+			begin
+				writeln(local, ' ', -c * 0)
+			end;
+			begin
+				writeln(0.1 + 0.2);
+				writeln(-0.0);
+				writeln(c + 0.2, ' ', z);
+				Show
+			end.
+		`,
+		wantOutput: "0.30000000000000004\n-0\n0.30000000000000004 -0\n-0.1 -0\n",
+	},
+}
+
+// TestTranspiler_interpretTests builds the programs the backends share, and
+// the transpileTests, with the go tool, their binaries must print what the
+// Interpreter does.
+func TestTranspiler_interpretTests(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool isn't installed")
+	}
+	if testing.Short() {
+		t.Skip("building the programs is slow")
+	}
+
+	type transpiled struct {
+		givenProgram string
+		givenInput   string
+	}
+	programs := make(map[string]transpiled, len(interpretTests)+len(transpileTests))
+	for name, tc := range interpretTests {
+		programs[name] = transpiled{givenProgram: tc.givenProgram, givenInput: tc.givenInput}
+	}
+	for name, tc := range transpileTests {
+		programs[name] = transpiled{givenProgram: tc.givenProgram}
+	}
+	names := make([]string, 0, len(programs))
+	for name := range programs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module transpiled\n\ngo 1.16\n"), 0644))
+	for i, name := range names {
+		program, err := Compile(programs[name].givenProgram)
+		assert.NoError(t, err, name)
+		source := bytes.NewBuffer(nil)
+		assert.NoError(t, NewTranspiler(source).Transpile(program), name)
+		pkg := filepath.Join(dir, fmt.Sprintf("p%02d", i))
+		assert.NoError(t, os.Mkdir(pkg, 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(pkg, "main.go"), source.Bytes(), 0644))
+	}
+	bin := filepath.Join(dir, "bin")
+	assert.NoError(t, os.Mkdir(bin, 0755))
+	build := exec.Command(goTool, "build", "-o", bin, "./...")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+
+	for i, name := range names {
+		tc := programs[name]
+		t.Run(name, func(t *testing.T) {
+			want := bytes.NewBuffer(nil)
+			wantErr := NewInterpreter(strings.NewReader(tc.givenInput), want).Interpret(tc.givenProgram)
+			if transpileTest, ok := transpileTests[name]; ok {
+				assert.Equal(t, transpileTest.wantOutput, want.String())
+				assert.Equal(t, transpileTest.wantError, wantErr != nil, wantErr)
+			} else {
+				assert.NoError(t, wantErr)
+			}
+			run := exec.Command(filepath.Join(bin, fmt.Sprintf("p%02d", i)))
+			run.Stdin = strings.NewReader(tc.givenInput)
+			output, err := run.Output()
+			assert.Equal(t, wantErr != nil, err != nil, err)
+			assert.Equal(t, want.String(), string(output))
+		})
+	}
+}
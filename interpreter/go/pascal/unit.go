@@ -0,0 +1,248 @@
+package pascal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// unitLoader reads the source of the unit name, file is where it's read
+// from.
+type unitLoader func(name string) (file, source string, err error)
+
+// searchPath returns a unitLoader reading the file of a unit, its name in
+// lowercase with the extension .pas, from the first of dirs having it, the
+// current directory if there are none.
+func searchPath(dirs []string) unitLoader {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	return func(name string) (file, source string, err error) {
+		base := strings.ToLower(name) + ".pas"
+		for _, dir := range dirs {
+			file = filepath.Join(dir, base)
+			var data []byte
+			if data, err = ioutil.ReadFile(file); err == nil {
+				return file, string(data), nil
+			}
+			if !os.IsNotExist(err) {
+				return
+			}
+		}
+		return "", "", fmt.Errorf("write the unit %s in %s, in one of the directories searched: %s",
+			name, base, strings.Join(dirs, ", "))
+	}
+}
+
+// useUnits analyzes the units a program uses and annotates it with them, in
+// the order they're initialized. The units of a program analyzed before
+// are analyzed again rather than read.
+func (s *SemanticAnalyzer) useUnits(n *ProgramNode) {
+	for _, unit := range n.units {
+		s.parsed[unit.name] = unit
+	}
+	s.order = nil
+	for _, token := range n.uses {
+		s.use(token)
+	}
+	n.units = s.order
+}
+
+// use analyzes the unit named at token in a USES clause, after the units it
+// uses in turn, and returns it or nil if it can't be used. The errors in
+// its source are reported at token.
+func (s *SemanticAnalyzer) use(token *Token) *UnitNode {
+	name := token.Value
+	if s.loading[name] {
+		s.errors = append(s.errors, s.error(ErrorCodeCircularDependency, token))
+		return nil
+	}
+	if unit, ok := s.units[name]; ok {
+		return unit
+	}
+	unit, file, err := s.load(token)
+	if unit == nil && err == nil {
+		s.units[name] = nil
+		return nil
+	}
+
+	s.loading[name] = true
+	// NOTE: the tokens of the unit are in another source, so they're neither
+	// resolved nor warned about.
+	errors, resolved, declarations := s.errors, s.resolved, len(s.declarations)
+	s.errors, s.resolved = nil, nil
+	if err != nil {
+		s.errors = append(s.errors, err)
+	}
+	if unit != nil {
+		for _, used := range unit.uses {
+			s.use(used)
+		}
+		unit.level = len(s.order) + 1
+		// NOTE: the errors are recorded as the unit is walked.
+		_ = Walk(s, unit)
+		s.order = append(s.order, unit)
+	}
+	for _, err := range s.errors {
+		if list, ok := err.(ErrorList); ok {
+			for _, e := range list {
+				errors = append(errors, relocate(e, token, file))
+			}
+			continue
+		}
+		errors = append(errors, relocate(err, token, file))
+	}
+	s.errors, s.resolved, s.declarations = errors, resolved, s.declarations[:declarations]
+	delete(s.loading, name)
+	s.units[name] = unit
+	return unit
+}
+
+// load reads and parses the unit named at token, err is the error of its
+// source. The errors of the USES clause are recorded.
+func (s *SemanticAnalyzer) load(token *Token) (unit *UnitNode, file string, err error) {
+	if unit = s.parsed[token.Value]; unit != nil {
+		return unit, unit.file, nil
+	}
+	file, source, loadErr := s.loader(token.Value)
+	if loadErr != nil {
+		e := s.error(ErrorCodeIdNotFound, token).(Error)
+		e.Suggestion = loadErr.Error()
+		s.errors = append(s.errors, e)
+		return nil, "", nil
+	}
+	parser, err := NewParser(NewLexer(source))
+	if err != nil {
+		return nil, file, err
+	}
+	root, err := parser.ParseUnit()
+	if err != nil {
+		return nil, file, err
+	}
+	if unit = root.(*UnitNode); unit.name != token.Value {
+		e := s.error(ErrorCodeIdNotFound, token).(Error)
+		e.Suggestion = fmt.Sprintf("%s declares the unit %s, use it by that name", file, unit.name)
+		s.errors = append(s.errors, e)
+		return nil, "", nil
+	}
+	unit.file = file
+	return unit, file, nil
+}
+
+// relocate moves an error in the source of a unit read from file to the
+// name of the unit at token, its message tells where it is.
+func relocate(err error, token *Token, file string) error {
+	e, ok := err.(Error)
+	if !ok {
+		return err
+	}
+	e.Message = fmt.Sprintf("%s:%d:%d: %s", file, e.Span.Row, e.Span.Col, e.Message)
+	e.Span = token.Span()
+	return e
+}
+
+// imports returns a scope enclosed in the current one with the symbols the
+// units at uses export, those of a unit hide those of the units before it.
+func (s *SemanticAnalyzer) imports(uses []*Token) *ScopedSymbolTable {
+	scope := NewScopedSymbolTable(TokenNames[Uses], s.currentScope.level, s.currentScope)
+	for _, token := range uses {
+		if unit := s.units[token.Value]; unit != nil {
+			for _, symbol := range unit.exports {
+				scope.Import(symbol)
+			}
+		}
+	}
+	return scope
+}
+
+// implement checks that the IMPLEMENTATION of a unit declares the routines
+// of its INTERFACE as they're headed there.
+func (s *SemanticAnalyzer) implement(n *UnitNode) {
+	for _, heading := range n.headings {
+		var token *Token
+		var params []*ParamNode
+		var returnType Symbol
+		switch h := heading.(type) {
+		case *ProcedureDeclNode:
+			token, params = h.token, h.params
+		case *FunctionDeclNode:
+			token, params = h.token, h.params
+			returnType = s.resolveType(h.returnType, "")
+		}
+		symbol, ok := s.currentScope.Lookup(token.Value, true)
+		if !ok {
+			e := s.error(ErrorCodeIdNotFound, token).(Error)
+			e.Suggestion = fmt.Sprintf("declare %s in the IMPLEMENTATION as it's headed in the INTERFACE", token.Value)
+			s.errors = append(s.errors, e)
+			continue
+		}
+		var formalParams []*VarSymbol
+		matches := true
+		switch sym := symbol.(type) {
+		case *ProcedureSymbol:
+			formalParams, matches = sym.formalParams, returnType == nil
+		case *FunctionSymbol:
+			formalParams, matches = sym.formalParams, returnType == sym.returnType
+		default:
+			matches = false
+		}
+		matches = matches && len(formalParams) == len(params)
+		for i := 0; matches && i < len(params); i++ {
+			matches = formalParams[i].byReference == params[i].byReference &&
+				formalParams[i].typ == s.resolveType(params[i].typNode, "")
+		}
+		if !matches {
+			e := s.error(ErrorCodeTypeMismatch, token).(Error)
+			e.Suggestion = fmt.Sprintf("declare %s in the IMPLEMENTATION as it's headed in the INTERFACE", token.Value)
+			s.errors = append(s.errors, e)
+		}
+	}
+}
+
+// exports returns the symbols declared in the INTERFACE of a unit.
+func (s *SemanticAnalyzer) exports(n *UnitNode) (symbols []Symbol) {
+	var tokens []*Token
+	for _, decl := range n.block.declarations[:n.public] {
+		switch d := decl.(type) {
+		case *ConstDeclNode:
+			tokens = append(tokens, d.token)
+		case *TypeDeclNode:
+			tokens = append(tokens, d.token)
+			tokens = append(tokens, enumValues(d.typNode)...)
+		case *VarDeclNode:
+			tokens = append(tokens, d.varNode.token)
+			tokens = append(tokens, enumValues(d.typNode)...)
+		}
+	}
+	for _, heading := range n.headings {
+		switch h := heading.(type) {
+		case *ProcedureDeclNode:
+			tokens = append(tokens, h.token)
+		case *FunctionDeclNode:
+			tokens = append(tokens, h.token)
+		}
+	}
+	for _, token := range tokens {
+		if symbol, ok := s.currentScope.Lookup(token.Value, true); ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return
+}
+
+// enumValues returns the values of the enumerated types in a type.
+func enumValues(node ASTNode) (values []*Token) {
+	switch n := node.(type) {
+	case *ArrayTypeNode:
+		return enumValues(n.elemType)
+	case *RecordTypeNode:
+		for _, field := range n.fields {
+			values = append(values, enumValues(field.typNode)...)
+		}
+	case *EnumTypeNode:
+		return n.values
+	}
+	return
+}
@@ -0,0 +1,200 @@
+package pascal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	counterTestUnit = `unit Counter;
+interface
+var count : integer;
+procedure Tick(n : integer);
+implementation
+var step : integer;
+procedure Tick(n : integer);
+begin
+	count := count + n * step
+end;
+begin
+	step := 1;
+	count := 0;
+	writeln('counter ready')
+end.`
+	shapesTestUnit = `unit Shapes;
+interface
+uses Counter;
+type Color = (Red, Green);
+function Area(w, h : integer) : integer;
+implementation
+function Area(w, h : integer) : integer;
+begin
+	Tick(1);
+	Area := w * h
+end;
+begin
+	writeln('shapes ready')
+end.`
+)
+
+// writeTestUnits writes the sources of units, by file name, in a temporary
+// directory and returns it.
+func writeTestUnits(t *testing.T, units map[string]string) string {
+	dir := t.TempDir()
+	for name, source := range units {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(source), 0644))
+	}
+	return dir
+}
+
+func TestCompileWith_units(t *testing.T) {
+	dir := writeTestUnits(t, map[string]string{"counter.pas": counterTestUnit, "shapes.pas": shapesTestUnit})
+	program, err := CompileWith(`
+		program Main;
+		uses Shapes, Counter;
+		var c : Color;
+		begin
+			c := Green;
+			Tick(Area(2, 3));
+			if c = Green then writeln(count)
+		end.
+	`, CompileOptions{SearchPath: []string{filepath.Join(dir, "missing"), dir}})
+	assert.NoError(t, err)
+	if assert.Len(t, program.Root().Units(), 2) {
+		assert.Equal(t, "COUNTER", program.Root().Units()[0].Name())
+		assert.Equal(t, filepath.Join(dir, "counter.pas"), program.Root().Units()[0].File())
+		assert.Equal(t, "SHAPES", program.Root().Units()[1].Name())
+	}
+
+	for _, backend := range []Backend{BackendAST, BackendVM} {
+		t.Run(string(backend), func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			assert.NoError(t, program.Run(context.Background(), RunOptions{Stdout: output, Backend: backend}))
+			assert.Equal(t, "counter ready\nshapes ready\n7\n", output.String())
+		})
+	}
+
+	t.Run("profiled", func(t *testing.T) {
+		profiler := NewProfiler(program)
+		assert.NoError(t, program.Run(context.Background(), RunOptions{Stdout: ioutil.Discard, Profiler: profiler}))
+		report := bytes.NewBuffer(nil)
+		assert.NoError(t, profiler.WriteJSON(report))
+		var decoded struct {
+			Total    int
+			Routines []struct {
+				Name  string
+				Row   int
+				Calls int
+			}
+		}
+		assert.NoError(t, json.Unmarshal(report.Bytes(), &decoded))
+		// the statements of the units aren't covered, their routines are.
+		assert.Equal(t, 4, decoded.Total)
+		calls := map[string]int{}
+		for _, routine := range decoded.Routines {
+			assert.Equal(t, 0, routine.Row, routine.Name)
+			calls[routine.Name] = routine.Calls
+		}
+		assert.Equal(t, map[string]int{"PROGRAM MAIN": 1, "FUNCTION SHAPES.AREA": 1, "PROCEDURE COUNTER.TICK": 2}, calls)
+	})
+	t.Run("debugged", func(t *testing.T) {
+		output := bytes.NewBuffer(nil)
+		assert.NoError(t, program.Run(context.Background(), RunOptions{Stdin: strings.NewReader("continue\n"), Stdout: output, Debug: true}))
+		assert.Equal(t, "counter ready\nshapes ready\nline 6: c := Green;\n(debug) 7\n", output.String())
+	})
+}
+
+func TestCompileWith_units_errors(t *testing.T) {
+	tests := map[string]struct {
+		givenUnits   map[string]string
+		givenProgram string
+		wantCode     ErrorCode
+		wantMessage  string
+	}{
+		"private symbol": {
+			givenUnits:   map[string]string{"counter.pas": counterTestUnit},
+			givenProgram: "program Main; uses Counter; begin step := 2 end.",
+			wantCode:     ErrorCodeIdNotFound,
+			wantMessage:  "token:(kind=ID,value=STEP,pos=(1,35))",
+		},
+		"symbol of a unit used indirectly": {
+			givenUnits:   map[string]string{"counter.pas": counterTestUnit, "shapes.pas": shapesTestUnit},
+			givenProgram: "program Main; uses Shapes; begin Tick(1) end.",
+			wantCode:     ErrorCodeIdNotFound,
+			wantMessage:  "token:(kind=ID,value=TICK,pos=(1,34))",
+		},
+		"missing unit": {
+			givenProgram: "program Main; uses Counter; begin end.",
+			wantCode:     ErrorCodeIdNotFound,
+			wantMessage:  "token:(kind=ID,value=COUNTER,pos=(1,20))",
+		},
+		"unit named otherwise": {
+			givenUnits:   map[string]string{"counter.pas": strings.Replace(counterTestUnit, "unit Counter", "unit Tally", 1)},
+			givenProgram: "program Main; uses Counter; begin end.",
+			wantCode:     ErrorCodeIdNotFound,
+			wantMessage:  "token:(kind=ID,value=COUNTER,pos=(1,20))",
+		},
+		"circular dependency": {
+			givenUnits: map[string]string{
+				"alpha.pas": "unit Alpha; interface uses Beta; implementation end.",
+				"beta.pas":  "unit Beta; interface uses Alpha; implementation end.",
+			},
+			givenProgram: "program Main; uses Alpha; begin end.",
+			wantCode:     ErrorCodeCircularDependency,
+			wantMessage:  "alpha.pas:1:28: beta.pas:1:27: token:(kind=ID,value=ALPHA,pos=(1,27))",
+		},
+		"heading not implemented": {
+			givenUnits:   map[string]string{"counter.pas": strings.Replace(counterTestUnit, "procedure Tick(n : integer);\nbegin", "procedure Tock(n : integer);\nbegin", 1)},
+			givenProgram: "program Main; uses Counter; begin end.",
+			wantCode:     ErrorCodeIdNotFound,
+			wantMessage:  "counter.pas:4:11: token:(kind=ID,value=TICK,pos=(4,11))",
+		},
+		"heading implemented otherwise": {
+			givenUnits:   map[string]string{"counter.pas": strings.Replace(counterTestUnit, "procedure Tick(n : integer);\nbegin", "procedure Tick(var n : integer);\nbegin", 1)},
+			givenProgram: "program Main; uses Counter; begin end.",
+			wantCode:     ErrorCodeTypeMismatch,
+			wantMessage:  "counter.pas:4:11: token:(kind=ID,value=TICK,pos=(4,11))",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := writeTestUnits(t, tc.givenUnits)
+			_, err := CompileWith(tc.givenProgram, CompileOptions{SearchPath: []string{dir}})
+			if list, ok := err.(ErrorList); ok {
+				err = list[0]
+			}
+			if e, ok := err.(Error); assert.True(t, ok, err) {
+				assert.Equal(t, tc.wantCode, e.Code)
+				assert.Equal(t, tc.wantMessage, strings.ReplaceAll(e.Message, dir+string(filepath.Separator), ""))
+			}
+		})
+	}
+}
+
+func TestParser_ParseUnit(t *testing.T) {
+	parser, err := NewParser(NewLexer(shapesTestUnit))
+	assert.NoError(t, err)
+	node, err := parser.ParseUnit()
+	assert.NoError(t, err)
+	unit := node.(*UnitNode)
+	assert.Equal(t, "SHAPES", unit.Name())
+	assert.Equal(t, []*Token{NewDynamicToken(ID, "COUNTER", 3, 6)}, unit.Uses())
+	if assert.Len(t, unit.Headings(), 1) {
+		assert.Equal(t, "AREA", unit.Headings()[0].(*FunctionDeclNode).Name())
+	}
+	assert.Equal(t, 1, unit.public)
+	assert.Len(t, unit.Block().Declarations(), 2)
+
+	parser, err = NewParser(NewLexer("unit Shapes; implementation end."))
+	assert.NoError(t, err)
+	_, err = parser.ParseUnit()
+	assert.Error(t, err)
+}
@@ -0,0 +1,399 @@
+package pascal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Runtime values are plain Go values: an INTEGER is an int64, a REAL is a
+// float64, a BOOLEAN is a bool, and both a STRING and a CHAR are a string
+// of UTF-8 text. A value of an enumerated type is an EnumValue. An ARRAY is
+// an *ArrayValue and a RECORD a *RecordValue, they are copied on assignment.
+
+// EnumValue is a value of an enumerated type, its ordinal is its position
+// in the declaration of the type.
+type EnumValue struct {
+	typ     *EnumTypeSymbol
+	ordinal int64
+}
+
+func (v EnumValue) String() string {
+	return v.typ.values[v.ordinal]
+}
+
+// newValue allocates the storage of a variable of typ, nil for a scalar
+// which stays uninitialized until assigned.
+func newValue(typ Symbol) interface{} {
+	switch t := typ.(type) {
+	case *ArrayTypeSymbol:
+		elems := make([]interface{}, t.high-t.low+1)
+		for i := range elems {
+			elems[i] = newValue(t.elemType)
+		}
+		return &ArrayValue{low: t.low, elems: elems}
+	case *RecordTypeSymbol:
+		fields := make([]interface{}, len(t.fields))
+		for i, field := range t.fields {
+			fields[i] = newValue(field.typ)
+		}
+		return &RecordValue{typ: t, fields: fields}
+	}
+	return nil
+}
+
+// maxInt is the largest int, math.MaxInt as of Go 1.17.
+const maxInt = int(^uint(0) >> 1)
+
+// sizeOf returns the number of scalars a variable of typ holds, ok is false
+// if there are more than an int counts.
+func sizeOf(typ Symbol) (size int, ok bool) {
+	switch t := typ.(type) {
+	case *ArrayTypeSymbol:
+		if t.high < t.low {
+			return 0, true
+		}
+		count := uint64(t.high - t.low)
+		elemSize, ok := sizeOf(t.elemType)
+		if !ok || count >= uint64(maxInt) {
+			return 0, false
+		}
+		if elemSize > 0 && int(count)+1 > maxInt/elemSize {
+			return 0, false
+		}
+		return (int(count) + 1) * elemSize, true
+	case *RecordTypeSymbol:
+		for _, field := range t.fields {
+			fieldSize, ok := sizeOf(field.typ)
+			if !ok || fieldSize > maxInt-size {
+				return 0, false
+			}
+			size += fieldSize
+		}
+		return size, true
+	}
+	return 1, true
+}
+
+// sizeOfValue returns the number of scalars a value holds.
+func sizeOfValue(value interface{}) (size int) {
+	switch v := value.(type) {
+	case *ArrayValue:
+		if len(v.elems) == 0 {
+			return 0
+		}
+		return len(v.elems) * sizeOfValue(v.elems[0])
+	case *RecordValue:
+		for _, field := range v.fields {
+			size += sizeOfValue(field)
+		}
+		return size
+	}
+	return 1
+}
+
+// allocation returns why a variable of typ can't be allocated while used
+// scalars are, limit being their most, "" if it can.
+func allocation(typ Symbol, used, limit int) string {
+	size, ok := sizeOf(typ)
+	if !ok {
+		return fmt.Sprintf("%s is too large", typ.GetName())
+	}
+	if limit > 0 && size > limit-used {
+		return fmt.Sprintf("more than %d variables", limit)
+	}
+	return ""
+}
+
+// copyValue returns a deep copy of an ARRAY or a RECORD, scalars as is.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *ArrayValue:
+		elems := make([]interface{}, len(v.elems))
+		for i, elem := range v.elems {
+			elems[i] = copyValue(elem)
+		}
+		return &ArrayValue{low: v.low, elems: elems}
+	case *RecordValue:
+		fields := make([]interface{}, len(v.fields))
+		for i, field := range v.fields {
+			fields[i] = copyValue(field)
+		}
+		return &RecordValue{typ: v.typ, fields: fields}
+	}
+	return value
+}
+
+// coerce converts value into the representation of typ, an INTEGER stored
+// into a REAL variable becomes a float64.
+func coerce(typ Symbol, value interface{}) interface{} {
+	if i, ok := value.(int64); ok && typ != nil && typ.GetName() == TokenNames[Real] {
+		return float64(i)
+	}
+	return value
+}
+
+// negate applies the unary minus to an INTEGER or a REAL.
+func negate(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		return -v
+	case float64:
+		return -v
+	}
+	log.WithField("value", value).Panicln("unexpected value")
+	return nil
+}
+
+// binaryOp applies an arithmetic or relational operator. INTEGER operands
+// stay integers unless one of them is a REAL, then both are widened.
+func binaryOp(op TokenKind, lhs, rhs interface{}) interface{} {
+	switch l := lhs.(type) {
+	case string:
+		if op == Plus {
+			return l + rhs.(string)
+		}
+		return relation(op, strings.Compare(l, rhs.(string)))
+	case bool:
+		// FALSE < TRUE as in Pascal.
+		return relation(op, compareInts(boolOrdinal(l), boolOrdinal(rhs.(bool))))
+	case EnumValue:
+		return relation(op, compareInts(l.ordinal, rhs.(EnumValue).ordinal))
+	case int64:
+		if r, ok := rhs.(int64); ok {
+			return integerOp(op, l, r)
+		}
+	}
+	return realOp(op, toReal(lhs), toReal(rhs))
+}
+
+func integerOp(op TokenKind, l, r int64) interface{} {
+	switch op {
+	case Plus:
+		return l + r
+	case Minus:
+		return l - r
+	case Mul:
+		return l * r
+	case IntegerDiv:
+		return l / r
+	case FloatDiv:
+		return float64(l) / float64(r)
+	}
+	return relation(op, compareInts(l, r))
+}
+
+func realOp(op TokenKind, l, r float64) interface{} {
+	switch op {
+	case Plus:
+		return l + r
+	case Minus:
+		return l - r
+	case Mul:
+		return l * r
+	case FloatDiv:
+		return l / r
+	}
+	switch {
+	case l < r:
+		return relation(op, -1)
+	case l > r:
+		return relation(op, 1)
+	}
+	return relation(op, 0)
+}
+
+// relation turns the result of a three-way comparison into the boolean
+// result of a relational operator.
+func relation(op TokenKind, cmp int) bool {
+	switch op {
+	case Equal:
+		return cmp == 0
+	case NotEqual:
+		return cmp != 0
+	case Less:
+		return cmp < 0
+	case LessEqual:
+		return cmp <= 0
+	case Greater:
+		return cmp > 0
+	case GreaterEqual:
+		return cmp >= 0
+	}
+	log.WithField("op", op).Panicln("unexpected operator")
+	return false
+}
+
+func compareInts(l, r int64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+// isZero reports whether value is a zero INTEGER or REAL.
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	}
+	return false
+}
+
+func boolOrdinal(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func toReal(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	log.WithField("value", value).Panicln("unexpected value")
+	return 0
+}
+
+// formatValue renders a runtime value the way WRITE prints it.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return TokenNames[True]
+		}
+		return TokenNames[False]
+	case string:
+		return v
+	case EnumValue:
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// parseValue reads a value of typ from its textual form, as READLN does.
+func parseValue(typ Symbol, text string) (value interface{}, err error) {
+	if typ != nil {
+		switch typ.GetName() {
+		case TokenNames[Integer]:
+			return strconv.ParseInt(text, 10, 64)
+		case TokenNames[String]:
+			return text, nil
+		case TokenNames[Char]:
+			if utf8.RuneCountInString(text) != 1 {
+				return nil, fmt.Errorf("%q is not a single character", text)
+			}
+			return text, nil
+		}
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+// builtinFunctions are the names of the functions implemented by the
+// interpreter itself, the VM calls them by index.
+var builtinFunctions = []string{"LENGTH", "COPY", "POS", "ORD", "CHR", "SUCC", "PRED"}
+
+// callBuiltinFunction applies a builtin function to the values of its
+// actual parameters, problem describes an argument it isn't defined for.
+// As in Turbo Pascal, COPY clamps its index and count to the string. The
+// strings are counted in characters, not in the bytes encoding them.
+func callBuiltinFunction(name string, args []interface{}) (value interface{}, problem string) {
+	switch name {
+	case "LENGTH":
+		return int64(utf8.RuneCountInString(args[0].(string))), ""
+	case "COPY":
+		s, index, count := []rune(args[0].(string)), args[1].(int64), args[2].(int64)
+		start := index - 1
+		if start < 0 {
+			start = 0
+		}
+		if start > int64(len(s)) {
+			start = int64(len(s))
+		}
+		end := start + count
+		if end < start {
+			end = start
+		}
+		if end > int64(len(s)) {
+			end = int64(len(s))
+		}
+		return string(s[start:end]), ""
+	case "POS":
+		s := args[1].(string)
+		index := strings.Index(s, args[0].(string))
+		if index < 0 {
+			return int64(0), ""
+		}
+		return int64(utf8.RuneCountInString(s[:index]) + 1), ""
+	case "ORD":
+		return ordinal(args[0]), ""
+	case "CHR":
+		code := args[0].(int64)
+		if code < 0 || code > utf8.MaxRune || !utf8.ValidRune(rune(code)) {
+			return nil, fmt.Sprintf("no character has code %d", code)
+		}
+		return string(rune(code)), ""
+	case "SUCC":
+		return step(args[0], 1, "successor")
+	case "PRED":
+		return step(args[0], -1, "predecessor")
+	}
+	log.WithField("name", name).Panicln("unknown builtin function")
+	return
+}
+
+// ordinal returns the position of a value of an ordinal type among the
+// values of its type, a CHAR's is its Unicode code point.
+func ordinal(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case string:
+		r, _ := utf8.DecodeRuneInString(v)
+		return int64(r)
+	case bool:
+		return boolOrdinal(v)
+	case EnumValue:
+		return v.ordinal
+	}
+	log.WithField("value", value).Panicln("unexpected value")
+	return 0
+}
+
+// step returns the value of an ordinal type by delta positions from value,
+// which is the neighbor's name if there's none.
+func step(value interface{}, delta int64, neighbor string) (interface{}, string) {
+	next := ordinal(value) + delta
+	switch v := value.(type) {
+	case int64:
+		return next, ""
+	case string:
+		if next >= 0 && next <= utf8.MaxRune && utf8.ValidRune(rune(next)) {
+			return string(rune(next)), ""
+		}
+	case bool:
+		if next == 0 || next == 1 {
+			return next == 1, ""
+		}
+	case EnumValue:
+		if next >= 0 && next < int64(len(v.typ.values)) {
+			return EnumValue{typ: v.typ, ordinal: next}, ""
+		}
+	}
+	return nil, fmt.Sprintf("%s has no %s", formatValue(value), neighbor)
+}
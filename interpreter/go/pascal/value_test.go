@@ -0,0 +1,118 @@
+package pascal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinaryOp(t *testing.T) {
+	tests := map[string]struct {
+		givenOp  TokenKind
+		givenLhs interface{}
+		givenRhs interface{}
+		want     interface{}
+	}{
+		"integer plus":       {givenOp: Plus, givenLhs: int64(2), givenRhs: int64(3), want: int64(5)},
+		"integer div":        {givenOp: IntegerDiv, givenLhs: int64(7), givenRhs: int64(2), want: int64(3)},
+		"integer float div":  {givenOp: FloatDiv, givenLhs: int64(7), givenRhs: int64(2), want: 3.5},
+		"mixed mul":          {givenOp: Mul, givenLhs: int64(2), givenRhs: 1.5, want: 3.0},
+		"real minus":         {givenOp: Minus, givenLhs: 1.5, givenRhs: 0.5, want: 1.0},
+		"integer less":       {givenOp: Less, givenLhs: int64(1), givenRhs: int64(2), want: true},
+		"mixed equal":        {givenOp: Equal, givenLhs: 2.0, givenRhs: int64(2), want: true},
+		"real greater equal": {givenOp: GreaterEqual, givenLhs: 1.5, givenRhs: 2.5, want: false},
+		"boolean ordering":   {givenOp: Less, givenLhs: false, givenRhs: true, want: true},
+		"boolean not equal":  {givenOp: NotEqual, givenLhs: true, givenRhs: true, want: false},
+		"string concat":      {givenOp: Plus, givenLhs: "ab", givenRhs: "c", want: "abc"},
+		"string less":        {givenOp: Less, givenLhs: "ab", givenRhs: "b", want: true},
+		"string equal":       {givenOp: Equal, givenLhs: "a", givenRhs: "a", want: true},
+		"enum ordering":      {givenOp: Greater, givenLhs: EnumValue{ordinal: 2}, givenRhs: EnumValue{ordinal: 1}, want: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, binaryOp(tc.givenOp, tc.givenLhs, tc.givenRhs))
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := map[string]struct {
+		givenValue interface{}
+		want       string
+	}{
+		"integer":       {givenValue: int64(-42), want: "-42"},
+		"real":          {givenValue: 2.5, want: "2.5"},
+		"integral real": {givenValue: 3.0, want: "3"},
+		"boolean":       {givenValue: true, want: "TRUE"},
+		"string":        {givenValue: "it's", want: "it's"},
+		"enum":          {givenValue: EnumValue{typ: &EnumTypeSymbol{values: []string{"RED", "GREEN"}}, ordinal: 1}, want: "GREEN"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, formatValue(tc.givenValue))
+		})
+	}
+}
+
+func TestCopyValue(t *testing.T) {
+	point := &RecordTypeSymbol{name: "POINT", fields: []*VarSymbol{
+		NewVarSymbol("X", NewBuiltinTypeSymbol("INTEGER")),
+		NewVarSymbol("Y", NewBuiltinTypeSymbol("INTEGER")),
+	}}
+	original := newValue(&ArrayTypeSymbol{low: 1, high: 2, elemType: point}).(*ArrayValue)
+	first, _ := original.Get(1)
+	first.(*RecordValue).fields[0] = int64(1)
+
+	copied := copyValue(original).(*ArrayValue)
+	copiedFirst, _ := copied.Get(1)
+	copiedFirst.(*RecordValue).fields[0] = int64(2)
+
+	assert.Equal(t, "[(X: 1; Y: ?), (X: ?; Y: ?)]", original.String())
+	assert.Equal(t, "[(X: 2; Y: ?), (X: ?; Y: ?)]", copied.String())
+	assert.Equal(t, "1..2", copied.Bounds())
+	assert.False(t, copied.Set(3, nil))
+}
+
+func TestCallBuiltinFunction(t *testing.T) {
+	colors := &EnumTypeSymbol{name: "COLOR", values: []string{"RED", "GREEN", "BLUE"}}
+	tests := map[string]struct {
+		givenName   string
+		givenArgs   []interface{}
+		want        interface{}
+		wantProblem string
+	}{
+		"length":              {givenName: "LENGTH", givenArgs: []interface{}{"abc"}, want: int64(3)},
+		"copy":                {givenName: "COPY", givenArgs: []interface{}{"hello", int64(2), int64(3)}, want: "ell"},
+		"copy past the end":   {givenName: "COPY", givenArgs: []interface{}{"hello", int64(4), int64(9)}, want: "lo"},
+		"copy before start":   {givenName: "COPY", givenArgs: []interface{}{"hello", int64(-1), int64(2)}, want: "he"},
+		"copy negative count": {givenName: "COPY", givenArgs: []interface{}{"hello", int64(2), int64(-1)}, want: ""},
+		"pos":                 {givenName: "POS", givenArgs: []interface{}{"ll", "hello"}, want: int64(3)},
+		"pos not found":       {givenName: "POS", givenArgs: []interface{}{"x", "hello"}, want: int64(0)},
+		"ord":                 {givenName: "ORD", givenArgs: []interface{}{"A"}, want: int64(65)},
+		"chr":                 {givenName: "CHR", givenArgs: []interface{}{int64(97)}, want: "a"},
+		"chr out of range":    {givenName: "CHR", givenArgs: []interface{}{int64(-1)}, wantProblem: "no character has code -1"},
+		"chr of a surrogate":  {givenName: "CHR", givenArgs: []interface{}{int64(0xD800)}, wantProblem: "no character has code 55296"},
+		"length of runes":     {givenName: "LENGTH", givenArgs: []interface{}{"né"}, want: int64(2)},
+		"copy of runes":       {givenName: "COPY", givenArgs: []interface{}{"çà et là", int64(2), int64(4)}, want: "à et"},
+		"pos of runes":        {givenName: "POS", givenArgs: []interface{}{"là", "çà et là"}, want: int64(7)},
+		"ord of a rune":       {givenName: "ORD", givenArgs: []interface{}{"é"}, want: int64(233)},
+		"chr of a rune":       {givenName: "CHR", givenArgs: []interface{}{int64(8364)}, want: "€"},
+		"ord of enum":         {givenName: "ORD", givenArgs: []interface{}{EnumValue{typ: colors, ordinal: 2}}, want: int64(2)},
+		"succ":                {givenName: "SUCC", givenArgs: []interface{}{int64(-1)}, want: int64(0)},
+		"succ of char":        {givenName: "SUCC", givenArgs: []interface{}{"a"}, want: "b"},
+		"succ of enum":        {givenName: "SUCC", givenArgs: []interface{}{EnumValue{typ: colors}}, want: EnumValue{typ: colors, ordinal: 1}},
+		"succ of last":        {givenName: "SUCC", givenArgs: []interface{}{EnumValue{typ: colors, ordinal: 2}}, wantProblem: "BLUE has no successor"},
+		"pred of boolean":     {givenName: "PRED", givenArgs: []interface{}{true}, want: false},
+		"pred of first":       {givenName: "PRED", givenArgs: []interface{}{false}, wantProblem: "FALSE has no predecessor"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			value, problem := callBuiltinFunction(tc.givenName, tc.givenArgs)
+			assert.Equal(t, tc.want, value)
+			assert.Equal(t, tc.wantProblem, problem)
+		})
+	}
+}
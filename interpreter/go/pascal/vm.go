@@ -0,0 +1,357 @@
+package pascal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+)
+
+// NewVM creates a VM, READLN reads from reader and WRITE/WRITELN write to writer.
+func NewVM(reader io.Reader, writer io.Writer) *VM {
+	return &VM{
+		reader: bufio.NewReader(reader),
+		writer: writer,
+		limits: Limits{}.withDefaults(),
+		ctx:    context.Background(),
+	}
+}
+
+// frame is the activation of a routine, staticLink is the frame of the
+// routine it's nested in.
+type frame struct {
+	routine    *Routine
+	slots      []interface{}
+	staticLink *frame
+	pc         int
+	// callToken is where the routine was called from.
+	callToken *Token
+	// extra are the scalars its ARRAY and RECORD values hold beyond their
+	// slots, counted against the memory limit.
+	extra int
+}
+
+// slotRef is the value of a VAR parameter's slot, it refers to the slot
+// of the variable, the element or the field passed by the caller.
+type slotRef struct {
+	slot *interface{}
+}
+
+// VM runs Bytecode on an operand stack, it's an alternative backend to the
+// Interpreter producing the same results and runtime errors.
+type VM struct {
+	reader *bufio.Reader
+	writer io.Writer
+	limits Limits
+	ctx    context.Context
+	frames []*frame
+	stack  []interface{}
+	// steps is the number of instructions run, slots the number of slots
+	// of the frames.
+	steps int
+	slots int
+	// optimizer rewrites the tree before it's compiled, if set.
+	optimizer *Optimizer
+}
+
+func (vm *VM) Interpret(source string) (err error) {
+	program, err := Compile(source)
+	if err != nil {
+		return
+	}
+	if program, err = program.optimize(vm.optimizer); err != nil {
+		return
+	}
+	return vm.run(context.Background(), program.root)
+}
+
+// run compiles the checked tree of a program to bytecode and runs it until
+// it ends or ctx is done.
+func (vm *VM) run(ctx context.Context, root ASTNode) (err error) {
+	vm.ctx = ctx
+	bytecode, err := NewCompiler().Compile(root)
+	if err != nil {
+		return
+	}
+	return vm.Run(bytecode)
+}
+
+func (vm *VM) Run(bytecode *Bytecode) (err error) {
+	vm.frames = vm.frames[:0]
+	vm.stack = vm.stack[:0]
+	vm.steps, vm.slots = 0, 0
+	// NOTE: runtime errors are returned, a panic means a bug in the compiler
+	// or the VM, still it must not bring down the process embedding it.
+	defer func() {
+		if r := recover(); r != nil {
+			err = Error{Code: ErrorCodeInternal, Module: ModuleInterpreter, Message: fmt.Sprint(r), Suggestion: runtimeSuggestion(ErrorCodeInternal), Trace: vm.trace()}
+		}
+	}()
+
+	vm.frames = append(vm.frames, &frame{
+		routine: bytecode.Routines[0],
+		slots:   make([]interface{}, bytecode.Routines[0].NumSlots),
+	})
+	vm.slots = bytecode.Routines[0].NumSlots
+	for len(vm.frames) > 0 {
+		f := vm.frames[len(vm.frames)-1]
+		if err = vm.step(f); err != nil {
+			return
+		}
+		ins := f.routine.Code[f.pc]
+		f.pc++
+
+		switch ins.Op {
+		case OpConst:
+			vm.push(bytecode.Consts[ins.A])
+		case OpLoad:
+			value := vm.frameAt(ins.A).slots[ins.B]
+			if ref, ok := value.(*slotRef); ok {
+				value = *ref.slot
+			}
+			if value == nil {
+				return vm.error(ErrorCodeUninitializedVariable, ins.Token, "variable is read before it is assigned")
+			}
+			vm.push(value)
+		case OpStore:
+			slots := vm.frameAt(ins.A).slots
+			if ref, ok := slots[ins.B].(*slotRef); ok {
+				*ref.slot = copyValue(vm.pop())
+			} else {
+				slots[ins.B] = copyValue(vm.pop())
+			}
+		case OpRef:
+			target := vm.frameAt(ins.A)
+			if ref, ok := target.slots[ins.B].(*slotRef); ok {
+				// a VAR parameter passed on refers to the same variable.
+				vm.push(ref)
+			} else {
+				vm.push(&slotRef{slot: &target.slots[ins.B]})
+			}
+		case OpRefIndex:
+			index, array := vm.pop().(int64), vm.pop().(*ArrayValue)
+			slot := array.Slot(index)
+			if slot == nil {
+				return vm.error(ErrorCodeIndexOutOfRange, ins.Token, fmt.Sprintf("index %d out of range %s", index, array.Bounds()))
+			}
+			vm.push(&slotRef{slot: slot})
+		case OpRefField:
+			vm.push(&slotRef{slot: &vm.pop().(*RecordValue).fields[ins.A]})
+		case OpIndex:
+			index, array := vm.pop().(int64), vm.pop().(*ArrayValue)
+			value, inRange := array.Get(index)
+			if !inRange {
+				return vm.error(ErrorCodeIndexOutOfRange, ins.Token, fmt.Sprintf("index %d out of range %s", index, array.Bounds()))
+			}
+			if value == nil {
+				return vm.error(ErrorCodeUninitializedVariable, ins.Token, "element is read before it is assigned")
+			}
+			vm.push(value)
+		case OpStoreIndex:
+			index, array, value := vm.pop().(int64), vm.pop().(*ArrayValue), vm.pop()
+			if !array.Set(index, copyValue(value)) {
+				return vm.error(ErrorCodeIndexOutOfRange, ins.Token, fmt.Sprintf("index %d out of range %s", index, array.Bounds()))
+			}
+		case OpField:
+			value := vm.pop().(*RecordValue).fields[ins.A]
+			if value == nil {
+				return vm.error(ErrorCodeUninitializedVariable, ins.Token, "field is read before it is assigned")
+			}
+			vm.push(value)
+		case OpStoreField:
+			record, value := vm.pop().(*RecordValue), vm.pop()
+			record.fields[ins.A] = copyValue(value)
+		case OpNew:
+			typ := bytecode.Consts[ins.A].(Symbol)
+			if problem := allocation(typ, vm.slots, vm.limits.Memory); problem != "" {
+				return vm.error(ErrorCodeMemoryLimitExceeded, ins.Token, problem)
+			}
+			size, _ := sizeOf(typ)
+			f.extra += size - 1
+			vm.slots += size - 1
+			vm.push(newValue(typ))
+		case OpBuiltin:
+			args := vm.stack[len(vm.stack)-ins.B:]
+			vm.stack = vm.stack[:len(vm.stack)-ins.B]
+			value, problem := callBuiltinFunction(builtinFunctions[ins.A], args)
+			if problem != "" {
+				return vm.error(ErrorCodeInvalidArgument, ins.Token, problem)
+			}
+			vm.push(value)
+		case OpToReal:
+			vm.push(float64(vm.pop().(int64)))
+		case OpNeg:
+			vm.push(negate(vm.pop()))
+		case OpNot:
+			vm.push(!vm.pop().(bool))
+		case OpBinary:
+			rhs, lhs := vm.pop(), vm.pop()
+			op := TokenKind(ins.A)
+			if (op == IntegerDiv || op == FloatDiv) && isZero(rhs) {
+				return vm.error(ErrorCodeDivisionByZero, ins.Token, "division by zero")
+			}
+			vm.push(binaryOp(op, lhs, rhs))
+		case OpJump:
+			f.pc = ins.A
+		case OpJumpIfFalse:
+			if !vm.pop().(bool) {
+				f.pc = ins.A
+			}
+		case OpCall:
+			if err = vm.call(bytecode.Routines[ins.A], vm.frameAt(ins.B), ins.Token); err != nil {
+				return
+			}
+		case OpReturn:
+			if f.routine.Kind == ARKindFunction {
+				// the result is whatever was last assigned to the function name.
+				result := f.slots[f.routine.ResultSlot]
+				if result == nil {
+					return vm.error(ErrorCodeUninitializedVariable, f.callToken, "function result is not set")
+				}
+				vm.popFrame()
+				vm.push(result)
+			} else {
+				vm.popFrame()
+			}
+		case OpWrite:
+			values := vm.stack[len(vm.stack)-ins.A:]
+			vm.stack = vm.stack[:len(vm.stack)-ins.A]
+			for _, value := range values {
+				if _, err = io.WriteString(vm.writer, formatValue(value)); err != nil {
+					return
+				}
+			}
+			if ins.B == 1 {
+				if _, err = io.WriteString(vm.writer, "\n"); err != nil {
+					return
+				}
+			}
+		case OpReadLine:
+			fields, problem, readErr := readFields(vm.reader, ins.A, ins.B)
+			if readErr != nil {
+				return readErr
+			}
+			if problem != "" {
+				return vm.error(ErrorCodeInvalidInput, ins.Token, problem)
+			}
+			for i := ins.A - 1; i >= 0; i-- {
+				vm.push(fields[i])
+			}
+		case OpParse:
+			field := vm.pop().(string)
+			typ := NewBuiltinTypeSymbol(TokenNames[TokenKind(ins.A)])
+			value, parseErr := parseValue(typ, field)
+			if parseErr != nil {
+				return vm.error(ErrorCodeInvalidInput, ins.Token, fmt.Sprintf("invalid %s %q", typ, field))
+			}
+			vm.push(value)
+		default:
+			return vm.stop(f, ErrorCodeInternal, fmt.Sprintf("unknown instruction %s", ins))
+		}
+	}
+	return
+}
+
+// call pushes the frame of routine, its arguments are popped from the stack.
+func (vm *VM) call(routine *Routine, staticLink *frame, token *Token) error {
+	if len(vm.frames) >= vm.limits.CallDepth {
+		return vm.error(ErrorCodeStackOverflow, token, fmt.Sprintf("more than %d nested calls", vm.limits.CallDepth))
+	}
+	slots := make([]interface{}, routine.NumSlots)
+	extra := 0
+	for i, arg := range vm.stack[len(vm.stack)-routine.NumParams:] {
+		// arguments are passed by value, unless they're a slotRef.
+		slots[i] = copyValue(arg)
+		extra += sizeOfValue(slots[i]) - 1
+	}
+	vm.stack = vm.stack[:len(vm.stack)-routine.NumParams]
+	vm.frames = append(vm.frames, &frame{
+		routine:    routine,
+		slots:      slots,
+		staticLink: staticLink,
+		callToken:  token,
+		extra:      extra,
+	})
+	vm.slots += routine.NumSlots + extra
+	return nil
+}
+
+func (vm *VM) popFrame() {
+	f := vm.frames[len(vm.frames)-1]
+	vm.slots -= len(f.slots) + f.extra
+	vm.frames = vm.frames[:len(vm.frames)-1]
+}
+
+// step counts the next instruction of f before it runs, the run stops there
+// if its context is done or a limit is exceeded.
+func (vm *VM) step(f *frame) error {
+	select {
+	case <-vm.ctx.Done():
+		return vm.stop(f, ErrorCodeCanceled, vm.ctx.Err().Error())
+	default:
+	}
+	if vm.steps++; vm.limits.Steps > 0 && vm.steps > vm.limits.Steps {
+		return vm.stop(f, ErrorCodeStepLimitExceeded, fmt.Sprintf("more than %d steps", vm.limits.Steps))
+	}
+	if vm.limits.Memory > 0 && vm.slots > vm.limits.Memory {
+		return vm.stop(f, ErrorCodeMemoryLimitExceeded, fmt.Sprintf("more than %d variables", vm.limits.Memory))
+	}
+	return nil
+}
+
+// stop fails the run before the next instruction of f. Not every
+// instruction has a token, so the error is located at the last one before
+// it or at the call, else it has no location.
+func (vm *VM) stop(f *frame, code ErrorCode, message string) error {
+	token := f.callToken
+	for pc := f.pc; pc >= 0; pc-- {
+		if f.routine.Code[pc].Token != nil {
+			token = f.routine.Code[pc].Token
+			break
+		}
+	}
+	if token == nil {
+		return Error{Code: code, Module: ModuleInterpreter, Message: message, Suggestion: runtimeSuggestion(code), Trace: vm.trace()}
+	}
+	return vm.error(code, token, message)
+}
+
+// frameAt follows hops static links from the current frame.
+func (vm *VM) frameAt(hops int) *frame {
+	f := vm.frames[len(vm.frames)-1]
+	for ; hops > 0; hops-- {
+		f = f.staticLink
+	}
+	return f
+}
+
+func (vm *VM) push(value interface{}) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() (value interface{}) {
+	value = vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return
+}
+
+// trace describes the frames, innermost first, as Interpreter.trace does.
+func (vm *VM) trace() []string {
+	trace := make([]string, 0, len(vm.frames))
+	for i := len(vm.frames) - 1; i >= 0; i-- {
+		routine := vm.frames[i].routine
+		trace = append(trace, fmt.Sprintf("%d: %s %s", routine.Level, routine.Kind, routine.Name))
+	}
+	return trace
+}
+
+func (vm *VM) error(code ErrorCode, token *Token, message string) error {
+	return Error{
+		Code:       code,
+		Module:     ModuleInterpreter,
+		Message:    fmt.Sprintf("%s,token:%s", message, token),
+		Span:       token.Span(),
+		Suggestion: runtimeSuggestion(code),
+		Trace:      vm.trace(),
+	}
+}
@@ -0,0 +1,74 @@
+package pascal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVM_Interpret(t *testing.T) {
+	for name, tc := range interpretTests {
+		t.Run(name, func(t *testing.T) {
+			output := bytes.NewBuffer(nil)
+			vm := NewVM(strings.NewReader(tc.givenInput), output)
+			assert.NoError(t, vm.Interpret(tc.givenProgram))
+			assert.Equal(t, tc.wantOutput, output.String())
+		})
+	}
+
+	t.Run("runtime errors", func(t *testing.T) {
+		for name, tc := range runtimeErrorTests {
+			t.Run(name, func(t *testing.T) {
+				output := bytes.NewBuffer(nil)
+				err := NewVM(nil, output).Interpret(tc.givenProgram)
+				assert.Equal(t, tc.wantError, err.Error())
+				assert.Equal(t, tc.wantOutput, output.String())
+				if tc.wantTrace != nil {
+					assert.Equal(t, tc.wantTrace, err.(Error).Trace)
+				} else {
+					assert.Len(t, err.(Error).Trace, maxCallDepth)
+				}
+			})
+		}
+	})
+
+	t.Run("nested routines reach enclosing variables through static links", func(t *testing.T) {
+		output := bytes.NewBuffer(nil)
+		err := NewVM(nil, output).Interpret(`
+			program Main;
+				var total : integer;
+				procedure Outer(n : integer);
+					var step : integer;
+					procedure Inner;
+					begin
+						total := total + step;
+						if total < n then Inner
+					end;
+				begin
+					step := 2;
+					Inner
+				end;
+			begin
+				total := 0;
+				Outer(7);
+				writeln(total)
+			end.
+		`)
+		assert.NoError(t, err)
+		assert.Equal(t, "8\n", output.String())
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		err := NewVM(strings.NewReader("1 x"), ioutil.Discard).Interpret(`
+			program Main;
+				var a : integer; r : real;
+			begin
+				readln(a, r)
+			end.
+		`)
+		assert.Equal(t, `<Error: module=Interpreter,code=InvalidInput,message="invalid REAL "x",token:(kind=ID,value=READLN,pos=(5,5))">`, err.Error())
+	})
+}
@@ -0,0 +1,182 @@
+package pascal
+
+func Walk(visitor Visitor, node ASTNode) (err error) {
+	ok, err := visitor.Before(node)
+	if err != nil || !ok {
+		return
+	}
+
+	switch n := node.(type) {
+	case *ProgramNode:
+		if err = Walk(visitor, n.block); err != nil {
+			return
+		}
+	case *UnitNode:
+		// NOTE: the headings are declared in full in the block.
+		if err = Walk(visitor, n.block); err != nil {
+			return
+		}
+	case *BlockNode:
+		for _, decl := range n.declarations {
+			if err = Walk(visitor, decl); err != nil {
+				return
+			}
+		}
+		if err = Walk(visitor, n.compoundStmt); err != nil {
+			return
+		}
+	case *VarDeclNode:
+		if err = Walk(visitor, n.varNode); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.typNode); err != nil {
+			return
+		}
+	case *ConstDeclNode:
+		if err = Walk(visitor, n.value); err != nil {
+			return
+		}
+	case *TypeDeclNode:
+		if err = Walk(visitor, n.typNode); err != nil {
+			return
+		}
+	case *ArrayTypeNode:
+		if err = Walk(visitor, n.low); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.high); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.elemType); err != nil {
+			return
+		}
+	case *RecordTypeNode:
+		for _, field := range n.fields {
+			if err = Walk(visitor, field); err != nil {
+				return
+			}
+		}
+	case *ProcedureDeclNode:
+		for _, param := range n.params {
+			if err = Walk(visitor, param); err != nil {
+				return
+			}
+		}
+		if err = Walk(visitor, n.block); err != nil {
+			return
+		}
+	case *FunctionDeclNode:
+		for _, param := range n.params {
+			if err = Walk(visitor, param); err != nil {
+				return
+			}
+		}
+		if err = Walk(visitor, n.returnType); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.block); err != nil {
+			return
+		}
+	case *ParamNode:
+		if err = Walk(visitor, n.varNode); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.typNode); err != nil {
+			return
+		}
+	case *CompoundNode:
+		for _, child := range n.children {
+			if err = Walk(visitor, child); err != nil {
+				return
+			}
+		}
+	case *ProcedureCallNode:
+		// NOTE: builtin procedures have no block to step into.
+		if n.procSymbol == nil {
+			break
+		}
+		if err = Walk(visitor, n.procSymbol.blockNode); err != nil {
+			return
+		}
+	case *FunctionCallNode:
+		for _, actualParam := range n.actualParams {
+			if err = Walk(visitor, actualParam); err != nil {
+				return
+			}
+		}
+	case *AssignNode:
+		if err = Walk(visitor, n.left); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.right); err != nil {
+			return
+		}
+	case *IfNode:
+		if err = Walk(visitor, n.condition); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.thenStmt); err != nil {
+			return
+		}
+		if n.elseStmt != nil {
+			if err = Walk(visitor, n.elseStmt); err != nil {
+				return
+			}
+		}
+	case *WhileNode:
+		if err = Walk(visitor, n.condition); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.body); err != nil {
+			return
+		}
+	case *RepeatNode:
+		for _, child := range n.children {
+			if err = Walk(visitor, child); err != nil {
+				return
+			}
+		}
+		if err = Walk(visitor, n.condition); err != nil {
+			return
+		}
+	case *ForNode:
+		if err = Walk(visitor, n.varNode); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.start); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.end); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.body); err != nil {
+			return
+		}
+	case *UnaryOpNode:
+		if err = Walk(visitor, n.operand); err != nil {
+			return
+		}
+	case *BinOpNode:
+		if err = Walk(visitor, n.left); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.right); err != nil {
+			return
+		}
+	case *IndexNode:
+		if err = Walk(visitor, n.base); err != nil {
+			return
+		}
+		if err = Walk(visitor, n.index); err != nil {
+			return
+		}
+	case *FieldNode:
+		if err = Walk(visitor, n.base); err != nil {
+			return
+		}
+	case *NoopNode:
+		// do nothing
+	}
+
+	return visitor.After(node)
+}